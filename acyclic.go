@@ -0,0 +1,126 @@
+package graff
+
+// AddEdgeChecked adds the edge to the graph like AddEdge, unless it would
+// close a cycle, in which case the graph is left unchanged and a
+// CyclicGraphError is returned holding the would-be cycle, starting with the
+// edge's source and target. A self-loop is a cycle of a single node.
+//
+// The edge closes a cycle if a path leads from its target back to its
+// source, which is looked for by a bidirectional breadth-first search from
+// both ends that always expands the smaller frontier, so it stops as soon as
+// either the descendants of the target or the ancestors of the source are
+// exhausted. The check is cheap when either set is small, as for endpoints
+// far apart in the order of a layered graph, and constant if the graph's
+// ReachabilityIndex is fresh.
+func (g *DirectedGraph) AddEdgeChecked(from Node, to Node) error {
+	if cycle := g.closesCycle(from, to); cycle != nil {
+		return &CyclicGraphError{Cycle: cycle}
+	}
+	g.AddEdge(from, to)
+	return nil
+}
+
+// AddHappensBeforeChecked records that the earlier event happens before the
+// later one like AddHappensBefore, unless it would create a causal cycle, in
+// which case the graph is left unchanged and a CyclicGraphError is returned
+// holding the would-be cycle, each event happening before the next, starting
+// with the earlier and the later event. See DirectedGraph.AddEdgeChecked.
+func (g *EventGraph) AddHappensBeforeChecked(earlier Node, later Node) error {
+	return g.DirectedGraph.AddEdgeChecked(earlier, later)
+}
+
+// AddEdgeChecked adds the edge to the graph like AddEdge, unless it would
+// create a causal cycle, see AddHappensBeforeChecked.
+func (g *EventGraph) AddEdgeChecked(from Node, to Node) error {
+	return g.AddHappensBeforeChecked(to, from)
+}
+
+// closesCycle returns the cycle which adding the edge would close, starting
+// with its source and target, or nil if there is none.
+func (g *DirectedGraph) closesCycle(from Node, to Node) []Node {
+	if from == to {
+		return []Node{from}
+	}
+	if !g.NodeExists(from) || !g.NodeExists(to) {
+		return nil
+	}
+	if x := g.freshReachability(); x != nil {
+		if reaches, _ := x.Reaches(to, from); !reaches {
+			return nil
+		}
+	}
+
+	// forward maps the descendants of the target to their predecessor on a
+	// path from it, and backward the ancestors of the source to their
+	// successor on a path to it
+	forward := map[Node]Node{to: nil}
+	backward := map[Node]Node{from: nil}
+	forwardFrontier := []Node{to}
+	backwardFrontier := []Node{from}
+
+	// path returns the cycle through the node where the searches met
+	path := func(meet Node) []Node {
+		reversed := make([]Node, 0)
+		for node := meet; node != to; node = forward[node] {
+			reversed = append(reversed, node)
+		}
+		cycle := []Node{from, to}
+		for i := len(reversed) - 1; i >= 0; i-- {
+			cycle = append(cycle, reversed[i])
+		}
+		for node := backward[meet]; node != nil && node != from; node = backward[node] {
+			cycle = append(cycle, node)
+		}
+		if meet == from {
+			cycle = cycle[:len(cycle)-1]
+		}
+		return cycle
+	}
+
+	for len(forwardFrontier) > 0 && len(backwardFrontier) > 0 {
+		var meet Node
+		met := false
+		if len(forwardFrontier) <= len(backwardFrontier) {
+			next := make([]Node, 0)
+			for _, node := range forwardFrontier {
+				g.EachOutgoing(node, func(outgoing Node) bool {
+					if _, ok := forward[outgoing]; ok {
+						return true
+					}
+					forward[outgoing] = node
+					if _, ok := backward[outgoing]; ok {
+						meet, met = outgoing, true
+						return false
+					}
+					next = append(next, outgoing)
+					return true
+				})
+				if met {
+					return path(meet)
+				}
+			}
+			forwardFrontier = next
+		} else {
+			next := make([]Node, 0)
+			for _, node := range backwardFrontier {
+				g.EachIncoming(node, func(incoming Node) bool {
+					if _, ok := backward[incoming]; ok {
+						return true
+					}
+					backward[incoming] = node
+					if _, ok := forward[incoming]; ok {
+						meet, met = incoming, true
+						return false
+					}
+					next = append(next, incoming)
+					return true
+				})
+				if met {
+					return path(meet)
+				}
+			}
+			backwardFrontier = next
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package graff
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddHappensBeforeChecked(t *testing.T) {
+	g := NewEventGraph()
+	g.AddHappensBefore("a", "b")
+	g.AddHappensBefore("b", "c")
+	count := g.EdgeCount()
+
+	err := g.AddHappensBeforeChecked("c", "a")
+	var cyclic *CyclicGraphError
+	if !errors.As(err, &cyclic) {
+		t.Fatalf("closing the cycle returned %v", err)
+	}
+	if want := []Node{"c", "a", "b"}; len(cyclic.Cycle) != 3 || cyclic.Cycle[0] != want[0] || cyclic.Cycle[1] != want[1] || cyclic.Cycle[2] != want[2] {
+		t.Errorf("the cycle is %v, want %v", cyclic.Cycle, want)
+	}
+	if g.EdgeCount() != count || g.HappensBefore("c", "a") {
+		t.Error("the rejected edge was added")
+	}
+	if err := g.AddHappensBeforeChecked("a", "a"); !errors.As(err, &cyclic) {
+		t.Errorf("a self-loop returned %v", err)
+	}
+	if err := g.AddHappensBeforeChecked("a", "c"); err != nil {
+		t.Error(err)
+	}
+}
+
+// BenchmarkAddEdgeChecked checks edges whose endpoints are far apart in the
+// order, where one of the searches runs out after a few steps, adjacent in
+// the middle of it, and reversed so that they close a cycle, compared to
+// adding an edge to a small graph.
+func BenchmarkAddEdgeChecked(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	tests := []struct {
+		name     string
+		from, to Node
+	}{
+		{"far", 10, 9990},
+		{"adjacent", 5000, 5001},
+		{"cycle", 9990, 10},
+	}
+	for _, test := range tests {
+		test := test
+		b.Run(test.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				g.closesCycle(test.from, test.to)
+			}
+		})
+	}
+	b.Run("AddEdge", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := NewDirectedGraph()
+			h.AddEdge(i, i+1)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package graff
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultAllPairsLimit is the maximum number of nodes AllPairsShortestPaths
+// accepts unless WithAllPairsLimit is used.
+const DefaultAllPairsLimit = 2000
+
+// AllPairsOption configures AllPairsShortestPaths.
+type AllPairsOption func(*allPairsConfig)
+
+type allPairsConfig struct {
+	limit int
+}
+
+// WithAllPairsLimit sets the maximum number of nodes AllPairsShortestPaths
+// accepts.
+func WithAllPairsLimit(limit int) AllPairsOption {
+	return func(c *allPairsConfig) {
+		c.limit = limit
+	}
+}
+
+// AllPairsShortestPaths computes the length of the shortest path between
+// every pair of nodes using the Floyd-Warshall algorithm, which permits
+// negative edge weights. Edges carrying a weight attribute weigh its value,
+// other edges weigh 1, see WeightAttr. Every node reaches itself at a
+// distance of 0, and pairs without a path are left out. The rows and
+// columns correspond to those of AdjacencyMatrix.
+// As the algorithm takes time cubic and memory quadratic in the number of
+// nodes, graphs exceeding the limit (see WithAllPairsLimit) are rejected with
+// an error wrapping ErrTooLarge rather than falling back to another algorithm.
+// If the graph contains a cycle of negative weight a NegativeCycleError is
+// returned.
+// See https://en.wikipedia.org/wiki/Floyd–Warshall_algorithm
+func (g *DirectedGraph) AllPairsShortestPaths(opts ...AllPairsOption) (map[Node]map[Node]float64, error) {
+	config := &allPairsConfig{limit: DefaultAllPairsLimit}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if g.NodeCount() > config.limit {
+		return nil, fmt.Errorf("%w: %d nodes exceed the limit of %d", ErrTooLarge, g.NodeCount(), config.limit)
+	}
+
+	nodes := g.Nodes()
+	n := len(nodes)
+	index := make(map[Node]int, n)
+	for i, node := range nodes {
+		index[node] = i
+	}
+
+	// the distances as a flat row-major matrix, with unreachable pairs at
+	// positive infinity
+	dist := make([]float64, n*n)
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	for i, from := range nodes {
+		dist[i*n+i] = 0
+		g.EachOutgoing(from, func(to Node) bool {
+			j := index[to]
+			if weight := g.edgeWeight(from, to); weight < dist[i*n+j] {
+				dist[i*n+j] = weight
+			}
+			return true
+		})
+	}
+
+	for k := 0; k < n; k++ {
+		rowK := dist[k*n : (k+1)*n]
+		for i := 0; i < n; i++ {
+			ik := dist[i*n+k]
+			if math.IsInf(ik, 1) {
+				continue
+			}
+			rowI := dist[i*n : (i+1)*n]
+			for j, kj := range rowK {
+				if ik+kj < rowI[j] {
+					rowI[j] = ik + kj
+				}
+			}
+		}
+	}
+
+	for i, node := range nodes {
+		if dist[i*n+i] < 0 {
+			// let the single-source search extract the cycle through the node
+			if _, _, err := g.BellmanFord(node); err != nil {
+				return nil, err
+			}
+			return nil, &NegativeCycleError{Cycle: []Node{node}}
+		}
+	}
+
+	result := make(map[Node]map[Node]float64, n)
+	for i, from := range nodes {
+		row := make(map[Node]float64)
+		for j, to := range nodes {
+			if d := dist[i*n+j]; !math.IsInf(d, 1) {
+				row[to] = d
+			}
+		}
+		result[from] = row
+	}
+	return result, nil
+}
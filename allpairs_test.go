@@ -0,0 +1,46 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestAllPairsShortestPaths(t *testing.T) {
+	// negative weights on a DAG cannot form a negative cycle
+	g := layeredGraph(40, 3)
+	random := rand.New(rand.NewSource(1))
+	for _, from := range g.Nodes() {
+		for _, to := range g.OutgoingEdges(from) {
+			g.SetEdgeAttr(from, to, WeightAttr, float64(random.Intn(10)-3))
+		}
+	}
+
+	all, err := g.AllPairsShortestPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range g.Nodes() {
+		want, _, err := g.BellmanFord(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(all[source], want) {
+			t.Errorf("got the distances %v from %v, want %v", all[source], source, want)
+		}
+	}
+}
+
+func TestAllPairsShortestPathsErrors(t *testing.T) {
+	g := layeredGraph(40, 3)
+	if _, err := g.AllPairsShortestPaths(WithAllPairsLimit(39)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got %v, want ErrTooLarge", err)
+	}
+
+	g.AddEdge(39, 0)
+	g.SetEdgeAttr(39, 0, WeightAttr, -100.0)
+	if _, err := g.AllPairsShortestPaths(); !errors.Is(err, ErrNegativeCycle) {
+		t.Errorf("got %v, want ErrNegativeCycle", err)
+	}
+}
@@ -0,0 +1,131 @@
+package graff
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultAncestorCacheSize is the number of entries an AncestorCache holds
+// unless configured otherwise.
+const DefaultAncestorCacheSize = 1024
+
+// AncestorCache answers repeated Ancestors queries for a slowly changing
+// graph from a cache holding the results for the most recently used nodes.
+// The cache of a DirectedGraph or EventGraph is invalidated by comparing the
+// graph's generation, which every mutation advances, and is currently flushed
+// entirely on any mutation. Other graphs have no generation, so their cache
+// must be purged when they change, see Purge.
+// It may be used by multiple goroutines at once, as long as the graph is not
+// mutated concurrently.
+type AncestorCache struct {
+	graph    Directed
+	capacity int
+
+	mu         sync.Mutex
+	generation uint64
+	entries    map[Node]*list.Element
+	// recent lists the entries, the most recently used first
+	recent *list.List
+}
+
+type ancestorEntry struct {
+	node      Node
+	ancestors []Node
+}
+
+// NewAncestorCache creates a cache of the ancestors of at most capacity of
+// the graph's nodes, or DefaultAncestorCacheSize if capacity is zero or less.
+func NewAncestorCache(graph Directed, capacity int) *AncestorCache {
+	if capacity <= 0 {
+		capacity = DefaultAncestorCacheSize
+	}
+	graph = directedView(graph)
+	return &AncestorCache{
+		graph:      graph,
+		capacity:   capacity,
+		generation: generationOf(graph),
+		entries:    make(map[Node]*list.Element),
+		recent:     list.New(),
+	}
+}
+
+// Get returns the ancestors of the node like DirectedGraph.Ancestors, from
+// the cache if they were computed since the graph was last mutated. The
+// result is shared with other callers and must not be modified.
+// An error wrapping ErrNodeNotFound is returned if the node does not exist.
+func (c *AncestorCache) Get(node Node) ([]Node, error) {
+	c.mu.Lock()
+	c.validate()
+	if element, ok := c.entries[node]; ok {
+		c.recent.MoveToFront(element)
+		c.mu.Unlock()
+		return element.Value.(*ancestorEntry).ancestors, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	ancestors, err := ancestorsOf(c.graph, node)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[node]; !ok && generation == c.generation {
+		c.add(node, ancestors)
+	}
+	return ancestors, nil
+}
+
+// Len returns the number of cached entries.
+func (c *AncestorCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.validate()
+	return c.recent.Len()
+}
+
+// Purge removes all cached entries.
+func (c *AncestorCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidate()
+}
+
+// validate invalidates the cached entries if the graph was mutated since
+// they were computed.
+func (c *AncestorCache) validate() {
+	if generation := generationOf(c.graph); c.generation != generation {
+		c.invalidate()
+		c.generation = generation
+	}
+}
+
+// invalidate removes the entries which may be affected by the mutations
+// since the cache's generation, which for now are all of them.
+func (c *AncestorCache) invalidate() {
+	for node := range c.entries {
+		delete(c.entries, node)
+	}
+	c.recent.Init()
+}
+
+// add caches the node's ancestors, evicting the least recently used entry
+// if the cache is full.
+func (c *AncestorCache) add(node Node, ancestors []Node) {
+	if c.recent.Len() >= c.capacity {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*ancestorEntry).node)
+	}
+	c.entries[node] = c.recent.PushFront(&ancestorEntry{node: node, ancestors: ancestors})
+}
+
+// generationOf returns the generation of a DirectedGraph, and zero for other
+// graphs, which do not track their mutations.
+func generationOf(d Directed) uint64 {
+	if g, ok := d.(*DirectedGraph); ok {
+		return g.generation
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package graff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAncestorCacheInvalidation(t *testing.T) {
+	g := NewEventGraph()
+	g.AddHappensBefore("a", "b")
+	g.AddHappensBefore("b", "c")
+	c := NewAncestorCache(g, 2)
+
+	ancestors, err := c.Get("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Node{"b", "a"}; !reflect.DeepEqual(ancestors, want) {
+		t.Errorf("the ancestors of c are %v, want %v", ancestors, want)
+	}
+	c.Get("b")
+	c.Get("a")
+	if c.Len() != 2 {
+		t.Errorf("the cache holds %d entries, want 2", c.Len())
+	}
+
+	g.AddHappensBefore("x", "b")
+	if c.Len() != 0 {
+		t.Errorf("the cache holds %d entries after a mutation", c.Len())
+	}
+	ancestors, _ = c.Get("c")
+	if len(ancestors) != 3 {
+		t.Errorf("the ancestors of c are %v after x was added", ancestors)
+	}
+	if _, err := c.Get("missing"); err == nil {
+		t.Error("a missing node has ancestors")
+	}
+}
+
+func BenchmarkAncestorCache(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	hot := []Node{9999, 9000, 8000, 7000}
+	b.Run("Ancestors", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := g.Ancestors(hot[i%len(hot)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("AncestorCache", func(b *testing.B) {
+		c := NewAncestorCache(g, 0)
+		for i := 0; i < b.N; i++ {
+			if _, err := c.Get(hot[i%len(hot)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
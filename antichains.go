@@ -0,0 +1,159 @@
+package graff
+
+import (
+	"math/bits"
+)
+
+// MaximalAntichains enumerates the maximal antichains of the graph, i.e.
+// the sets of pairwise incomparable nodes, neither of which reaches the
+// other, to which no further node can be added. They are found as the
+// maximal cliques of the incomparability graph using the Bron–Kerbosch
+// algorithm with pivoting, listing the nodes of every antichain in the
+// graph's node order. Enumeration stops after max antichains, in which case
+// the antichains found so far are returned together with ErrTruncated.
+// A max of zero or less means no limit, but as the number of maximal
+// antichains can grow exponentially with the graph size, setting one is
+// strongly advised.
+// The graph's reachability index is built unless a fresh one exists, see
+// BuildReachabilityIndex, so a CyclicGraphError is returned if the graph
+// contains a cycle.
+// See https://en.wikipedia.org/wiki/Bron–Kerbosch_algorithm
+func (g *DirectedGraph) MaximalAntichains(max int) ([][]Node, error) {
+	x := g.freshReachability()
+	if x == nil {
+		var err error
+		if x, err = g.BuildReachabilityIndex(); err != nil {
+			return nil, err
+		}
+	}
+
+	nodes := g.Nodes()
+	words := (len(nodes) + 63) / 64
+	// incomparable holds the neighbours of every node in the
+	// incomparability graph as a bit set
+	incomparable := make([]bitSet, len(nodes))
+	for i, a := range nodes {
+		incomparable[i] = make(bitSet, words)
+		for j, b := range nodes {
+			if i != j && !x.related(a, b) {
+				incomparable[i].add(j)
+			}
+		}
+	}
+
+	e := &antichainEnumerator{
+		nodes:        nodes,
+		incomparable: incomparable,
+		results:      make([][]Node, 0),
+		max:          max,
+	}
+	candidates := make(bitSet, words)
+	for i := range nodes {
+		candidates.add(i)
+	}
+	if len(nodes) > 0 && !e.enumerate(make([]int, 0), candidates, make(bitSet, words)) {
+		return e.results, ErrTruncated
+	}
+	return e.results, nil
+}
+
+// bitSet is a set of small non-negative integers.
+type bitSet []uint64
+
+func (s bitSet) add(i int) {
+	s[i/64] |= 1 << uint(i%64)
+}
+
+func (s bitSet) remove(i int) {
+	s[i/64] &^= 1 << uint(i%64)
+}
+
+func (s bitSet) empty() bool {
+	for _, word := range s {
+		if word != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// intersect returns the intersection of the sets as a new set.
+func (s bitSet) intersect(other bitSet) bitSet {
+	result := make(bitSet, len(s))
+	for i := range s {
+		result[i] = s[i] & other[i]
+	}
+	return result
+}
+
+// each calls fn with the members of the set in ascending order.
+func (s bitSet) each(fn func(i int)) {
+	for w, word := range s {
+		for word != 0 {
+			fn(w*64 + bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+}
+
+type antichainEnumerator struct {
+	nodes        []Node
+	incomparable []bitSet
+	results      [][]Node
+	max          int
+}
+
+// enumerate reports every maximal antichain extending the current one by
+// candidates, excluding those containing any of the excluded nodes,
+// returning false once the maximum number of results was exceeded.
+func (e *antichainEnumerator) enumerate(current []int, candidates bitSet, excluded bitSet) bool {
+	if candidates.empty() && excluded.empty() {
+		if e.max > 0 && len(e.results) == e.max {
+			return false
+		}
+		antichain := make([]Node, 0, len(current))
+		members := make(bitSet, len(candidates))
+		for _, i := range current {
+			members.add(i)
+		}
+		members.each(func(i int) {
+			antichain = append(antichain, e.nodes[i])
+		})
+		e.results = append(e.results, antichain)
+		return true
+	}
+
+	// only candidates comparable to the pivot need to be branched on, as
+	// any maximal antichain contains the pivot or one of them
+	pivot, most := -1, -1
+	for _, set := range []bitSet{candidates, excluded} {
+		set.each(func(i int) {
+			if count := popCount(candidates.intersect(e.incomparable[i])); count > most {
+				pivot, most = i, count
+			}
+		})
+	}
+
+	branches := make([]int, 0)
+	candidates.each(func(i int) {
+		if i == pivot || e.incomparable[pivot][i/64]&(1<<uint(i%64)) == 0 {
+			branches = append(branches, i)
+		}
+	})
+	for _, i := range branches {
+		if !e.enumerate(append(current, i), candidates.intersect(e.incomparable[i]), excluded.intersect(e.incomparable[i])) {
+			return false
+		}
+		candidates.remove(i)
+		excluded.add(i)
+	}
+	return true
+}
+
+func popCount(s bitSet) int {
+	count := 0
+	for _, word := range s {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
@@ -0,0 +1,44 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// booleanLattice returns the subsets of {1, 2, 3} ordered by inclusion,
+// named by their elements and "0" for the empty set.
+func booleanLattice() *DirectedGraph {
+	g := NewDirectedGraph()
+	for _, edge := range [][2]Node{
+		{"0", "1"}, {"0", "2"}, {"0", "3"},
+		{"1", "12"}, {"1", "13"}, {"2", "12"}, {"2", "23"}, {"3", "13"}, {"3", "23"},
+		{"12", "123"}, {"13", "123"}, {"23", "123"},
+	} {
+		g.AddEdge(edge[0], edge[1])
+	}
+	return g
+}
+
+func TestMaximalAntichains(t *testing.T) {
+	antichains, err := booleanLattice().MaximalAntichains(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, len(antichains))
+	for i, antichain := range antichains {
+		got[i] = fmt.Sprint(antichain)
+	}
+	sort.Strings(got)
+	want := []string{"[0]", "[1 2 3]", "[1 23]", "[12 13 23]", "[123]", "[2 13]", "[3 12]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	antichains, err = booleanLattice().MaximalAntichains(3)
+	if !errors.Is(err, ErrTruncated) || len(antichains) != 3 {
+		t.Errorf("got %d antichains and %v, want 3 and ErrTruncated", len(antichains), err)
+	}
+}
@@ -0,0 +1,182 @@
+package graff
+
+import (
+	"errors"
+)
+
+// Errors relating to attributes.
+var (
+	ErrEdgeNotFound = errors.New("The edge does not exist within the graph")
+)
+
+// WeightAttr is the edge attribute holding the edge's numeric weight,
+// used by the weighted algorithms when present.
+const WeightAttr = "weight"
+
+// attributeList holds key-value attributes keyed by an arbitrary value,
+// e.g. a node or an edge.
+type attributeList struct {
+	attrs map[interface{}]map[string]interface{}
+}
+
+func newAttributeList() *attributeList {
+	return &attributeList{
+		attrs: make(map[interface{}]map[string]interface{}),
+	}
+}
+
+func (l *attributeList) Copy() *attributeList {
+	attrs := make(map[interface{}]map[string]interface{}, len(l.attrs))
+	for owner, values := range l.attrs {
+		attrs[owner] = copyAttrs(values)
+	}
+	return &attributeList{
+		attrs: attrs,
+	}
+}
+
+func (l *attributeList) Set(owner interface{}, key string, value interface{}) {
+	values, ok := l.attrs[owner]
+	if !ok {
+		values = make(map[string]interface{})
+		l.attrs[owner] = values
+	}
+	values[key] = value
+}
+
+func (l *attributeList) Get(owner interface{}, key string) (interface{}, bool) {
+	value, ok := l.attrs[owner][key]
+	return value, ok
+}
+
+func (l *attributeList) All(owner interface{}) map[string]interface{} {
+	if values, ok := l.attrs[owner]; ok {
+		return copyAttrs(values)
+	}
+	return nil
+}
+
+func (l *attributeList) Remove(owner interface{}, key string) {
+	if values, ok := l.attrs[owner]; ok {
+		delete(values, key)
+		if len(values) == 0 {
+			delete(l.attrs, owner)
+		}
+	}
+}
+
+func (l *attributeList) RemoveAll(owner interface{}) {
+	delete(l.attrs, owner)
+}
+
+// Rename moves the attributes of the old owner to the new one.
+func (l *attributeList) Rename(old interface{}, new interface{}) {
+	if values, ok := l.attrs[old]; ok {
+		delete(l.attrs, old)
+		l.attrs[new] = values
+	}
+}
+
+func copyAttrs(values map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
+
+// SetNodeAttr sets the node's attribute to the specified value.
+// Attributes are removed together with the node and copied by Copy.
+// If the node does not exist within the graph ErrNodeNotFound is returned.
+func (g *DirectedGraph) SetNodeAttr(node Node, key string, value interface{}) error {
+	if !g.NodeExists(node) {
+		return ErrNodeNotFound
+	}
+	g.nodeAttrs.Set(node, key, value)
+	return nil
+}
+
+// NodeAttr returns the value of the node's attribute, if set.
+func (g *DirectedGraph) NodeAttr(node Node, key string) (interface{}, bool) {
+	return g.nodeAttrs.Get(node, key)
+}
+
+// NodeAttrs returns a copy of all of the node's attributes, or nil if
+// the node has none.
+func (g *DirectedGraph) NodeAttrs(node Node) map[string]interface{} {
+	return g.nodeAttrs.All(node)
+}
+
+// RemoveNodeAttr removes the node's attribute.
+func (g *DirectedGraph) RemoveNodeAttr(node Node, key string) {
+	g.nodeAttrs.Remove(node, key)
+}
+
+// SetEdgeAttr sets the edge's attribute to the specified value.
+// Attributes are removed together with the edge, copied by Copy and kept
+// for the edges retained by RemoveTransitives.
+// If the edge does not exist within the graph ErrEdgeNotFound is returned.
+func (g *DirectedGraph) SetEdgeAttr(from Node, to Node, key string, value interface{}) error {
+	if !g.edges.Exists(from, to) {
+		return ErrEdgeNotFound
+	}
+	g.edgeAttrs.Set(Edge{from, to}, key, value)
+	return nil
+}
+
+// EdgeAttr returns the value of the edge's attribute, if set.
+func (g *DirectedGraph) EdgeAttr(from Node, to Node, key string) (interface{}, bool) {
+	return g.edgeAttrs.Get(Edge{from, to}, key)
+}
+
+// EdgeAttrs returns a copy of all of the edge's attributes, or nil if
+// the edge has none.
+func (g *DirectedGraph) EdgeAttrs(from Node, to Node) map[string]interface{} {
+	return g.edgeAttrs.All(Edge{from, to})
+}
+
+// RemoveEdgeAttr removes the edge's attribute.
+func (g *DirectedGraph) RemoveEdgeAttr(from Node, to Node, key string) {
+	g.edgeAttrs.Remove(Edge{from, to}, key)
+}
+
+// edgeWeight returns the edge's weight attribute converted to a float64,
+// or the default weight of 1 if it is absent or not numeric.
+func (g *DirectedGraph) edgeWeight(from Node, to Node) float64 {
+	if value, ok := g.EdgeAttr(from, to, WeightAttr); ok {
+		if weight, ok := toFloat(value); ok {
+			return weight
+		}
+	}
+	return 1
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
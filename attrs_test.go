@@ -0,0 +1,84 @@
+package graff
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEdgeAttrs(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("a", "c")
+	if err := g.SetEdgeAttr("c", "a", "label", "x"); !errors.Is(err, ErrEdgeNotFound) {
+		t.Errorf("got %v for a missing edge, want ErrEdgeNotFound", err)
+	}
+	g.SetEdgeAttr("a", "b", "label", "blocks")
+	g.SetEdgeAttr("a", "b", WeightAttr, 2.5)
+	g.SetEdgeAttr("a", "c", "label", "soft-depends")
+
+	copied := g.Copy()
+	g.SetEdgeAttr("a", "b", "label", "changed")
+	if value, _ := copied.EdgeAttr("a", "b", "label"); value != "blocks" {
+		t.Errorf("the copy's label is %v, want blocks", value)
+	}
+
+	if err := g.RemoveTransitives(); err != nil {
+		t.Fatal(err)
+	}
+	if attrs := g.EdgeAttrs("a", "b"); len(attrs) != 2 || attrs["label"] != "changed" {
+		t.Errorf("the retained edge has the attributes %v", attrs)
+	}
+	if attrs := g.EdgeAttrs("a", "c"); attrs != nil {
+		t.Errorf("the removed transitive edge kept the attributes %v", attrs)
+	}
+
+	g.RemoveEdge("a", "b")
+	g.AddEdge("a", "b")
+	if attrs := g.EdgeAttrs("a", "b"); attrs != nil {
+		t.Errorf("the re-added edge has the old attributes %v", attrs)
+	}
+}
+
+func TestEventGraphEdgeAttrs(t *testing.T) {
+	e := NewEventGraph()
+	e.AddEdge("later", "earlier")
+	if err := e.SetEdgeAttr("later", "earlier", "label", "after"); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := e.EdgeAttr("later", "earlier", "label"); !ok || value != "after" {
+		t.Errorf("got %v in the caller's direction, want after", value)
+	}
+	if value, _ := e.DirectedGraph.EdgeAttr("earlier", "later", "label"); value != "after" {
+		t.Errorf("got %v on the underlying edge, want after", value)
+	}
+	if err := e.SetEdgeAttr("earlier", "later", "label", "x"); !errors.Is(err, ErrEdgeNotFound) {
+		t.Errorf("got %v in the wrong direction, want ErrEdgeNotFound", err)
+	}
+}
+
+func TestWriteDOTEdgeAttrs(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.SetEdgeAttr("a", "b", "label", "blocks")
+	g.SetEdgeAttr("a", "b", "color", "red")
+	g.SetEdgeAttr("a", "b", "style", "dashed")
+	g.SetEdgeAttr("a", "b", "owner", "ci")
+
+	var b strings.Builder
+	if err := g.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	if want := `"a" -> "b" ["color"="red", "label"="blocks", "style"="dashed"];`; !strings.Contains(b.String(), want) {
+		t.Errorf("got\n%s\nwant the edge line %s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := g.WriteDOT(&b, WithDOTAttributes()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"owner"="ci"`) {
+		t.Errorf("WithDOTAttributes left out the owner:\n%s", b.String())
+	}
+}
@@ -0,0 +1,94 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to shortest paths.
+var (
+	ErrNegativeCycle = errors.New("The graph contains a negative cycle")
+)
+
+// NegativeCycleError reports a cycle of negative total weight, which makes
+// the shortest paths through it undefined.
+// It wraps ErrNegativeCycle so it can be tested for using errors.Is.
+type NegativeCycleError struct {
+	Cycle []Node
+}
+
+func (e *NegativeCycleError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrNegativeCycle, e.Cycle)
+}
+
+func (e *NegativeCycleError) Unwrap() error {
+	return ErrNegativeCycle
+}
+
+// BellmanFord computes the shortest paths from the source to every node
+// reachable from it using the Bellman-Ford algorithm, which permits negative
+// edge weights. Edges carrying a weight attribute weigh its value, other
+// edges weigh 1, see WeightAttr.
+// It returns the distance of every reachable node and its predecessor on the
+// shortest path, leaving out the source's predecessor and unreachable nodes.
+// If a cycle of negative weight is reachable from the source a
+// NegativeCycleError is returned, with the cycle's nodes in edge order.
+// See https://en.wikipedia.org/wiki/Bellman–Ford_algorithm
+func (g *DirectedGraph) BellmanFord(source Node) (map[Node]float64, map[Node]Node, error) {
+	if !g.NodeExists(source) {
+		return nil, nil, &NodeNotFoundError{Node: source}
+	}
+
+	distances := map[Node]float64{source: 0}
+	predecessors := make(map[Node]Node)
+
+	// relax relaxes every edge leaving a reached node once, returning the
+	// target of the last edge which shortened a path, if any
+	relax := func() (Node, bool) {
+		var last Node
+		relaxed := false
+		for _, from := range g.Nodes() {
+			distance, ok := distances[from]
+			if !ok {
+				continue
+			}
+			g.EachOutgoing(from, func(to Node) bool {
+				candidate := distance + g.edgeWeight(from, to)
+				if current, ok := distances[to]; !ok || candidate < current {
+					distances[to] = candidate
+					predecessors[to] = from
+					last = to
+					relaxed = true
+				}
+				return true
+			})
+		}
+		return last, relaxed
+	}
+
+	for i := 1; i < g.NodeCount(); i++ {
+		if _, relaxed := relax(); !relaxed {
+			return distances, predecessors, nil
+		}
+	}
+
+	node, relaxed := relax()
+	if !relaxed {
+		return distances, predecessors, nil
+	}
+
+	// following the predecessors as many times as there are nodes is
+	// guaranteed to end up on the cycle
+	for i := 0; i < g.NodeCount(); i++ {
+		node = predecessors[node]
+	}
+	cycle := []Node{node}
+	for current := predecessors[node]; current != node; current = predecessors[current] {
+		cycle = append(cycle, current)
+	}
+	// the cycle was collected against the direction of its edges
+	for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+		cycle[i], cycle[j] = cycle[j], cycle[i]
+	}
+	return nil, nil, &NegativeCycleError{Cycle: cycle}
+}
@@ -0,0 +1,65 @@
+package graff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBellmanFord(t *testing.T) {
+	// a credit on b->c makes the detour through b shorter, and x is unreachable
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("a", "c")
+	g.AddEdge("c", "d")
+	g.AddEdge("x", "a")
+	g.SetEdgeAttr("a", "b", WeightAttr, 2.0)
+	g.SetEdgeAttr("b", "c", WeightAttr, -3.0)
+	g.SetEdgeAttr("a", "c", WeightAttr, 1.0)
+
+	distances, predecessors, err := g.BellmanFord("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[Node]float64{"a": 0, "b": 2, "c": -1, "d": 0}; !reflect.DeepEqual(distances, want) {
+		t.Errorf("got the distances %v, want %v", distances, want)
+	}
+	if want := map[Node]Node{"b": "a", "c": "b", "d": "c"}; !reflect.DeepEqual(predecessors, want) {
+		t.Errorf("got the predecessors %v, want %v", predecessors, want)
+	}
+
+	if _, _, err := g.BellmanFord("missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("got %v, want ErrNodeNotFound", err)
+	}
+}
+
+func TestBellmanFordNegativeCycle(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("s", "a")
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "a")
+	g.AddEdge("t", "s")
+	g.SetEdgeAttr("b", "c", WeightAttr, -4.0)
+
+	_, _, err := g.BellmanFord("s")
+	var negative *NegativeCycleError
+	if !errors.As(err, &negative) || !errors.Is(err, ErrNegativeCycle) {
+		t.Fatalf("got %v, want a NegativeCycleError", err)
+	}
+	if len(negative.Cycle) != 3 {
+		t.Fatalf("got the cycle %v, want a, b and c", negative.Cycle)
+	}
+	for i, from := range negative.Cycle {
+		if to := negative.Cycle[(i+1)%3]; !g.EdgeExists(from, to) {
+			t.Errorf("the cycle %v is not in edge order", negative.Cycle)
+		}
+	}
+
+	// a negative cycle which the source does not reach is ignored
+	g.AddEdge("u", "v")
+	if distances, _, err := g.BellmanFord("u"); err != nil || len(distances) != 2 {
+		t.Errorf("got %v, %v from u, which cannot reach the cycle", distances, err)
+	}
+}
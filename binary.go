@@ -0,0 +1,210 @@
+package graff
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Errors relating to the binary graph format.
+var (
+	ErrInvalidFormat      = errors.New("The data is not in the binary graph format")
+	ErrUnsupportedVersion = errors.New("The binary graph format version is not supported")
+)
+
+// binaryMagic identifies the binary graph format.
+const binaryMagic = "GRAF"
+
+// binaryVersion is the version of the binary graph format written.
+const binaryVersion byte = 1
+
+// maxPrealloc caps the number of nodes and edges the graph read is grown for
+// up front from the counts in the input, so that corrupt counts fail when
+// the data runs out instead of exhausting the memory.
+const maxPrealloc = 1 << 16
+
+// maxNodeEncoding is the maximum length of a node's encoding accepted.
+const maxNodeEncoding = 1 << 24
+
+// WriteTo writes the graph in a compact binary format using the
+// DefaultNodeCodec, returning the number of bytes written.
+// See WriteToWithCodec.
+func (g *DirectedGraph) WriteTo(w io.Writer) (int64, error) {
+	return g.WriteToWithCodec(w, DefaultNodeCodec)
+}
+
+// WriteToWithCodec writes the graph in a compact binary format, returning the
+// number of bytes written. The format starts with a magic header and a version
+// byte, followed by the length-prefixed encoding of every node in insertion
+// order and the edges, which reference their nodes by index. All integers are
+// varints. Edge multiplicities are preserved, attributes are not written.
+func (g *DirectedGraph) WriteToWithCodec(w io.Writer, codec NodeCodec) (int64, error) {
+	cw := &countingWriter{w: w}
+	b := bufio.NewWriter(cw)
+	buf := make([]byte, 0, binary.MaxVarintLen64)
+	writeUvarint := func(v uint64) {
+		buf = binary.AppendUvarint(buf[:0], v)
+		b.Write(buf)
+	}
+
+	b.WriteString(binaryMagic)
+	b.WriteByte(binaryVersion)
+
+	// the edges are written by the IDs of their nodes, mapped to the order
+	// the nodes are written in
+	nodes := g.Nodes()
+	ids := make([]int32, len(nodes))
+	indices := make([]uint64, g.edges.index.Len())
+	writeUvarint(uint64(len(nodes)))
+	for i, node := range nodes {
+		data, err := codec.EncodeNode(node)
+		if err != nil {
+			return cw.n, err
+		}
+		ids[i], _ = g.edges.index.Lookup(node)
+		indices[ids[i]] = uint64(i)
+		writeUvarint(uint64(len(data)))
+		b.Write(data)
+	}
+
+	writeUvarint(uint64(g.EdgeCount()))
+	for i, node := range nodes {
+		for _, to := range g.edges.outgoingIDs(node) {
+			writeUvarint(uint64(i))
+			writeUvarint(indices[to])
+			writeUvarint(uint64(g.edges.edges[edgeKey(ids[i], to)]))
+		}
+	}
+
+	err := b.Flush()
+	return cw.n, err
+}
+
+// ReadGraphFrom reads a graph written by WriteTo using the DefaultNodeCodec.
+func ReadGraphFrom(r io.Reader) (*DirectedGraph, error) {
+	return ReadGraphFromWithCodec(r, DefaultNodeCodec)
+}
+
+// ReadGraphFromWithCodec reads a graph written by WriteToWithCodec, decoding
+// the nodes using the codec. An error wrapping ErrInvalidFormat is returned
+// if the data is malformed, or ErrUnsupportedVersion if it was written by a
+// newer version of the format.
+// The graph is grown for the node and edge counts in the data before reading
+// the nodes and edges, see Grow, up to a limit since the counts may be
+// corrupt.
+func ReadGraphFromWithCodec(r io.Reader, codec NodeCodec) (*DirectedGraph, error) {
+	b := bufio.NewReader(r)
+
+	header := make([]byte, len(binaryMagic)+1)
+	if _, err := io.ReadFull(b, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if string(header[:len(binaryMagic)]) != binaryMagic {
+		return nil, fmt.Errorf("%w: bad magic header", ErrInvalidFormat)
+	}
+	if version := header[len(binaryMagic)]; version != binaryVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedVersion, version)
+	}
+
+	readUvarint := func(what string) (uint64, error) {
+		v, err := binary.ReadUvarint(b)
+		if err != nil {
+			return 0, fmt.Errorf("%w: reading %s: %v", ErrInvalidFormat, what, err)
+		}
+		return v, nil
+	}
+
+	g := NewDirectedGraph()
+
+	nodeCount, err := readUvarint("node count")
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int32, 0, minUint64(nodeCount, maxPrealloc))
+	g.Grow(int(minUint64(nodeCount, maxPrealloc)), 0)
+	for i := uint64(0); i < nodeCount; i++ {
+		size, err := readUvarint("node length")
+		if err != nil {
+			return nil, err
+		}
+		if size > maxNodeEncoding {
+			return nil, fmt.Errorf("%w: node encoding of %d bytes", ErrInvalidFormat, size)
+		}
+		data, err := io.ReadAll(io.LimitReader(b, int64(size)))
+		if err == nil && uint64(len(data)) < size {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading node: %v", ErrInvalidFormat, err)
+		}
+		node, err := codec.DecodeNode(data)
+		if err != nil {
+			return nil, err
+		}
+		if g.NodeExists(node) {
+			return nil, fmt.Errorf("%w: duplicate node %v", ErrInvalidFormat, node)
+		}
+		g.AddNode(node)
+		ids = append(ids, g.edges.intern(node))
+	}
+
+	edgeCount, err := readUvarint("edge count")
+	if err != nil {
+		return nil, err
+	}
+	g.Grow(0, int(minUint64(edgeCount, maxPrealloc)))
+	for i := uint64(0); i < edgeCount; i++ {
+		from, err := readUvarint("edge source")
+		if err != nil {
+			return nil, err
+		}
+		to, err := readUvarint("edge target")
+		if err != nil {
+			return nil, err
+		}
+		multiplicity, err := readUvarint("edge multiplicity")
+		if err != nil {
+			return nil, err
+		}
+		if from >= nodeCount || to >= nodeCount {
+			return nil, fmt.Errorf("%w: edge references node %d of %d", ErrInvalidFormat, maxUint64(from, to), nodeCount)
+		}
+		if multiplicity == 0 {
+			return nil, fmt.Errorf("%w: edge with multiplicity 0", ErrInvalidFormat)
+		}
+
+		if !g.edges.addIDs(ids[from], ids[to], int(multiplicity)) {
+			return nil, fmt.Errorf("%w: duplicate edge %v -> %v", ErrInvalidFormat, g.edges.index.Node(ids[from]), g.edges.index.Node(ids[to]))
+		}
+	}
+
+	return g, nil
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
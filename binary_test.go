@@ -0,0 +1,161 @@
+package graff
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdgeCounted("a", "b")
+	g.AddEdge("b", "c")
+	g.AddNode("d")
+
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadGraphFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.NodeCount() != 4 || read.EdgeCount() != 2 || read.EdgeMultiplicity("a", "b") != 2 {
+		t.Errorf("read %d nodes and %d edges, want 4 and 2", read.NodeCount(), read.EdgeCount())
+	}
+}
+
+func TestBinaryHugeCounts(t *testing.T) {
+	// a header claiming 2^63 nodes, the first of which is 16 MiB long
+	data := []byte("GRAF\x01\x80\x80\x80\x80\x80\x80\x80\x80\x80\x01\x80\x80\x80\x08")
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := ReadGraphFrom(bytes.NewReader(data)); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("got %v, want ErrInvalidFormat", err)
+	}
+	runtime.ReadMemStats(&after)
+	// the graph is only grown for the first maxPrealloc nodes
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<25 {
+		t.Errorf("reading %d bytes allocated %d bytes", len(data), allocated)
+	}
+}
+
+func TestBinaryNodeTypes(t *testing.T) {
+	g := NewDirectedGraph()
+	nodes := []Node{"s", -7, int64(1) << 40, uint64(1) << 63, 2.5, true, false, ""}
+	for i := 1; i < len(nodes); i++ {
+		g.AddEdge(nodes[i-1], nodes[i])
+	}
+
+	var buf bytes.Buffer
+	n, err := g.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("reported %d bytes written, wrote %d", n, buf.Len())
+	}
+	read, err := ReadGraphFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read.Nodes(), nodes) {
+		t.Errorf("read the nodes %v, want %v", read.Nodes(), nodes)
+	}
+	if !reflect.DeepEqual(read.AdjacencyMatrix(), g.AdjacencyMatrix()) {
+		t.Errorf("read the edges %v, want %v", read.AdjacencyMatrix(), g.AdjacencyMatrix())
+	}
+
+	g.AddNode(struct{}{})
+	if _, err := g.WriteTo(&buf); !errors.Is(err, ErrUnsupportedNode) {
+		t.Errorf("got %v for a struct node, want ErrUnsupportedNode", err)
+	}
+}
+
+func TestBinaryHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := layeredGraph(10, 2).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	future := append([]byte{}, data...)
+	future[len(binaryMagic)] = binaryVersion + 1
+	if _, err := ReadGraphFrom(bytes.NewReader(future)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("got %v for a newer version, want ErrUnsupportedVersion", err)
+	}
+	if _, err := ReadGraphFrom(bytes.NewReader(append([]byte("JSON"), data[4:]...))); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("got %v for a bad magic header, want ErrInvalidFormat", err)
+	}
+	for _, cut := range []int{0, 3, len(data) / 2, len(data) - 1} {
+		if _, err := ReadGraphFrom(bytes.NewReader(data[:cut])); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("got %v for %d of %d bytes, want ErrInvalidFormat", err, cut, len(data))
+		}
+	}
+}
+
+func TestReadGraphFromGrows(t *testing.T) {
+	g := layeredGraph(1000, 3)
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadGraphFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// growing by appending would have left room for more
+	if capacity := cap(read.nodes.nodes); capacity != 1000 {
+		t.Errorf("the nodes of the graph read have a capacity of %d, want 1000", capacity)
+	}
+}
+
+// BenchmarkBinaryFormat writes and reads a graph of 1M edges in the binary
+// and the JSON Lines format, reporting the size of the output.
+func BenchmarkBinaryFormat(b *testing.B) {
+	g := layeredGraph(250000, 4)
+	formats := []struct {
+		name  string
+		write func(w io.Writer) error
+		read  func(r io.Reader) error
+	}{
+		{"binary", func(w io.Writer) error {
+			_, err := g.WriteTo(w)
+			return err
+		}, func(r io.Reader) error {
+			_, err := ReadGraphFrom(r)
+			return err
+		}},
+		{"jsonl", func(w io.Writer) error {
+			return g.WriteJSONL(w)
+		}, func(r io.Reader) error {
+			_, err := ReadJSONL(r)
+			return err
+		}},
+	}
+	for _, format := range formats {
+		format := format
+		var buf bytes.Buffer
+		b.Run("write/"+format.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := format.write(&buf); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(buf.Len()), "bytes")
+		})
+		data := buf.Bytes()
+		b.Run("read/"+format.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := format.read(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Errors relating to the GraphBuilder.
+var (
+	ErrDuplicateEdge = errors.New("The edge already exists within the graph")
+	ErrSelfLoop      = errors.New("The edge is a self-loop")
+)
+
+// BuildError reports all violations encountered by a GraphBuilder, in the
+// order they occurred. Each violation wraps ErrDuplicateEdge, ErrSelfLoop or
+// ErrCyclicGraph, which can be tested for using errors.Is on the BuildError.
+type BuildError struct {
+	Errors []error
+}
+
+func (e *BuildError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d violations: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+func (e *BuildError) Unwrap() []error {
+	return e.Errors
+}
+
+// GraphBuilder constructs a DirectedGraph from untrusted input, skipping
+// duplicate edges, self-loops and, once RequireAcyclic was called, edges
+// which would introduce a cycle, and reporting all of them together by Build
+// rather than failing at the first one. Its methods can be chained:
+//
+//	g, err := NewGraphBuilder().RequireAcyclic().AddChain(a, b, c).AddEdge(a, d).Build()
+type GraphBuilder struct {
+	graph *DirectedGraph
+	errs  []error
+	// reversed reports edges in the reverse of their orientation within the
+	// graph, for the EventGraphBuilder
+	reversed bool
+
+	// acyclic enables the maintenance of a topological order, which assigns
+	// every node a unique position, to detect cycles incrementally
+	acyclic bool
+	order   map[Node]int
+	next    int
+}
+
+// NewGraphBuilder returns a builder of an empty graph.
+func NewGraphBuilder() *GraphBuilder {
+	return &GraphBuilder{
+		graph: NewDirectedGraph(),
+		errs:  make([]error, 0),
+	}
+}
+
+// AddNode adds the node to the graph. Duplicate nodes are ignored.
+func (b *GraphBuilder) AddNode(node Node) *GraphBuilder {
+	if !b.graph.NodeExists(node) {
+		b.graph.AddNode(node)
+		if b.acyclic {
+			b.order[node] = b.next
+			b.next++
+		}
+	}
+	return b
+}
+
+// AddEdge adds the edge to the graph, together with its nodes, unless it
+// already exists, is a self-loop, or would introduce a cycle once
+// RequireAcyclic was called, in which case the violation is recorded.
+func (b *GraphBuilder) AddEdge(from Node, to Node) *GraphBuilder {
+	if b.reversed {
+		from, to = to, from
+	}
+	b.AddNode(from)
+	b.AddNode(to)
+
+	switch {
+	case from == to:
+		b.errs = append(b.errs, fmt.Errorf("%w: %v -> %v", ErrSelfLoop, from, to))
+		return b
+	case b.graph.EdgeExists(from, to):
+		edge := b.edge(from, to)
+		b.errs = append(b.errs, fmt.Errorf("%w: %v -> %v", ErrDuplicateEdge, edge.From, edge.To))
+		return b
+	}
+	if b.acyclic {
+		if cycle := b.reorder(from, to); cycle != nil {
+			b.errs = append(b.errs, b.cycleError(cycle))
+			return b
+		}
+	}
+	b.graph.AddEdge(from, to)
+	return b
+}
+
+// edge returns the edge in the orientation it was given by the caller.
+func (b *GraphBuilder) edge(from Node, to Node) Edge {
+	if b.reversed {
+		return Edge{to, from}
+	}
+	return Edge{from, to}
+}
+
+// AddChain adds an edge between every node and the next, see AddEdge.
+func (b *GraphBuilder) AddChain(nodes ...Node) *GraphBuilder {
+	for _, node := range nodes {
+		b.AddNode(node)
+	}
+	for i := 1; i < len(nodes); i++ {
+		b.AddEdge(nodes[i-1], nodes[i])
+	}
+	return b
+}
+
+// RequireAcyclic makes the builder reject edges which would introduce a
+// cycle. Cycles are detected incrementally by maintaining a topological
+// order of the nodes, which an edge only needs to repair locally, see
+// https://doi.org/10.1145/1187436.1210590 (Pearce and Kelly), so Build
+// does not need to sort the graph. If the graph is already cyclic the cycle
+// is recorded and no further cycles are detected.
+func (b *GraphBuilder) RequireAcyclic() *GraphBuilder {
+	if b.acyclic {
+		return b
+	}
+	sorted, err := b.graph.LexTopoSort(nil)
+	if err != nil {
+		b.errs = append(b.errs, b.cycleError(b.graph.findCycle()))
+		return b
+	}
+	b.acyclic = true
+	b.order = make(map[Node]int, len(sorted))
+	for i, node := range sorted {
+		b.order[node] = i
+	}
+	b.next = len(sorted)
+	return b
+}
+
+// cycleError returns a CyclicGraphError for the cycle within the graph, in
+// the orientation of the caller.
+func (b *GraphBuilder) cycleError(cycle []Node) error {
+	if b.reversed {
+		reversed := make([]Node, len(cycle))
+		for i, node := range cycle {
+			reversed[len(cycle)-1-i] = node
+		}
+		cycle = reversed
+	}
+	return &CyclicGraphError{Cycle: cycle}
+}
+
+// reorder repairs the topological order for the edge about to be added,
+// returning the cycle it would introduce, starting at from, if any.
+func (b *GraphBuilder) reorder(from Node, to Node) []Node {
+	lower, upper := b.order[to], b.order[from]
+	if lower > upper {
+		return nil
+	}
+
+	// search forwards from to among the nodes up to from in the order,
+	// which would reach from if the edge closes a cycle
+	parents := map[Node]Node{to: nil}
+	forward := []Node{to}
+	for i := 0; i < len(forward); i++ {
+		node := forward[i]
+		var found bool
+		b.graph.EachOutgoing(node, func(next Node) bool {
+			if next == from {
+				parents[from] = node
+				found = true
+				return false
+			}
+			if _, ok := parents[next]; !ok && b.order[next] < upper {
+				parents[next] = node
+				forward = append(forward, next)
+			}
+			return true
+		})
+		if found {
+			path := []Node{from}
+			for node := parents[from]; node != to; node = parents[node] {
+				path = append(path, node)
+			}
+			path = append(path, to)
+			// the path was collected backwards from from to to
+			cycle := []Node{from}
+			for i := len(path) - 1; i > 0; i-- {
+				cycle = append(cycle, path[i])
+			}
+			return cycle
+		}
+	}
+
+	// search backwards from from among the nodes down to to in the order
+	seen := map[Node]bool{from: true}
+	backward := []Node{from}
+	for i := 0; i < len(backward); i++ {
+		b.graph.EachIncoming(backward[i], func(prev Node) bool {
+			if !seen[prev] && b.order[prev] > lower {
+				seen[prev] = true
+				backward = append(backward, prev)
+			}
+			return true
+		})
+	}
+
+	// move the nodes reaching from before those reached from to, reusing
+	// their positions
+	byOrder := func(nodes []Node) {
+		sort.Slice(nodes, func(i, j int) bool { return b.order[nodes[i]] < b.order[nodes[j]] })
+	}
+	byOrder(backward)
+	byOrder(forward)
+	moved := append(backward, forward...)
+	positions := make([]int, len(moved))
+	for i, node := range moved {
+		positions[i] = b.order[node]
+	}
+	sort.Ints(positions)
+	for i, node := range moved {
+		b.order[node] = positions[i]
+	}
+	return nil
+}
+
+// Build returns the graph, or a BuildError if any violations were recorded.
+func (b *GraphBuilder) Build() (*DirectedGraph, error) {
+	if len(b.errs) > 0 {
+		return nil, &BuildError{Errors: b.errs}
+	}
+	return b.graph, nil
+}
+
+// EventGraphBuilder constructs an EventGraph like the GraphBuilder does a
+// DirectedGraph, taking edges in the EventGraph's orientation.
+type EventGraphBuilder struct {
+	*GraphBuilder
+}
+
+// NewEventGraphBuilder returns a builder of an empty event graph.
+func NewEventGraphBuilder() *EventGraphBuilder {
+	b := NewGraphBuilder()
+	b.reversed = true
+	return &EventGraphBuilder{b}
+}
+
+// AddNode adds the node to the graph, see GraphBuilder.AddNode.
+func (b *EventGraphBuilder) AddNode(node Node) *EventGraphBuilder {
+	b.GraphBuilder.AddNode(node)
+	return b
+}
+
+// AddEdge adds the edge to the graph, see GraphBuilder.AddEdge.
+func (b *EventGraphBuilder) AddEdge(from Node, to Node) *EventGraphBuilder {
+	b.GraphBuilder.AddEdge(from, to)
+	return b
+}
+
+// AddChain adds an edge between every node and the next, see
+// GraphBuilder.AddChain.
+func (b *EventGraphBuilder) AddChain(nodes ...Node) *EventGraphBuilder {
+	b.GraphBuilder.AddChain(nodes...)
+	return b
+}
+
+// RequireAcyclic makes the builder reject edges which would introduce a
+// cycle, see GraphBuilder.RequireAcyclic.
+func (b *EventGraphBuilder) RequireAcyclic() *EventGraphBuilder {
+	b.GraphBuilder.RequireAcyclic()
+	return b
+}
+
+// Build returns the event graph, or a BuildError if any violations were
+// recorded.
+func (b *EventGraphBuilder) Build() (*EventGraph, error) {
+	g, err := b.GraphBuilder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &EventGraph{g}, nil
+}
@@ -0,0 +1,81 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestGraphBuilderViolations(t *testing.T) {
+	_, err := NewGraphBuilder().
+		RequireAcyclic().
+		AddChain("a", "b", "c").
+		AddEdge("a", "b").
+		AddEdge("d", "d").
+		AddEdge("c", "a").
+		Build()
+
+	var build *BuildError
+	if !errors.As(err, &build) || len(build.Errors) != 3 {
+		t.Fatalf("got %v, want three violations", err)
+	}
+	if !errors.Is(build.Errors[0], ErrDuplicateEdge) || !errors.Is(build.Errors[1], ErrSelfLoop) {
+		t.Errorf("got the violations %v", build.Errors)
+	}
+	var cyclic *CyclicGraphError
+	if !errors.As(build.Errors[2], &cyclic) || !reflect.DeepEqual(cyclic.Cycle, []Node{"c", "a", "b"}) {
+		t.Errorf("got %v, want the cycle [c a b]", build.Errors[2])
+	}
+	if !errors.Is(err, ErrSelfLoop) {
+		t.Error("the BuildError does not wrap its violations")
+	}
+
+	g, err := NewGraphBuilder().AddChain("a", "b", "c").AddEdge("c", "a").Build()
+	if err != nil || !g.EdgeExists("c", "a") {
+		t.Errorf("got %v without RequireAcyclic, want the cycle built", err)
+	}
+}
+
+func TestGraphBuilderIncrementalCycles(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	b := NewGraphBuilder().RequireAcyclic()
+	for i := 0; i < 2000; i++ {
+		from, to := random.Intn(60), random.Intn(60)
+		closes := from != to && b.graph.reaches(to, from)
+		count := len(b.errs)
+		b.AddEdge(from, to)
+		if rejected := len(b.errs) > count && errors.Is(b.errs[count], ErrCyclicGraph); rejected != closes {
+			t.Fatalf("adding %v -> %v: rejected %v, want %v", from, to, rejected, closes)
+		}
+	}
+	g := b.graph
+	if _, err := g.DFSSort(); err != nil {
+		t.Errorf("the built graph is cyclic: %v", err)
+	}
+}
+
+func TestEventGraphBuilder(t *testing.T) {
+	// b depends on a, so a happens before b
+	_, err := NewEventGraphBuilder().
+		RequireAcyclic().
+		AddEdge("b", "a").
+		AddEdge("b", "a").
+		AddEdge("a", "b").
+		Build()
+	var build *BuildError
+	if !errors.As(err, &build) || len(build.Errors) != 2 {
+		t.Fatalf("got %v, want two violations", err)
+	}
+	if got, want := build.Errors[0].Error(), ErrDuplicateEdge.Error()+": b -> a"; got != want {
+		t.Errorf("got %q, want the edge in the caller's orientation %q", got, want)
+	}
+
+	g, err := NewEventGraphBuilder().AddChain("c", "b", "a").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.HappensBefore("a", "c") || !g.EdgeExists("c", "b") {
+		t.Error("the edges were not added in the orientation of AddEdge")
+	}
+}
@@ -0,0 +1,107 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Errors relating to centrality.
+var (
+	ErrPathCountOverflow = errors.New("The number of shortest paths exceeds the floating point range")
+)
+
+// BetweennessCentrality computes the betweenness centrality of every node,
+// i.e. the number of shortest directed paths between other pairs of nodes
+// passing through it, using Brandes' algorithm with unit edge lengths.
+// The shortest paths are counted as float64, which a chain of k diamonds
+// exceeds for k above 1023, in which case an error wrapping
+// ErrPathCountOverflow is returned rather than meaningless values.
+// See https://en.wikipedia.org/wiki/Betweenness_centrality
+func (g *DirectedGraph) BetweennessCentrality() (map[Node]float64, error) {
+	return betweennessCentrality(g, false)
+}
+
+// BetweennessCentralityNormalized computes the betweenness centrality like
+// BetweennessCentrality, normalized by (n-1)(n-2) so that values fall within
+// [0, 1].
+func (g *DirectedGraph) BetweennessCentralityNormalized() (map[Node]float64, error) {
+	return betweennessCentrality(g, true)
+}
+
+func betweennessCentrality(g Directed, normalize bool) (map[Node]float64, error) {
+	nodes := g.Nodes()
+	n := len(nodes)
+
+	index := make(map[Node]int, n)
+	for i, node := range nodes {
+		index[node] = i
+	}
+
+	centrality := make([]float64, n)
+
+	// per-source state, reused between iterations
+	stack := make([]int, 0, n)
+	queue := make([]int, 0, n)
+	predecessors := make([][]int, n)
+	sigma := make([]float64, n)
+	distance := make([]int, n)
+	delta := make([]float64, n)
+
+	for s := range nodes {
+		stack = stack[:0]
+		queue = queue[:0]
+		for i := range nodes {
+			predecessors[i] = predecessors[i][:0]
+			sigma[i] = 0
+			distance[i] = -1
+			delta[i] = 0
+		}
+		sigma[s] = 1
+		distance[s] = 0
+
+		// breadth-first search counting the shortest paths from s
+		queue = append(queue, s)
+		for head := 0; head < len(queue); head++ {
+			v := queue[head]
+			stack = append(stack, v)
+
+			for _, outgoing := range g.OutgoingEdges(nodes[v]) {
+				w := index[outgoing]
+				if distance[w] < 0 {
+					distance[w] = distance[v] + 1
+					queue = append(queue, w)
+				}
+				if distance[w] == distance[v]+1 {
+					sigma[w] += sigma[v]
+					if math.IsInf(sigma[w], 1) {
+						return nil, fmt.Errorf("%w: from %v to %v", ErrPathCountOverflow, nodes[s], outgoing)
+					}
+					predecessors[w] = append(predecessors[w], v)
+				}
+			}
+		}
+
+		// accumulate the dependencies in order of non-increasing distance
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range predecessors[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				centrality[w] += delta[w]
+			}
+		}
+	}
+
+	scale := 1.0
+	if normalize && n > 2 {
+		scale = 1 / float64((n-1)*(n-2))
+	}
+
+	results := make(map[Node]float64, n)
+	for i, node := range nodes {
+		results[node] = centrality[i] * scale
+	}
+	return results, nil
+}
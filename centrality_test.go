@@ -0,0 +1,75 @@
+package graff
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestBetweennessCentralityStarAndPath(t *testing.T) {
+	// every path between the n leaves of the star passes through its center
+	star := NewDirectedGraph()
+	for i := 0; i < 5; i++ {
+		star.AddEdge(i, "center")
+		star.AddEdge("center", i)
+	}
+	centrality, err := star.BetweennessCentralityNormalized()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if centrality["center"] != 1 || centrality[0] != 0 {
+		t.Errorf("the star's centrality is %v", centrality)
+	}
+
+	// the i-th node of a directed path of n nodes lies on i(n-1-i) paths
+	path := NewDirectedGraph()
+	for i := 0; i < 5; i++ {
+		path.AddEdge(i, i+1)
+	}
+	centrality, err = path.BetweennessCentrality()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= 5; i++ {
+		if want := float64(i * (5 - i)); centrality[i] != want {
+			t.Errorf("node %d has centrality %v, want %v", i, centrality[i], want)
+		}
+	}
+}
+
+func TestBetweennessCentralityOverflow(t *testing.T) {
+	// a chain of k diamonds has 2^k shortest paths from end to end
+	diamonds := func(k int) *DirectedGraph {
+		g := NewDirectedGraph()
+		for i := 0; i < k; i++ {
+			g.AddEdge(3*i, 3*i+1)
+			g.AddEdge(3*i, 3*i+2)
+			g.AddEdge(3*i+1, 3*i+3)
+			g.AddEdge(3*i+2, 3*i+3)
+		}
+		return g
+	}
+
+	centrality, err := diamonds(1000).BetweennessCentrality()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for node, value := range centrality {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			t.Fatalf("%v has centrality %v", node, value)
+		}
+	}
+	if _, err := diamonds(1030).BetweennessCentrality(); !errors.Is(err, ErrPathCountOverflow) {
+		t.Errorf("overflowing the path counts returned %v", err)
+	}
+}
+
+func BenchmarkBetweennessCentrality(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := g.BetweennessCentrality(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
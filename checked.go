@@ -0,0 +1,115 @@
+package graff
+
+import (
+	"fmt"
+)
+
+// NodeNotFoundError reports a node which does not exist within the graph,
+// including its dynamic type, since querying with a key of the wrong type
+// (e.g. a string rather than a custom struct) is a common mistake.
+// It wraps ErrNodeNotFound so it can be tested for using errors.Is.
+type NodeNotFoundError struct {
+	Node Node
+}
+
+func (e *NodeNotFoundError) Error() string {
+	return fmt.Sprintf("%v: %v (%T)", ErrNodeNotFound, e.Node, e.Node)
+}
+
+func (e *NodeNotFoundError) Unwrap() error {
+	return ErrNodeNotFound
+}
+
+// OutgoingEdgesChecked returns the nodes belonging to directed edges pointing
+// from the specified node like OutgoingEdges, but returns a NodeNotFoundError
+// rather than nil for a node which does not exist within the graph.
+func (g *DirectedGraph) OutgoingEdgesChecked(node Node) ([]Node, error) {
+	if !g.NodeExists(node) {
+		return nil, &NodeNotFoundError{Node: node}
+	}
+	return g.OutgoingEdges(node), nil
+}
+
+// IncomingEdgesChecked returns the nodes belonging to directed edges pointing
+// towards the specified node like IncomingEdges, but returns a
+// NodeNotFoundError rather than nil for a node which does not exist within
+// the graph.
+func (g *DirectedGraph) IncomingEdgesChecked(node Node) ([]Node, error) {
+	if !g.NodeExists(node) {
+		return nil, &NodeNotFoundError{Node: node}
+	}
+	return g.IncomingEdges(node), nil
+}
+
+// EdgeExistsChecked checks whether the edge exists within the graph like
+// EdgeExists, but returns a NodeNotFoundError rather than false if either
+// node does not exist within the graph.
+func (g *DirectedGraph) EdgeExistsChecked(from Node, to Node) (bool, error) {
+	if err := g.checkNodes([]Node{from, to}); err != nil {
+		return false, err
+	}
+	return g.EdgeExists(from, to), nil
+}
+
+// EdgeExistsChecked checks whether the edge exists within the graph like
+// EdgeExists, but returns a NodeNotFoundError rather than false if either
+// node does not exist within the graph.
+func (g *EventGraph) EdgeExistsChecked(from Node, to Node) (bool, error) {
+	return g.DirectedGraph.EdgeExistsChecked(to, from)
+}
+
+// RemoveNodeChecked removes the node and all edges to or from it like
+// RemoveNode, but returns a NodeNotFoundError rather than failing silently
+// for a node which does not exist within the graph.
+func (g *DirectedGraph) RemoveNodeChecked(node Node) error {
+	if !g.NodeExists(node) {
+		return &NodeNotFoundError{Node: node}
+	}
+	g.RemoveNode(node)
+	return nil
+}
+
+// RemoveEdgeChecked removes the edge like RemoveEdge, but returns a
+// NodeNotFoundError if either node does not exist within the graph, or
+// ErrEdgeNotFound if the edge does not, rather than failing silently.
+func (g *DirectedGraph) RemoveEdgeChecked(from Node, to Node) error {
+	if err := g.checkNodes([]Node{from, to}); err != nil {
+		return err
+	}
+	if g.RemoveEdges(Edge{from, to}) == 0 {
+		return ErrEdgeNotFound
+	}
+	return nil
+}
+
+// RemoveEdgeChecked removes the edge like RemoveEdge, but returns an error
+// rather than failing silently, see DirectedGraph.RemoveEdgeChecked.
+func (g *EventGraph) RemoveEdgeChecked(from Node, to Node) error {
+	return g.DirectedGraph.RemoveEdgeChecked(to, from)
+}
+
+// MissingEdges returns the specified edges which do not exist within the
+// graph, in the order given, so that all of them can be reported at once.
+// The result is empty if all of them exist.
+func (g *DirectedGraph) MissingEdges(edges ...Edge) []Edge {
+	missing := make([]Edge, 0)
+	for _, edge := range edges {
+		if !g.EdgeExists(edge.From, edge.To) {
+			missing = append(missing, edge)
+		}
+	}
+	return missing
+}
+
+// MissingEdges returns the specified edges which do not exist within the
+// graph like DirectedGraph.MissingEdges, interpreting them in the same
+// orientation as AddEdge.
+func (g *EventGraph) MissingEdges(edges ...Edge) []Edge {
+	missing := make([]Edge, 0)
+	for _, edge := range edges {
+		if !g.EdgeExists(edge.From, edge.To) {
+			missing = append(missing, edge)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,66 @@
+package graff
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type serviceKey struct {
+	name string
+}
+
+func TestCheckedWrongKeyType(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge(serviceKey{"api"}, serviceKey{"db"})
+
+	// querying with the name rather than the key silently finds nothing
+	if edges := g.OutgoingEdges("api"); edges != nil {
+		t.Fatalf("got the edges %v for a string key", edges)
+	}
+
+	var notFound *NodeNotFoundError
+	if _, err := g.OutgoingEdgesChecked("api"); !errors.As(err, &notFound) || notFound.Node != "api" {
+		t.Errorf("OutgoingEdgesChecked got %v, want a NodeNotFoundError", err)
+	} else if !strings.Contains(err.Error(), "(string)") {
+		t.Errorf("the error %q does not name the key type", err)
+	}
+	if _, err := g.IncomingEdgesChecked("db"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("IncomingEdgesChecked got %v, want ErrNodeNotFound", err)
+	}
+	if _, err := g.EdgeExistsChecked(serviceKey{"api"}, "db"); !errors.As(err, &notFound) || notFound.Node != "db" {
+		t.Errorf("EdgeExistsChecked got %v, want a NodeNotFoundError for db", err)
+	}
+
+	if edges, err := g.OutgoingEdgesChecked(serviceKey{"api"}); err != nil || len(edges) != 1 || edges[0] != (serviceKey{"db"}) {
+		t.Errorf("OutgoingEdgesChecked got %v, %v", edges, err)
+	}
+	if ok, err := g.EdgeExistsChecked(serviceKey{"db"}, serviceKey{"api"}); err != nil || ok {
+		t.Errorf("EdgeExistsChecked got %v, %v for the reversed edge", ok, err)
+	}
+}
+
+func TestMissingNodesAndEdges(t *testing.T) {
+	g := NewEventGraph()
+	g.AddHappensBefore("a", "b")
+	g.AddNode("c")
+
+	if !g.HasNode("c") || g.HasNode("d") {
+		t.Error("HasNode got the wrong answer")
+	}
+	if missing := g.HasNodes("x", "a", "y", "c"); !reflect.DeepEqual(missing, []Node{"x", "y"}) {
+		t.Errorf("HasNodes got %v, want every missing node", missing)
+	}
+
+	// the event graph takes edges in the orientation of AddEdge, where b
+	// depends on a
+	want := []Edge{{"a", "b"}, {"c", "a"}}
+	if missing := g.MissingEdges(Edge{"a", "b"}, Edge{"b", "a"}, Edge{"c", "a"}); !reflect.DeepEqual(missing, want) {
+		t.Errorf("the event graph is missing %v, want %v", missing, want)
+	}
+	want = []Edge{{"b", "a"}, {"c", "a"}}
+	if missing := g.DirectedGraph.MissingEdges(Edge{"a", "b"}, Edge{"b", "a"}, Edge{"c", "a"}); !reflect.DeepEqual(missing, want) {
+		t.Errorf("the directed graph is missing %v, want %v", missing, want)
+	}
+}
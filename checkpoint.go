@@ -0,0 +1,63 @@
+package graff
+
+import (
+	"errors"
+)
+
+// Errors relating to checkpoints.
+var (
+	ErrForeignCheckpoint = errors.New("The checkpoint was not taken of this graph")
+)
+
+// CheckpointToken records the number of nodes and edges added to and removed
+// from a graph so far, see Checkpoint.
+type CheckpointToken struct {
+	graph        *graph
+	nodesAdded   int
+	nodesRemoved int
+	edgesAdded   int
+	edgesRemoved int
+}
+
+// GraphDelta holds the number of nodes and edges added to and removed from
+// a graph since a checkpoint.
+type GraphDelta struct {
+	NodesAdded   int
+	NodesRemoved int
+	EdgesAdded   int
+	EdgesRemoved int
+}
+
+// Checkpoint returns a token from which Since reports the changes made to
+// the graph afterwards, e.g. to report the edges added since metrics were
+// last flushed. Taking a checkpoint is cheap, as every mutation maintains
+// the counts anyway.
+func (g *DirectedGraph) Checkpoint() CheckpointToken {
+	return CheckpointToken{
+		graph:        g.graph,
+		nodesAdded:   g.nodes.added,
+		nodesRemoved: g.nodes.removed,
+		edgesAdded:   g.edges.added,
+		edgesRemoved: g.edges.removed,
+	}
+}
+
+// Since returns the number of nodes and edges added and removed since the
+// checkpoint was taken, so that NodeCount and EdgeCount changed by the
+// difference of the added and removed ones. Edges are counted as distinct
+// edges, so adding an existing edge or changing its multiplicity does not
+// count, while a node or edge removed and added again counts twice.
+// Replacing a node counts as neither.
+// ErrForeignCheckpoint is returned if the checkpoint was taken of another
+// graph, including the graph a copy was made of.
+func (g *DirectedGraph) Since(t CheckpointToken) (GraphDelta, error) {
+	if t.graph != g.graph {
+		return GraphDelta{}, ErrForeignCheckpoint
+	}
+	return GraphDelta{
+		NodesAdded:   g.nodes.added - t.nodesAdded,
+		NodesRemoved: g.nodes.removed - t.nodesRemoved,
+		EdgesAdded:   g.edges.added - t.edgesAdded,
+		EdgesRemoved: g.edges.removed - t.edgesRemoved,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestSince(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	checkpoint := g.Checkpoint()
+
+	g.AddEdge("b", "c")     // 1 node, 1 edge added
+	g.AddEdge("b", "c")     // no effect
+	g.AddNode("d")          // 1 node added
+	g.RemoveEdge("a", "b")  // 1 edge removed
+	g.AddEdge("a", "b")     // 1 edge added again
+	g.RemoveNode("b")       // 1 node and 2 edges removed
+	g.AddEdge("b", "d")     // 1 node, 1 edge added again
+	g.ReplaceNode("d", "e") // no change
+	delta, err := g.Since(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (GraphDelta{NodesAdded: 3, NodesRemoved: 1, EdgesAdded: 3, EdgesRemoved: 3}); delta != want {
+		t.Errorf("got %+v, want %+v", delta, want)
+	}
+
+	if _, err := NewDirectedGraph().Since(checkpoint); !errors.Is(err, ErrForeignCheckpoint) {
+		t.Errorf("another graph got %v, want ErrForeignCheckpoint", err)
+	}
+	c := g.Copy()
+	if _, err := c.Since(checkpoint); !errors.Is(err, ErrForeignCheckpoint) {
+		t.Errorf("a copy got %v, want ErrForeignCheckpoint", err)
+	}
+	copied := c.Checkpoint()
+	c.AddEdge("x", "y")
+	if delta, _ := c.Since(copied); delta != (GraphDelta{NodesAdded: 2, EdgesAdded: 1}) {
+		t.Errorf("the copy's checkpoint got %+v", delta)
+	}
+	if delta, _ := g.Since(g.Checkpoint()); delta != (GraphDelta{}) {
+		t.Errorf("a mutation of the copy changed the original by %+v", delta)
+	}
+}
+
+func TestSinceMatchesCounts(t *testing.T) {
+	random := rand.New(rand.NewSource(9))
+	g := layeredGraph(50, 3)
+	checkpoint := g.Checkpoint()
+	nodes, edges := g.NodeCount(), g.EdgeCount()
+	for i := 0; i < 500; i++ {
+		from, to := random.Intn(60), random.Intn(60)
+		switch random.Intn(4) {
+		case 0, 1:
+			g.AddEdge(from, to)
+		case 2:
+			g.RemoveEdge(from, to)
+		default:
+			g.RemoveNode(from)
+		}
+	}
+	delta, err := g.Since(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := nodes + delta.NodesAdded - delta.NodesRemoved; got != g.NodeCount() {
+		t.Errorf("the delta %+v gives %d nodes, the graph has %d", delta, got, g.NodeCount())
+	}
+	if got := edges + delta.EdgesAdded - delta.EdgesRemoved; got != g.EdgeCount() {
+		t.Errorf("the delta %+v gives %d edges, the graph has %d", delta, got, g.EdgeCount())
+	}
+}
@@ -0,0 +1,103 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to vector clocks.
+var (
+	ErrNotChain = errors.New("The events of a process are not totally ordered")
+)
+
+// AssignClocks stamps every event with a vector clock consistent with the
+// happens-before relation: for distinct events a and b, HappensBefore(a, b)
+// holds iff a's clock is less than or equal to b's in every component, so two
+// events are concurrent iff neither clock dominates the other.
+//
+// Vector clocks only capture concurrency if the events of every process are
+// totally ordered, which does not hold for weakly connected components in
+// general, so the events are instead partitioned into chains by a greedy
+// chain decomposition, each chain acting as a process. Use AssignClocksBy to
+// supply the processes instead.
+func (g *EventGraph) AssignClocks() (map[Node][]int, error) {
+	nodes, err := g.LexTopoSort(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// extend the chain ending with one of the event's causes if there is one
+	chains := make(map[Node]int, len(nodes))
+	tails := make([]Node, 0)
+	for _, node := range nodes {
+		chain := -1
+		for _, cause := range g.IncomingEdges(node) {
+			if c := chains[cause]; tails[c] == cause {
+				chain = c
+				break
+			}
+		}
+		if chain == -1 {
+			chain = len(tails)
+			tails = append(tails, nil)
+		}
+		chains[node] = chain
+		tails[chain] = node
+	}
+
+	return g.assignClocks(nodes, func(node Node) int {
+		return chains[node]
+	})
+}
+
+// AssignClocksBy stamps every event with a vector clock like AssignClocks,
+// using the process returned for every event, which must be non-negative and
+// is used as the index of the process's component within the clocks.
+// The events of a process must be totally ordered by happens-before,
+// otherwise an error wrapping ErrNotChain is returned.
+func (g *EventGraph) AssignClocksBy(process func(Node) int) (map[Node][]int, error) {
+	nodes, err := g.LexTopoSort(nil)
+	if err != nil {
+		return nil, err
+	}
+	return g.assignClocks(nodes, process)
+}
+
+func (g *EventGraph) assignClocks(nodes []Node, process func(Node) int) (map[Node][]int, error) {
+	processes := make(map[Node]int, len(nodes))
+	size := 0
+	for _, node := range nodes {
+		p := process(node)
+		if p < 0 {
+			return nil, fmt.Errorf("%w: negative process %d for %v", ErrInvalidOption, p, node)
+		}
+		processes[node] = p
+		if p >= size {
+			size = p + 1
+		}
+	}
+
+	clocks := make(map[Node][]int, len(nodes))
+	counts := make([]int, size)
+	for _, node := range nodes {
+		clock := make([]int, size)
+		for _, cause := range g.IncomingEdges(node) {
+			for i, count := range clocks[cause] {
+				if count > clock[i] {
+					clock[i] = count
+				}
+			}
+		}
+
+		// the previous event of the process must be among the causes
+		p := processes[node]
+		if clock[p] != counts[p] {
+			return nil, fmt.Errorf("%w: %v is concurrent with an earlier event of process %d", ErrNotChain, node, p)
+		}
+		counts[p]++
+		clock[p] = counts[p]
+
+		clocks[node] = clock
+	}
+	return clocks, nil
+}
@@ -0,0 +1,78 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// dominates determines whether every component of a is at most that of b.
+func dominates(a []int, b []int) bool {
+	for i := range a {
+		if a[i] > b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAssignClocksMatchesHappensBefore(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < 50; run++ {
+		g := NewEventGraph()
+		n := 2 + rng.Intn(12)
+		for i := 0; i < n; i++ {
+			g.AddNode(i)
+		}
+		for k := rng.Intn(2 * n); k > 0; k-- {
+			a, b := rng.Intn(n), rng.Intn(n)
+			if a < b {
+				g.AddHappensBefore(a, b)
+			}
+		}
+
+		clocks, err := g.AssignClocks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for a := 0; a < n; a++ {
+			for b := 0; b < n; b++ {
+				if a == b {
+					continue
+				}
+				if got, want := dominates(clocks[a], clocks[b]), g.HappensBefore(a, b); got != want {
+					t.Fatalf("run %d: the clocks %v and %v of %d and %d disagree with HappensBefore = %v",
+						run, clocks[a], clocks[b], a, b, want)
+				}
+			}
+		}
+	}
+}
+
+func TestAssignClocksBy(t *testing.T) {
+	// two processes exchanging one message
+	g := NewEventGraphFromPairs([][2]Node{
+		{"p1", "p2"}, {"p2", "p3"},
+		{"q1", "q2"}, {"p1", "q2"},
+	})
+	process := map[Node]int{"p1": 0, "p2": 0, "p3": 0, "q1": 1, "q2": 1}
+	clocks, err := g.AssignClocksBy(func(node Node) int { return process[node] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[Node][2]int{"p1": {1, 0}, "p2": {2, 0}, "p3": {3, 0}, "q1": {0, 1}, "q2": {1, 2}}
+	for node, clock := range want {
+		if got := clocks[node]; len(got) != 2 || got[0] != clock[0] || got[1] != clock[1] {
+			t.Errorf("%v has the clock %v, want %v", node, got, clock)
+		}
+	}
+
+	// q1 and p1 are concurrent, so they cannot share a process
+	process["q1"] = 0
+	if _, err := g.AssignClocksBy(func(node Node) int { return process[node] }); !errors.Is(err, ErrNotChain) {
+		t.Errorf("got %v, want ErrNotChain", err)
+	}
+	if _, err := g.AssignClocksBy(func(node Node) int { return -1 }); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v for a negative process, want ErrInvalidOption", err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/quan8/cofgra"
+)
+
+// format reads and writes graphs in a file format.
+type format struct {
+	read  func(r io.Reader) (*graff.DirectedGraph, error)
+	write func(g *graff.DirectedGraph, w io.Writer) error
+}
+
+// formats holds the supported formats by name.
+var formats = map[string]format{
+	"csv": {
+		read:  func(r io.Reader) (*graff.DirectedGraph, error) { return graff.ReadCSV(r) },
+		write: func(g *graff.DirectedGraph, w io.Writer) error { return g.WriteCSV(w) },
+	},
+	"dot": {
+		read:  func(r io.Reader) (*graff.DirectedGraph, error) { return graff.ParseDOT(r) },
+		write: func(g *graff.DirectedGraph, w io.Writer) error { return g.WriteDOT(w, graff.WithDOTAttributes()) },
+	},
+	"jsonl": {
+		read:  func(r io.Reader) (*graff.DirectedGraph, error) { return graff.ReadJSONL(r) },
+		write: func(g *graff.DirectedGraph, w io.Writer) error { return g.WriteJSONL(w) },
+	},
+	"pajek": {
+		read:  func(r io.Reader) (*graff.DirectedGraph, error) { return graff.ParsePajek(r) },
+		write: func(g *graff.DirectedGraph, w io.Writer) error { return g.WritePajek(w) },
+	},
+	"tgf": {
+		read:  func(r io.Reader) (*graff.DirectedGraph, error) { return graff.ParseTGF(r) },
+		write: func(g *graff.DirectedGraph, w io.Writer) error { return g.WriteTGF(w) },
+	},
+}
+
+// extensions maps file extensions to the names of their formats.
+var extensions = map[string]string{
+	".csv":    "csv",
+	".dot":    "dot",
+	".gv":     "dot",
+	".jsonl":  "jsonl",
+	".ndjson": "jsonl",
+	".net":    "pajek",
+	".paj":    "pajek",
+	".tgf":    "tgf",
+}
+
+// formatNames returns the names of the supported formats in order.
+func formatNames() string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// detectFormat returns the format named, or the one of the file's extension
+// if no name is given.
+func detectFormat(name string, path string) (format, error) {
+	if name == "" {
+		if path == "" || path == "-" {
+			return format{}, usageError("cannot detect the format of standard input or output, use -from or -to")
+		}
+		var ok bool
+		if name, ok = extensions[strings.ToLower(filepath.Ext(path))]; !ok {
+			return format{}, usageError(fmt.Sprintf("cannot detect the format of %s by its extension, use -from or -to", path))
+		}
+	}
+	f, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return format{}, usageError(fmt.Sprintf("unknown format %q, expected one of %s", name, formatNames()))
+	}
+	return f, nil
+}
+
+// readGraph reads a graph from the file, or standard input if the path is
+// empty or -, in the format named or detected by the file's extension.
+func readGraph(path string, formatName string, stdin io.Reader) (*graff.DirectedGraph, error) {
+	f, err := detectFormat(formatName, path)
+	if err != nil {
+		return nil, err
+	}
+	r := stdin
+	if path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	} else {
+		path = "standard input"
+	}
+
+	g, err := f.read(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return g, nil
+}
+
+// writeOutput calls write with the file, or standard output if the path is
+// empty or -, closing the file afterwards.
+func writeOutput(path string, stdout io.Writer, write func(w io.Writer) error) error {
+	if path == "" || path == "-" {
+		return write(stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,255 @@
+// Command graff sorts, layers, converts and checks graph files.
+//
+// Usage:
+//
+//	graff sort [-i input] [-o output] [-from format]
+//	graff layers -width n [-i input] [-o output] [-from format] [-to text|json]
+//	graff convert [-i input] [-o output] [-from format] [-to format]
+//	graff check [-i input] [-from format]
+//
+// Graphs are read from standard input and written to standard output unless
+// files are given. The formats are csv, dot, jsonl, pajek and tgf, detected
+// by the files' extensions unless given by -from and -to.
+//
+// The exit code is 0 on success, 1 if the command failed, e.g. because the
+// graph contains a cycle, and 2 if it was used incorrectly.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quan8/cofgra"
+)
+
+// The exit codes of the command.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// usageError reports an incorrect use of the command.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// command runs a subcommand with its arguments.
+type command func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+
+var commands = map[string]command{
+	"sort":    runSort,
+	"layers":  runLayers,
+	"convert": runConvert,
+	"check":   runCheck,
+}
+
+const usage = `usage: graff <command> [flags]
+
+commands:
+  sort     print the nodes in topological order, one per line
+  layers   assign the nodes to layers of bounded width
+  convert  convert a graph to another format
+  check    report whether the graph contains a cycle
+
+Run graff <command> -h for the flags of a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line, returning the exit code.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "graff: unknown command %q\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	err := cmd(args[1:], stdin, stdout, stderr)
+	var usage usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usage):
+		fmt.Fprintf(stderr, "graff %s: %v\n", args[0], err)
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "graff %s: %v\n", args[0], err)
+		return exitFailure
+	}
+}
+
+// ioFlags holds the flags selecting the input and output.
+type ioFlags struct {
+	input  string
+	output string
+	from   string
+	to     string
+}
+
+// newFlagSet returns the flags of a command, reporting errors to stderr.
+func newFlagSet(name string, stderr io.Writer, files *ioFlags, output bool) *flag.FlagSet {
+	flags := flag.NewFlagSet("graff "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&files.input, "i", "", "the input file, or - for standard input")
+	flags.StringVar(&files.from, "from", "", "the input format: "+formatNames())
+	if output {
+		flags.StringVar(&files.output, "o", "", "the output file, or - for standard output")
+	}
+	return flags
+}
+
+// parseFlags parses the arguments, rejecting positional ones.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError(err.Error())
+	}
+	if flags.NArg() > 0 {
+		return usageError(fmt.Sprintf("unexpected argument %q", flags.Arg(0)))
+	}
+	return nil
+}
+
+func runSort(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	var files ioFlags
+	flags := newFlagSet("sort", stderr, &files, true)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	g, err := readGraph(files.input, files.from, stdin)
+	if err != nil {
+		return err
+	}
+	nodes, err := g.DFSSort()
+	if err != nil {
+		return err
+	}
+	return writeOutput(files.output, stdout, func(w io.Writer) error {
+		for _, node := range nodes {
+			if _, err := fmt.Fprintln(w, node); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func runLayers(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	var files ioFlags
+	flags := newFlagSet("layers", stderr, &files, true)
+	width := flags.Int("width", 0, "the maximum number of nodes per layer")
+	flags.StringVar(&files.to, "to", "", "the output format: text or json, detected by the extension of the output file")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *width <= 0 {
+		return usageError("-width must be positive")
+	}
+	asJSON, err := layersFormat(files.to, files.output)
+	if err != nil {
+		return err
+	}
+
+	g, err := readGraph(files.input, files.from, stdin)
+	if err != nil {
+		return err
+	}
+	sorter, err := graff.NewCoffmanGrahamSorterWithOptions(g, graff.WithWidth(*width))
+	if err != nil {
+		return err
+	}
+	layers, err := sorter.Sort()
+	if err != nil {
+		return err
+	}
+	return writeOutput(files.output, stdout, func(w io.Writer) error {
+		if asJSON {
+			labels := make([][]string, len(layers))
+			for i, layer := range layers {
+				labels[i] = make([]string, len(layer))
+				for j, node := range layer {
+					labels[i][j] = fmt.Sprint(node)
+				}
+			}
+			return json.NewEncoder(w).Encode(labels)
+		}
+		_, err := fmt.Fprintln(w, graff.FormatLayers(layers, nil))
+		return err
+	})
+}
+
+// layersFormat determines whether the layers are written as JSON rather
+// than text, by the format named or the extension of the output file.
+func layersFormat(name string, path string) (bool, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return true, nil
+	case "text":
+		return false, nil
+	case "":
+		return strings.ToLower(filepath.Ext(path)) == ".json", nil
+	}
+	return false, usageError(fmt.Sprintf("unknown layers format %q, expected text or json", name))
+}
+
+func runConvert(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	var files ioFlags
+	flags := newFlagSet("convert", stderr, &files, true)
+	flags.StringVar(&files.to, "to", "", "the output format: "+formatNames())
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	to, err := detectFormat(files.to, files.output)
+	if err != nil {
+		return err
+	}
+
+	g, err := readGraph(files.input, files.from, stdin)
+	if err != nil {
+		return err
+	}
+	return writeOutput(files.output, stdout, func(w io.Writer) error {
+		return to.write(g, w)
+	})
+}
+
+func runCheck(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	var files ioFlags
+	flags := newFlagSet("check", stderr, &files, false)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	g, err := readGraph(files.input, files.from, stdin)
+	if err != nil {
+		return err
+	}
+	if _, err := g.DFSSort(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "ok: %d nodes, %d edges, no cycles\n", g.NodeCount(), g.EdgeCount())
+	return nil
+}
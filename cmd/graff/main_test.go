@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// golden returns the content of the file in testdata.
+func golden(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  command
+		args []string
+		want string
+	}{
+		{"sort", runSort, []string{"-i", "testdata/build.tgf"}, golden(t, "build.sort")},
+		{"layers", runLayers, []string{"-width", "2", "-i", "testdata/build.tgf"}, golden(t, "build.layers")},
+		{"layers json", runLayers, []string{"-width", "2", "-i", "testdata/build.tgf", "-to", "json"}, golden(t, "build.layers.json")},
+		{"convert", runConvert, []string{"-i", "testdata/build.tgf", "-to", "csv"}, golden(t, "build.csv")},
+		{"check", runCheck, []string{"-i", "testdata/build.tgf"}, "ok: 6 nodes, 7 edges, no cycles\n"},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		if err := test.cmd(test.args, strings.NewReader(""), &stdout, &stderr); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if stdout.String() != test.want {
+			t.Errorf("%s wrote\n%s\nwant\n%s", test.name, stdout.String(), test.want)
+		}
+	}
+}
+
+func TestStandardInput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader(golden(t, "build.tgf"))
+	if code := run([]string{"sort", "-from", "tgf"}, stdin, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if want := golden(t, "build.sort"); stdout.String() != want {
+		t.Errorf("wrote\n%s\nwant\n%s", stdout.String(), want)
+	}
+}
+
+func TestExtensionDetection(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"build.dot", "build.gv", "build.jsonl", "build.net", "build.csv", "build.tgf"} {
+		path := filepath.Join(dir, name)
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"convert", "-i", "testdata/build.tgf", "-o", path}, nil, &stdout, &stderr); code != exitOK {
+			t.Fatalf("converting to %s: exit code %d: %s", name, code, stderr.String())
+		}
+		if code := run([]string{"sort", "-i", path}, nil, &stdout, &stderr); code != exitOK {
+			t.Fatalf("sorting %s: exit code %d: %s", name, code, stderr.String())
+		}
+		if want := golden(t, "build.sort"); stdout.String() != want {
+			t.Errorf("sorting %s wrote\n%s\nwant\n%s", name, stdout.String(), want)
+		}
+	}
+
+	path := filepath.Join(dir, "layers.json")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"layers", "-width", "2", "-i", "testdata/build.tgf", "-o", path}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := golden(t, "build.layers.json"); string(data) != want {
+		t.Errorf("wrote\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{nil, exitUsage},
+		{[]string{"help"}, exitOK},
+		{[]string{"frobnicate"}, exitUsage},
+		{[]string{"sort", "-h"}, exitOK},
+		{[]string{"sort", "-bogus"}, exitUsage},
+		{[]string{"sort", "-i", "testdata/build.tgf", "extra"}, exitUsage},
+		{[]string{"sort"}, exitUsage},
+		{[]string{"sort", "-i", "testdata/build.unknown"}, exitUsage},
+		{[]string{"convert", "-i", "testdata/build.tgf", "-to", "yaml"}, exitUsage},
+		{[]string{"layers", "-i", "testdata/build.tgf"}, exitUsage},
+		{[]string{"layers", "-width", "2", "-i", "testdata/build.tgf", "-to", "xml"}, exitUsage},
+		{[]string{"check", "-i", "testdata/cycle.csv"}, exitFailure},
+		{[]string{"sort", "-i", "testdata/cycle.csv"}, exitFailure},
+		{[]string{"layers", "-width", "2", "-i", "testdata/cycle.csv"}, exitFailure},
+		{[]string{"check", "-i", "testdata/missing.csv"}, exitFailure},
+		{[]string{"check", "-from", "dot", "-i", "testdata/build.tgf"}, exitFailure},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(test.args, strings.NewReader(""), &stdout, &stderr); code != test.want {
+			t.Errorf("%v exited with %d, want %d: %s", test.args, code, test.want, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package graff
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Errors relating to encoding nodes.
+var (
+	ErrUnsupportedNode = errors.New("The node cannot be encoded")
+	ErrInvalidEncoding = errors.New("The node encoding is invalid")
+)
+
+// NodeCodec converts node values to and from bytes for the binary graph
+// format. Encoding a node must always produce the same bytes, and decoding
+// them must produce a node equal to the original.
+type NodeCodec interface {
+	EncodeNode(node Node) ([]byte, error)
+	DecodeNode(data []byte) (Node, error)
+}
+
+// DefaultNodeCodec encodes nodes of the types string, int, int64, uint64,
+// float64 and bool, prefixing the value with a tag identifying its type.
+var DefaultNodeCodec NodeCodec = defaultNodeCodec{}
+
+const (
+	tagString byte = iota + 1
+	tagInt
+	tagInt64
+	tagUint64
+	tagFloat64
+	tagBool
+)
+
+type defaultNodeCodec struct{}
+
+func (defaultNodeCodec) EncodeNode(node Node) ([]byte, error) {
+	switch v := node.(type) {
+	case string:
+		data := make([]byte, 1+len(v))
+		data[0] = tagString
+		copy(data[1:], v)
+		return data, nil
+	case int:
+		return binary.AppendVarint([]byte{tagInt}, int64(v)), nil
+	case int64:
+		return binary.AppendVarint([]byte{tagInt64}, v), nil
+	case uint64:
+		return binary.AppendUvarint([]byte{tagUint64}, v), nil
+	case float64:
+		return binary.BigEndian.AppendUint64([]byte{tagFloat64}, math.Float64bits(v)), nil
+	case bool:
+		if v {
+			return []byte{tagBool, 1}, nil
+		}
+		return []byte{tagBool, 0}, nil
+	}
+	return nil, fmt.Errorf("%w: %v of type %T", ErrUnsupportedNode, node, node)
+}
+
+func (defaultNodeCodec) DecodeNode(data []byte) (Node, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: missing type tag", ErrInvalidEncoding)
+	}
+	tag, value := data[0], data[1:]
+
+	switch tag {
+	case tagString:
+		return string(value), nil
+	case tagInt, tagInt64:
+		v, n := binary.Varint(value)
+		if n <= 0 || n != len(value) {
+			return nil, fmt.Errorf("%w: malformed integer", ErrInvalidEncoding)
+		}
+		if tag == tagInt {
+			if int64(int(v)) != v {
+				return nil, fmt.Errorf("%w: integer %d overflows int", ErrInvalidEncoding, v)
+			}
+			return int(v), nil
+		}
+		return v, nil
+	case tagUint64:
+		v, n := binary.Uvarint(value)
+		if n <= 0 || n != len(value) {
+			return nil, fmt.Errorf("%w: malformed integer", ErrInvalidEncoding)
+		}
+		return v, nil
+	case tagFloat64:
+		if len(value) != 8 {
+			return nil, fmt.Errorf("%w: malformed float", ErrInvalidEncoding)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(value)), nil
+	case tagBool:
+		if len(value) != 1 || value[0] > 1 {
+			return nil, fmt.Errorf("%w: malformed bool", ErrInvalidEncoding)
+		}
+		return value[0] == 1, nil
+	}
+	return nil, fmt.Errorf("%w: unknown type tag %d", ErrInvalidEncoding, tag)
+}
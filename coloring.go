@@ -0,0 +1,50 @@
+package graff
+
+// ColorConcurrentEvents colors the incomparability graph of the events, in
+// which two events are adjacent if they are concurrent, i.e. neither happens
+// before the other. Concurrent events never share a color, so that the
+// colors can serve as slots. Colors are numbered from 0.
+// The events are colored greedily in lexicographic topological order (see
+// LexTopoSort), each receiving the smallest color not taken by a concurrent
+// event colored before it. This is a heuristic: while the result is always
+// valid, it may use more colors than the minimum, which equals the size of
+// the largest set of mutually concurrent events.
+// The graph's reachability index is built unless a fresh one exists, see
+// BuildReachabilityIndex, so a CyclicGraphError is returned if the graph
+// contains a cycle.
+func (g *EventGraph) ColorConcurrentEvents() (map[Node]int, error) {
+	x := g.freshReachability()
+	if x == nil {
+		var err error
+		if x, err = g.BuildReachabilityIndex(); err != nil {
+			return nil, err
+		}
+	}
+	nodes, err := g.LexTopoSort(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	colors := make(map[Node]int, len(nodes))
+	for i, node := range nodes {
+		taken := make([]bool, i+1)
+		for _, other := range nodes[:i] {
+			if !x.related(node, other) {
+				taken[colors[other]] = true
+			}
+		}
+		color := 0
+		for taken[color] {
+			color++
+		}
+		colors[node] = color
+	}
+	return colors, nil
+}
+
+// related determines whether a directed path leads from either node to the
+// other, both of which must have existed when the index was built.
+func (x *ReachabilityIndex) related(a Node, b Node) bool {
+	i, j := x.ids[a], x.ids[b]
+	return x.reach[i][j/64]&(1<<uint(j%64)) != 0 || x.reach[j][i/64]&(1<<uint(i%64)) != 0
+}
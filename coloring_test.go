@@ -0,0 +1,47 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestColorConcurrentEvents(t *testing.T) {
+	// a 2x3 grid, whose largest set of concurrent events has two members,
+	// e.g. 0,2 and 1,1, so two colors suffice
+	g := NewEventGraph()
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 3; j++ {
+			node := fmt.Sprint(i, ",", j)
+			if i+1 < 2 {
+				g.AddHappensBefore(node, fmt.Sprint(i+1, ",", j))
+			}
+			if j+1 < 3 {
+				g.AddHappensBefore(node, fmt.Sprint(i, ",", j+1))
+			}
+		}
+	}
+
+	colors, err := g.ColorConcurrentEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := make(map[int]bool)
+	for _, a := range g.Nodes() {
+		used[colors[a]] = true
+		for _, b := range g.Nodes() {
+			if a != b && !g.HappensBefore(a, b) && !g.HappensBefore(b, a) && colors[a] == colors[b] {
+				t.Errorf("the concurrent events %v and %v share the color %d", a, b, colors[a])
+			}
+		}
+	}
+	if len(used) != 2 {
+		t.Errorf("used %d colors, want 2", len(used))
+	}
+
+	g.AddHappensBefore("1,2", "0,0")
+	var cyclic *CyclicGraphError
+	if _, err := g.ColorConcurrentEvents(); !errors.As(err, &cyclic) {
+		t.Errorf("got %v, want a CyclicGraphError", err)
+	}
+}
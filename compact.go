@@ -0,0 +1,106 @@
+package graff
+
+// CompactionStats reports the changes made by CompactBelow.
+type CompactionStats struct {
+	RemovedNodes int
+	RemovedEdges int
+	// AddedEdges is the number of edges added to preserve the ordering of
+	// the remaining events.
+	AddedEdges int
+}
+
+// CompactBelow removes all events which happen before any of the frontier
+// events, except the frontier events themselves, while preserving
+// HappensBefore for all remaining events.
+//
+// An ordering between remaining events can only run through removed events
+// if it starts at a frontier event, since any other event with a path into
+// the removed events happens before the frontier as well. These frontier
+// events are kept as boundary markers: an edge is added from each of them to
+// every remaining event it reached through removed events only.
+// An error wrapping ErrNodeNotFound is returned for an unknown frontier event.
+func (g *EventGraph) CompactBelow(frontier []Node) (CompactionStats, error) {
+	var stats CompactionStats
+	if err := g.checkNodes(frontier); err != nil {
+		return stats, err
+	}
+
+	// the edges of the directed graph point from an event to its dependants,
+	// so the events happening before the frontier are found walking them
+	// backwards
+	d := g.DirectedGraph
+	boundary := make(map[Node]bool, len(frontier))
+	for _, node := range frontier {
+		boundary[node] = true
+	}
+	removed := make(map[Node]bool)
+	queue := copyNodes(frontier)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		d.EachIncoming(node, func(cause Node) bool {
+			if !removed[cause] && !boundary[cause] {
+				removed[cause] = true
+				queue = append(queue, cause)
+			}
+			return true
+		})
+	}
+	if len(removed) == 0 {
+		return stats, nil
+	}
+
+	// find the remaining events each boundary event reaches through removed
+	// events only, before removing anything
+	added := make([]Edge, 0)
+	for _, node := range frontier {
+		visited := make(map[Node]bool)
+		queue := make([]Node, 0)
+		d.EachOutgoing(node, func(next Node) bool {
+			if removed[next] && !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+			return true
+		})
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			d.EachOutgoing(current, func(next Node) bool {
+				if visited[next] {
+					return true
+				}
+				visited[next] = true
+				if removed[next] {
+					queue = append(queue, next)
+				} else if next != node && !d.EdgeExists(node, next) {
+					added = append(added, Edge{node, next})
+				}
+				return true
+			})
+		}
+	}
+
+	nodes := make([]Node, 0, len(removed))
+	for _, node := range d.Nodes() {
+		if !removed[node] {
+			continue
+		}
+		nodes = append(nodes, node)
+		stats.RemovedEdges += d.OutgoingEdgeCount(node)
+		d.EachIncoming(node, func(cause Node) bool {
+			if !removed[cause] {
+				stats.RemovedEdges++
+			}
+			return true
+		})
+	}
+	d.RemoveNodes(nodes...)
+	stats.RemovedNodes = len(nodes)
+
+	for _, edge := range added {
+		d.AddEdge(edge.From, edge.To)
+	}
+	stats.AddedEdges = len(added)
+	return stats, nil
+}
@@ -0,0 +1,77 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestCompactBelow(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		g := NewEventGraph()
+		for i := 0; i < 40; i++ {
+			g.AddNode(i)
+		}
+		for i := 0; i < 80; i++ {
+			if a, b := random.Intn(40), random.Intn(40); a < b {
+				g.AddHappensBefore(a, b)
+			}
+		}
+		frontier := []Node{random.Intn(40), random.Intn(40), random.Intn(40)}
+
+		ancestors := make(map[Node]bool)
+		for _, node := range g.Nodes() {
+			for _, f := range frontier {
+				if g.HappensBefore(node, f) && node != frontier[0] && node != frontier[1] && node != frontier[2] {
+					ancestors[node] = true
+				}
+			}
+		}
+		before := make(map[[2]Node]bool)
+		for _, a := range g.Nodes() {
+			for _, b := range g.Nodes() {
+				if !ancestors[a] && !ancestors[b] {
+					before[[2]Node{a, b}] = g.HappensBefore(a, b)
+				}
+			}
+		}
+
+		stats, err := g.CompactBelow(frontier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.RemovedNodes != len(ancestors) || g.NodeCount() != 40-len(ancestors) {
+			t.Errorf("round %d: removed %d nodes, want the %d ancestors of %v", round, stats.RemovedNodes, len(ancestors), frontier)
+		}
+		for pair, want := range before {
+			if got := g.HappensBefore(pair[0], pair[1]); got != want {
+				t.Errorf("round %d: HappensBefore(%v, %v) is %v after compacting below %v, want %v", round, pair[0], pair[1], got, frontier, want)
+			}
+		}
+	}
+}
+
+func TestCompactBelowStats(t *testing.T) {
+	// x happens before the frontier event f, and after the frontier event c,
+	// which therefore keeps happening before e and f through new edges
+	g := NewEventGraph()
+	g.AddHappensBefore("c", "x")
+	g.AddHappensBefore("x", "f")
+	g.AddHappensBefore("x", "e")
+
+	stats, err := g.CompactBelow([]Node{"c", "f"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (CompactionStats{RemovedNodes: 1, RemovedEdges: 3, AddedEdges: 2}); stats != want {
+		t.Errorf("got %+v, want %+v", stats, want)
+	}
+	if !g.HappensBefore("c", "e") || !g.HappensBefore("c", "f") {
+		t.Error("c no longer happens before e and f")
+	}
+
+	if _, err := g.CompactBelow([]Node{"x"}); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("got %v, want ErrNodeNotFound", err)
+	}
+}
@@ -0,0 +1,130 @@
+package graff
+
+// Point is the position of a node within a layered layout.
+type Point struct {
+	X     float64
+	Layer int
+}
+
+// CoordinateOption configures AssignCoordinates.
+type CoordinateOption func(*coordinateConfig)
+
+type coordinateConfig struct {
+	separation float64
+	sweeps     int
+}
+
+// WithSeparation sets the minimum horizontal distance between two nodes of
+// a layer. The default is 1.
+func WithSeparation(separation float64) CoordinateOption {
+	return func(c *coordinateConfig) {
+		c.separation = separation
+	}
+}
+
+// AssignCoordinates assigns an x-coordinate to every node of the layers, as
+// returned by CoffmanGrahamSort, keeping the order of the nodes within their
+// layer and at least the minimum separation (see WithSeparation) between
+// them, so that connected nodes are roughly aligned.
+// The layers are swept upwards and downwards a few times, moving the nodes of
+// each layer as close as possible to the average position of their
+// neighbours in the layers already swept, in the least squares sense.
+// The coordinates are shifted so that the leftmost node is at 0.
+func AssignCoordinates(g Directed, layers [][]Node, opts ...CoordinateOption) map[Node]Point {
+	g = directedView(g)
+	config := &coordinateConfig{separation: 1, sweeps: 4}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	levels := make(map[Node]int, g.NodeCount())
+	xs := make(map[Node]float64, g.NodeCount())
+	for level, layer := range layers {
+		for i, node := range layer {
+			levels[node] = level
+			xs[node] = float64(i) * config.separation
+		}
+	}
+
+	// align moves the nodes of the layer towards their neighbours in the
+	// layers before (or after) it
+	align := func(layer []Node, level int, before bool) {
+		desired := make([]float64, len(layer))
+		for i, node := range layer {
+			sum, count := 0.0, 0
+			neighbour := func(other Node) bool {
+				if l, ok := levels[other]; ok && (before && l < level || !before && l > level) {
+					sum += xs[other]
+					count++
+				}
+				return true
+			}
+			eachIncoming(g, node, neighbour)
+			eachOutgoing(g, node, neighbour)
+			if count > 0 {
+				desired[i] = sum / float64(count)
+			} else {
+				desired[i] = xs[node]
+			}
+		}
+		for i, x := range separate(desired, config.separation) {
+			xs[layer[i]] = x
+		}
+	}
+
+	// end with a downwards sweep, so that every node is centred over its
+	// neighbours in the layers before it
+	for sweep := 0; sweep < config.sweeps; sweep++ {
+		for level := len(layers) - 2; level >= 0; level-- {
+			align(layers[level], level, false)
+		}
+		for level := 1; level < len(layers); level++ {
+			align(layers[level], level, true)
+		}
+	}
+
+	min := 0.0
+	first := true
+	for _, x := range xs {
+		if first || x < min {
+			min, first = x, false
+		}
+	}
+	points := make(map[Node]Point, len(xs))
+	for node, x := range xs {
+		points[node] = Point{X: x - min, Layer: levels[node]}
+	}
+	return points
+}
+
+// separate returns the positions closest to the desired ones in the least
+// squares sense which keep their order and are at least the separation
+// apart. Shifting the ith position by i times the separation turns this into
+// an isotonic regression, solved by pooling adjacent violators.
+func separate(desired []float64, separation float64) []float64 {
+	type block struct {
+		sum   float64
+		count int
+	}
+	blocks := make([]block, 0, len(desired))
+	for i, x := range desired {
+		blocks = append(blocks, block{x - float64(i)*separation, 1})
+		for len(blocks) > 1 {
+			last, prev := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if prev.sum/float64(prev.count) <= last.sum/float64(last.count) {
+				break
+			}
+			blocks = blocks[:len(blocks)-1]
+			blocks[len(blocks)-1] = block{prev.sum + last.sum, prev.count + last.count}
+		}
+	}
+
+	result := make([]float64, 0, len(desired))
+	for _, b := range blocks {
+		mean := b.sum / float64(b.count)
+		for j := 0; j < b.count; j++ {
+			result = append(result, mean+float64(len(result))*separation)
+		}
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package graff
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAssignCoordinates(t *testing.T) {
+	// a balanced binary in-tree: every pair of leaves feeds a parent
+	g := NewDirectedGraph()
+	for leaf := 0; leaf < 8; leaf++ {
+		g.AddEdge(leaf, 8+leaf/2)
+	}
+	for parent := 8; parent < 12; parent++ {
+		g.AddEdge(parent, 12+(parent-8)/2)
+	}
+	g.AddEdge(12, 14)
+	g.AddEdge(13, 14)
+	layers, err := g.CoffmanGrahamSort(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points := AssignCoordinates(g, layers, WithSeparation(2))
+	for level, layer := range layers {
+		for i, node := range layer {
+			if points[node].Layer != level {
+				t.Errorf("%v is in layer %d, want %d", node, points[node].Layer, level)
+			}
+			if i > 0 && points[node].X-points[layer[i-1]].X < 2-1e-9 {
+				t.Errorf("%v at %g overlaps %v at %g", node, points[node].X, layer[i-1], points[layer[i-1]].X)
+			}
+		}
+	}
+	for _, parent := range []Node{8, 9, 10, 11, 12, 13, 14} {
+		children := g.IncomingEdges(parent)
+		centre := (points[children[0]].X + points[children[1]].X) / 2
+		if math.Abs(points[parent].X-centre) > 1e-9 {
+			t.Errorf("%v is at %g, want it centred over %v at %g", parent, points[parent].X, children, centre)
+		}
+	}
+}
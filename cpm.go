@@ -0,0 +1,155 @@
+package graff
+
+import (
+	"math"
+)
+
+// DefaultMaxCriticalPaths is the number of critical paths enumerated by
+// CPMResult.CriticalPaths unless WithMaxCriticalPaths is used.
+const DefaultMaxCriticalPaths = 100
+
+// cpmEpsilon is the tolerance used when comparing the computed times.
+const cpmEpsilon = 1e-9
+
+// CPMResult holds the outcome of a critical path analysis.
+type CPMResult struct {
+	EarliestStart  map[Node]float64
+	EarliestFinish map[Node]float64
+	LatestStart    map[Node]float64
+	LatestFinish   map[Node]float64
+	Slack          map[Node]float64
+
+	// Critical holds the nodes without slack in topological order.
+	Critical []Node
+	// Makespan is the earliest time at which every node can be finished.
+	Makespan float64
+
+	graph    *DirectedGraph
+	maxPaths int
+}
+
+// CPMOption configures a critical path analysis.
+type CPMOption func(*CPMResult)
+
+// WithMaxCriticalPaths limits the number of chains enumerated by
+// CPMResult.CriticalPaths. A limit of zero or less means no limit.
+func WithMaxCriticalPaths(max int) CPMOption {
+	return func(r *CPMResult) {
+		r.maxPaths = max
+	}
+}
+
+// CriticalPathAnalysis computes the earliest and latest start and finish
+// times and the slack of every node using the critical path method, where
+// every node takes the time returned by the duration function and may only
+// start once the nodes it depends on were finished.
+// A nil duration function means every node takes one unit of time.
+// See https://en.wikipedia.org/wiki/Critical_path_method
+func (g *DirectedGraph) CriticalPathAnalysis(duration func(Node) float64, opts ...CPMOption) (CPMResult, error) {
+	if duration == nil {
+		duration = func(Node) float64 { return 1 }
+	}
+
+	nodes, err := g.LexTopoSort(nil)
+	if err != nil {
+		return CPMResult{}, err
+	}
+
+	r := CPMResult{
+		EarliestStart:  make(map[Node]float64, len(nodes)),
+		EarliestFinish: make(map[Node]float64, len(nodes)),
+		LatestStart:    make(map[Node]float64, len(nodes)),
+		LatestFinish:   make(map[Node]float64, len(nodes)),
+		Slack:          make(map[Node]float64, len(nodes)),
+		Critical:       make([]Node, 0),
+		graph:          g,
+		maxPaths:       DefaultMaxCriticalPaths,
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	durations := make(map[Node]float64, len(nodes))
+	for _, node := range nodes {
+		durations[node] = duration(node)
+	}
+
+	// forward pass
+	for _, node := range nodes {
+		start := 0.0
+		for _, dependant := range g.IncomingEdges(node) {
+			start = math.Max(start, r.EarliestFinish[dependant])
+		}
+		r.EarliestStart[node] = start
+		r.EarliestFinish[node] = start + durations[node]
+		r.Makespan = math.Max(r.Makespan, r.EarliestFinish[node])
+	}
+
+	// backward pass
+	for i := len(nodes) - 1; i >= 0; i-- {
+		node := nodes[i]
+		finish := r.Makespan
+		for _, outgoing := range g.OutgoingEdges(node) {
+			finish = math.Min(finish, r.LatestStart[outgoing])
+		}
+		r.LatestFinish[node] = finish
+		r.LatestStart[node] = finish - durations[node]
+		r.Slack[node] = r.LatestStart[node] - r.EarliestStart[node]
+	}
+
+	for _, node := range nodes {
+		if r.isCritical(node) {
+			r.Critical = append(r.Critical, node)
+		}
+	}
+	return r, nil
+}
+
+func (r CPMResult) isCritical(node Node) bool {
+	return math.Abs(r.Slack[node]) < cpmEpsilon
+}
+
+// CriticalPaths enumerates the chains of critical nodes running from the
+// start of the schedule to the makespan, where each node finishes exactly
+// when the next one starts. The number of chains is capped, see
+// WithMaxCriticalPaths.
+func (r CPMResult) CriticalPaths() [][]Node {
+	paths := make([][]Node, 0)
+	path := make([]Node, 0)
+
+	var walk func(node Node) bool
+	walk = func(node Node) bool {
+		path = append(path, node)
+		defer func() { path = path[:len(path)-1] }()
+
+		if math.Abs(r.EarliestFinish[node]-r.Makespan) < cpmEpsilon && !r.graph.HasOutgoingEdges(node) {
+			if r.maxPaths > 0 && len(paths) == r.maxPaths {
+				return false
+			}
+			paths = append(paths, copyNodes(path))
+			return true
+		}
+
+		for _, outgoing := range r.graph.OutgoingEdges(node) {
+			if !r.isCritical(outgoing) {
+				continue
+			}
+			if math.Abs(r.EarliestFinish[node]-r.EarliestStart[outgoing]) >= cpmEpsilon {
+				continue
+			}
+			if !walk(outgoing) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, node := range r.Critical {
+		if r.EarliestStart[node] < cpmEpsilon && !r.graph.HasIncomingEdges(node) {
+			if !walk(node) {
+				break
+			}
+		}
+	}
+	return paths
+}
@@ -0,0 +1,75 @@
+package graff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCriticalPathAnalysis(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "d")
+	g.AddEdge("b", "e")
+	durations := map[Node]float64{"a": 3, "b": 1, "c": 2, "d": 4, "e": 1}
+
+	r, err := g.CriticalPathAnalysis(func(node Node) float64 { return durations[node] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Makespan != 9 {
+		t.Errorf("makespan %v, want 9", r.Makespan)
+	}
+	want := map[Node][3]float64{
+		// earliest start, latest start, slack
+		"a": {0, 0, 0},
+		"b": {0, 2, 2},
+		"c": {3, 3, 0},
+		"d": {5, 5, 0},
+		"e": {1, 8, 7},
+	}
+	for node, times := range want {
+		got := [3]float64{r.EarliestStart[node], r.LatestStart[node], r.Slack[node]}
+		if got != times {
+			t.Errorf("%v has earliest start, latest start and slack %v, want %v", node, got, times)
+		}
+		if r.EarliestFinish[node] != times[0]+durations[node] || r.LatestFinish[node] != times[1]+durations[node] {
+			t.Errorf("%v finishes at %v and %v", node, r.EarliestFinish[node], r.LatestFinish[node])
+		}
+	}
+	if !reflect.DeepEqual(r.Critical, []Node{"a", "c", "d"}) {
+		t.Errorf("critical nodes %v, want [a c d]", r.Critical)
+	}
+	if paths := r.CriticalPaths(); !reflect.DeepEqual(paths, [][]Node{{"a", "c", "d"}}) {
+		t.Errorf("critical paths %v, want [[a c d]]", paths)
+	}
+}
+
+func TestCriticalPathsCap(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("s", "x")
+	g.AddEdge("s", "y")
+	g.AddEdge("x", "t")
+	g.AddEdge("y", "t")
+
+	r, err := g.CriticalPathAnalysis(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Makespan != 3 || len(r.CriticalPaths()) != 2 {
+		t.Errorf("got makespan %v and paths %v, want 3 and both branches", r.Makespan, r.CriticalPaths())
+	}
+	r, err = g.CriticalPathAnalysis(nil, WithMaxCriticalPaths(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths := r.CriticalPaths(); len(paths) != 1 {
+		t.Errorf("got %d paths, want the cap of 1", len(paths))
+	}
+
+	g.AddEdge("t", "s")
+	if _, err := g.CriticalPathAnalysis(nil); !errors.Is(err, ErrCyclicGraph) {
+		t.Errorf("got %v, want ErrCyclicGraph", err)
+	}
+}
@@ -0,0 +1,133 @@
+package graff
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Errors relating to the CSV edge list format.
+var (
+	ErrInvalidCSV = errors.New("The data is not a valid CSV edge list")
+)
+
+// ReadCSV reads a graph from comma-separated values listing one edge per
+// record as the labels of its source and target followed by an optional
+// numeric weight, which is stored as the edge's WeightAttr, e.g. a,b,1.5.
+// A record holding a single label adds a node without edges, and lines
+// starting with # are comments. Labels become string nodes unless
+// WithLabelMapper is used. Nodes are added in the order they first appear.
+//
+// An error wrapping ErrInvalidCSV, including the line number, is returned
+// if a record has more than three fields or an invalid weight, or one
+// wrapping ErrDuplicateNode if two labels map to the same node.
+func ReadCSV(r io.Reader, opts ...LabelOption) (*DirectedGraph, error) {
+	config := newLabelConfig(opts)
+	records := csv.NewReader(r)
+	records.Comment = '#'
+	records.FieldsPerRecord = -1
+	records.TrimLeadingSpace = true
+
+	g := NewDirectedGraph()
+	owners := newLabeledNodes(config)
+	nodes := make(map[string]Node)
+	node := func(label string) (Node, error) {
+		if node, ok := nodes[label]; ok {
+			return node, nil
+		}
+		node, err := owners.node(label)
+		if err != nil {
+			return nil, err
+		}
+		nodes[label] = node
+		g.AddNode(node)
+		return node, nil
+	}
+
+	for {
+		record, err := records.Read()
+		if err == io.EOF {
+			return g, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+		line, _ := records.FieldPos(0)
+		if len(record) > 3 {
+			return nil, fmt.Errorf("%w: line %d: %d fields, expected at most 3", ErrInvalidCSV, line, len(record))
+		}
+
+		from, err := node(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(record) == 1 {
+			continue
+		}
+		to, err := node(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		g.AddEdge(from, to)
+		if len(record) == 3 {
+			weight, err := strconv.ParseFloat(record[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: invalid weight %q", ErrInvalidCSV, line, record[2])
+			}
+			g.SetEdgeAttr(from, to, WeightAttr, weight)
+		}
+	}
+}
+
+// WriteCSV writes the graph in the CSV edge list format read by ReadCSV,
+// listing the nodes without edges in insertion order, followed by the edges
+// in the order of their source and then their target, so the output is
+// deterministic. Edges carrying a numeric WeightAttr are written with their
+// weight. Labels are the nodes' fmt.Sprint representation unless
+// WithNodeLabeler is used, and are quoted as needed. If a record would start
+// with an empty label or one starting with #, which would be read as a blank
+// or comment line, an error wrapping ErrUnsupportedNode is returned.
+func (g *DirectedGraph) WriteCSV(w io.Writer, opts ...LabelOption) error {
+	config := newLabelConfig(opts)
+	records := csv.NewWriter(w)
+	first := func(node Node) (string, error) {
+		label := config.label(node)
+		if label == "" || label[0] == '#' {
+			return "", fmt.Errorf("%w: the label %q of %v cannot start a record", ErrUnsupportedNode, label, node)
+		}
+		return label, nil
+	}
+
+	for _, node := range g.Nodes() {
+		if !g.HasEdges(node) {
+			label, err := first(node)
+			if err != nil {
+				return err
+			}
+			if err := records.Write([]string{label}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, from := range g.Nodes() {
+		label, err := first(from)
+		if err != nil && g.HasOutgoingEdges(from) {
+			return err
+		}
+		for _, to := range g.OutgoingEdges(from) {
+			record := []string{label, config.label(to)}
+			if value, ok := g.EdgeAttr(from, to, WeightAttr); ok {
+				if weight, ok := toFloat(value); ok {
+					record = append(record, strconv.FormatFloat(weight, 'g', -1, 64))
+				}
+			}
+			if err := records.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	records.Flush()
+	return records.Error()
+}
@@ -0,0 +1,150 @@
+package graff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// CytoscapeOption configures the output of WriteCytoscapeJSON.
+type CytoscapeOption func(*cytoscapeConfig)
+
+type cytoscapeConfig struct {
+	id         func(Node) string
+	levelWidth int
+	points     map[Node]Point
+}
+
+// WithCytoscapeIDs derives the element IDs of the nodes using the function
+// rather than their fmt.Sprint representation. The IDs must be unique.
+func WithCytoscapeIDs(id func(Node) string) CytoscapeOption {
+	return func(c *cytoscapeConfig) {
+		c.id = id
+	}
+}
+
+// WithCytoscapeLevels embeds the level computed by a Coffman-Graham sort of
+// the specified width as the level data field of every node, so that it can
+// be used by a layered preset layout.
+func WithCytoscapeLevels(width int) CytoscapeOption {
+	return func(c *cytoscapeConfig) {
+		c.levelWidth = width
+	}
+}
+
+// WithCytoscapePositions sets the position of every node to the coordinates
+// assigned by AssignCoordinates, with its x-coordinate as x and its layer as
+// y, for use by the preset layout which may scale them using its transform.
+func WithCytoscapePositions(points map[Node]Point) CytoscapeOption {
+	return func(c *cytoscapeConfig) {
+		c.points = points
+	}
+}
+
+type cytoscapeGraph struct {
+	Elements cytoscapeElements `json:"elements"`
+}
+
+type cytoscapeElements struct {
+	Nodes []cytoscapeNode `json:"nodes"`
+	Edges []cytoscapeEdge `json:"edges"`
+}
+
+// cytoscapeNode and cytoscapeEdge keep their data in a map, so that the
+// attributes are written alongside the fields Cytoscape.js uses.
+type cytoscapeNode struct {
+	Data     map[string]interface{} `json:"data"`
+	Position *cytoscapePosition     `json:"position,omitempty"`
+}
+
+type cytoscapePosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type cytoscapeEdge struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// cytoscapeData returns the attributes as data, leaving out those named
+// like the fields written by the format.
+func cytoscapeData(attrs map[string]interface{}, fields ...string) map[string]interface{} {
+	data := make(map[string]interface{}, len(attrs)+len(fields))
+	for key, value := range attrs {
+		data[key] = value
+	}
+	for _, field := range fields {
+		delete(data, field)
+	}
+	return data
+}
+
+// WriteCytoscapeJSON writes the graph in the Cytoscape.js elements JSON
+// format. Nodes are written in insertion order followed by the edges, so the
+// output is deterministic. The attributes of the nodes and edges are written
+// as fields of their data, except for those named like the fields written
+// by the format, i.e. id and level for nodes and source and target for
+// edges, which are left out. An error is returned if an attribute cannot be
+// encoded as JSON.
+// If two nodes share an ID an error wrapping ErrDuplicateNode is returned.
+// See https://js.cytoscape.org/#notation/elements-json
+func (g *DirectedGraph) WriteCytoscapeJSON(w io.Writer, opts ...CytoscapeOption) error {
+	config := &cytoscapeConfig{
+		id: func(node Node) string { return fmt.Sprint(node) },
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	var levels map[Node]int
+	if config.levelWidth > 0 {
+		layers, err := g.CoffmanGrahamSort(config.levelWidth)
+		if err != nil {
+			return err
+		}
+		levels = make(map[Node]int, g.NodeCount())
+		for level, layer := range layers {
+			for _, node := range layer {
+				levels[node] = level
+			}
+		}
+	}
+
+	nodes := g.Nodes()
+	ids := make(map[Node]string, len(nodes))
+	owners := make(map[string]Node, len(nodes))
+	out := cytoscapeGraph{cytoscapeElements{
+		Nodes: make([]cytoscapeNode, 0, len(nodes)),
+		Edges: make([]cytoscapeEdge, 0),
+	}}
+	for _, node := range nodes {
+		id := config.id(node)
+		if owner, ok := owners[id]; ok {
+			return fmt.Errorf("%w: %v and %v both have the ID %q", ErrDuplicateNode, owner, node, id)
+		}
+		ids[node] = id
+		owners[id] = node
+
+		data := cytoscapeData(g.NodeAttrs(node), "id", "level")
+		data["id"] = id
+		if level, ok := levels[node]; ok {
+			data["level"] = level
+		}
+		element := cytoscapeNode{Data: data}
+		if point, ok := config.points[node]; ok {
+			element.Position = &cytoscapePosition{X: point.X, Y: float64(point.Layer)}
+		}
+		out.Elements.Nodes = append(out.Elements.Nodes, element)
+	}
+
+	for _, from := range nodes {
+		for _, to := range g.OutgoingEdges(from) {
+			data := cytoscapeData(g.EdgeAttrs(from, to), "source", "target")
+			data["source"] = ids[from]
+			data["target"] = ids[to]
+			out.Elements.Edges = append(out.Elements.Edges, cytoscapeEdge{data})
+		}
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
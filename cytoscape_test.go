@@ -0,0 +1,61 @@
+package graff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteCytoscapeJSON(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("fetch", "build")
+	g.AddEdge("build", "test")
+	g.AddEdge("fetch", "lint")
+	g.SetNodeAttr("build", "owner", "ci")
+	g.SetNodeAttr("build", "id", "ignored")
+	g.SetEdgeAttr("fetch", "build", WeightAttr, 2.5)
+	g.SetEdgeAttr("build", "test", "source", "ignored")
+
+	var b bytes.Buffer
+	if err := g.WriteCytoscapeJSON(&b, WithCytoscapeLevels(2)); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "cytoscape.json")
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, b.Bytes(), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(indented.Bytes()), bytes.TrimSpace(want)) {
+		t.Errorf("wrote\n%s\nwant\n%s", indented.String(), want)
+	}
+}
+
+func TestWriteCytoscapeIDsAndPositions(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge(1, 2)
+	id := WithCytoscapeIDs(func(node Node) string { return fmt.Sprintf("n%d", node) })
+	positions := WithCytoscapePositions(map[Node]Point{1: {X: 0.5, Layer: 0}, 2: {X: 1.5, Layer: 1}})
+
+	var b bytes.Buffer
+	if err := g.WriteCytoscapeJSON(&b, id, positions); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"elements":{"nodes":[{"data":{"id":"n1"},"position":{"x":0.5,"y":0}},{"data":{"id":"n2"},"position":{"x":1.5,"y":1}}],"edges":[{"data":{"source":"n1","target":"n2"}}]}}`
+	if got := strings.TrimSpace(b.String()); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+
+	clash := WithCytoscapeIDs(func(node Node) string { return "n" })
+	if err := g.WriteCytoscapeJSON(&b, clash); !errors.Is(err, ErrDuplicateNode) {
+		t.Errorf("got %v for clashing IDs, want ErrDuplicateNode", err)
+	}
+}
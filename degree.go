@@ -0,0 +1,107 @@
+package graff
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// NodeDegree pairs a node with its in- or outdegree.
+type NodeDegree struct {
+	Node   Node
+	Degree int
+}
+
+// TopByInDegree returns the n nodes with the most incoming edges (fan-in),
+// the highest first, breaking ties by insertion order. All nodes are
+// returned if the graph has fewer than n.
+// Degrees are read from the adjacency lists in constant time, so this takes
+// O(V log n) time.
+func (g *DirectedGraph) TopByInDegree(n int) []NodeDegree {
+	return g.topByDegree(n, g.IncomingEdgeCount)
+}
+
+// TopByOutDegree returns the n nodes with the most outgoing edges (fan-out),
+// see TopByInDegree.
+func (g *DirectedGraph) TopByOutDegree(n int) []NodeDegree {
+	return g.topByDegree(n, g.OutgoingEdgeCount)
+}
+
+// DegreeHistogram counts the nodes by their indegree and outdegree, mapping
+// every degree which occurs to the number of nodes having it.
+func (g *DirectedGraph) DegreeHistogram() (in map[int]int, out map[int]int) {
+	in = make(map[int]int)
+	out = make(map[int]int)
+	for _, node := range g.Nodes() {
+		in[g.IncomingEdgeCount(node)]++
+		out[g.OutgoingEdgeCount(node)]++
+	}
+	return in, out
+}
+
+func (g *DirectedGraph) topByDegree(n int, degree func(Node) int) []NodeDegree {
+	if n <= 0 {
+		return make([]NodeDegree, 0)
+	}
+
+	// keep the best n nodes seen so far in a heap with the worst on top
+	h := &degreeHeap{}
+	for position, node := range g.Nodes() {
+		entry := degreeEntry{NodeDegree{node, degree(node)}, position}
+		if h.Len() < n {
+			heap.Push(h, entry)
+		} else if h.worse(h.entries[0], entry) {
+			h.entries[0] = entry
+			heap.Fix(h, 0)
+		}
+	}
+
+	sort.Slice(h.entries, func(i, j int) bool {
+		return h.worse(h.entries[j], h.entries[i])
+	})
+	top := make([]NodeDegree, len(h.entries))
+	for i, entry := range h.entries {
+		top[i] = entry.NodeDegree
+	}
+	return top
+}
+
+type degreeEntry struct {
+	NodeDegree
+	position int
+}
+
+// degreeHeap implements heap.Interface, keeping the worst entry on top.
+type degreeHeap struct {
+	entries []degreeEntry
+}
+
+// worse determines whether a ranks below b, having a lower degree or the
+// same degree and a later insertion position.
+func (h *degreeHeap) worse(a degreeEntry, b degreeEntry) bool {
+	if a.Degree != b.Degree {
+		return a.Degree < b.Degree
+	}
+	return a.position > b.position
+}
+
+func (h *degreeHeap) Len() int {
+	return len(h.entries)
+}
+
+func (h *degreeHeap) Less(i, j int) bool {
+	return h.worse(h.entries[i], h.entries[j])
+}
+
+func (h *degreeHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+}
+
+func (h *degreeHeap) Push(x interface{}) {
+	h.entries = append(h.entries, x.(degreeEntry))
+}
+
+func (h *degreeHeap) Pop() interface{} {
+	entry := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return entry
+}
@@ -0,0 +1,72 @@
+package graff
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestTopByDegree(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddNode("z")
+	for _, edge := range [][2]Node{{"a", "b"}, {"a", "c"}, {"b", "c"}, {"d", "c"}, {"a", "d"}, {"z", "b"}} {
+		g.AddEdge(edge[0], edge[1])
+	}
+
+	// b and d tie with c on their fan-in, z came first
+	if got, want := g.TopByInDegree(3), []NodeDegree{{"c", 3}, {"b", 2}, {"d", 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got fan-in %v, want %v", got, want)
+	}
+	if got, want := g.TopByOutDegree(3), []NodeDegree{{"a", 3}, {"z", 1}, {"b", 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got fan-out %v, want %v", got, want)
+	}
+	if got := g.TopByInDegree(10); len(got) != g.NodeCount() {
+		t.Errorf("got %d nodes, want all %d", len(got), g.NodeCount())
+	}
+	if got := g.TopByInDegree(0); len(got) != 0 {
+		t.Errorf("got %v for n = 0", got)
+	}
+
+	in, out := g.DegreeHistogram()
+	if want := map[int]int{0: 2, 1: 1, 2: 1, 3: 1}; !reflect.DeepEqual(in, want) {
+		t.Errorf("got the indegree histogram %v, want %v", in, want)
+	}
+	if want := map[int]int{0: 1, 1: 3, 3: 1}; !reflect.DeepEqual(out, want) {
+		t.Errorf("got the outdegree histogram %v, want %v", out, want)
+	}
+}
+
+func TestTopByDegreeTracksMutations(t *testing.T) {
+	random := rand.New(rand.NewSource(3))
+	g := layeredGraph(300, 4)
+	for i := 0; i < 200; i++ {
+		nodes := g.Nodes()
+		switch from, to := nodes[random.Intn(len(nodes))], nodes[random.Intn(len(nodes))]; random.Intn(3) {
+		case 0:
+			g.AddEdge(from, to)
+		case 1:
+			g.RemoveEdge(from, to)
+		default:
+			g.RemoveNodes(from)
+		}
+	}
+
+	// compare with the degrees counted from scratch, ranked by a stable sort
+	want := make([]NodeDegree, 0, g.NodeCount())
+	for _, node := range g.Nodes() {
+		want = append(want, NodeDegree{node, len(g.IncomingEdges(node))})
+	}
+	sort.SliceStable(want, func(i, j int) bool { return want[i].Degree > want[j].Degree })
+	if got := g.TopByInDegree(20); !reflect.DeepEqual(got, want[:20]) {
+		t.Errorf("got %v, want %v", got, want[:20])
+	}
+}
+
+func BenchmarkTopByInDegree(b *testing.B) {
+	g := layeredGraph(100000, 5)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.TopByInDegree(20)
+	}
+}
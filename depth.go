@@ -0,0 +1,157 @@
+package graff
+
+// NodeDepths returns the depth of every node, i.e. the length of the longest
+// path from any root to the node, so that roots have a depth of 0.
+// A CyclicGraphError is returned if the graph contains a cycle.
+func (g *DirectedGraph) NodeDepths() (map[Node]int, error) {
+	return g.longestPaths(true)
+}
+
+// NodeHeights returns the height of every node, i.e. the length of the
+// longest path from the node to any leaf, so that leaves have a height of 0.
+// Heights make a good priority for the PriorityListScheduler, scheduling the
+// nodes on the critical path first.
+// A CyclicGraphError is returned if the graph contains a cycle.
+func (g *DirectedGraph) NodeHeights() (map[Node]int, error) {
+	return g.longestPaths(false)
+}
+
+// LongestPathLayering assigns every node to the layer of its depth, so that
+// every edge points to a later layer and the number of layers is minimal.
+// Layers are unbounded in width, see CoffmanGrahamSort for a bounded
+// layering. The nodes of a layer are in insertion order.
+// A CyclicGraphError is returned if the graph contains a cycle.
+func (g *DirectedGraph) LongestPathLayering() ([][]Node, error) {
+	depths, err := g.NodeDepths()
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([][]Node, 0)
+	for _, node := range g.Nodes() {
+		depth := depths[node]
+		for len(layers) <= depth {
+			layers = append(layers, make([]Node, 0))
+		}
+		layers[depth] = append(layers[depth], node)
+	}
+	return layers, nil
+}
+
+// longestPaths computes the length of the longest path leading to every node
+// if forward, or leading from every node otherwise, in a single pass over
+// the nodes in topological order.
+func (g *DirectedGraph) longestPaths(forward bool) (map[Node]int, error) {
+	nodes, err := g.LexTopoSort(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	lengths := make(map[Node]int, len(nodes))
+	each := g.EachIncoming
+	if !forward {
+		each = g.EachOutgoing
+		for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+			nodes[i], nodes[j] = nodes[j], nodes[i]
+		}
+	}
+
+	for _, node := range nodes {
+		length := 0
+		each(node, func(neighbour Node) bool {
+			if l := lengths[neighbour] + 1; l > length {
+				length = l
+			}
+			return true
+		})
+		lengths[node] = length
+	}
+	return lengths, nil
+}
+
+// MinSpanLayering assigns the nodes to layers so as to reduce the total span
+// of the edges, i.e. the number of layers they cross, rather than the number
+// of layers, which makes for shorter edges when rendering. Starting from the
+// LongestPathLayering, it repeatedly moves nodes with more outgoing than
+// incoming edges one layer further along, together with the dependants
+// which would otherwise no longer follow them, whenever this reduces the
+// total span and adds no layers, following the promotion heuristic of
+// Nikolov and Tarassov. It stops once a pass over all nodes brings no
+// improvement, or after maxIterations passes unless it is zero or less.
+// Empty layers are dropped and the nodes of a layer are in insertion order.
+// A CyclicGraphError is returned if the graph contains a cycle.
+// See https://doi.org/10.1016/j.dam.2005.05.023
+func (g *DirectedGraph) MinSpanLayering(maxIterations int) ([][]Node, error) {
+	levels, err := g.NodeDepths()
+	if err != nil {
+		return nil, err
+	}
+	last := 0
+	for _, level := range levels {
+		if level > last {
+			last = level
+		}
+	}
+
+	// promote moves the node one layer further along, recursively moving the
+	// dependants in the next layer too, and returns the resulting change of
+	// the total span, or false if a node would leave the last layer. The
+	// moved nodes are recorded so that the promotion can be undone.
+	moved := make([]Node, 0)
+	var promote func(node Node) (int, bool)
+	promote = func(node Node) (int, bool) {
+		if levels[node] == last {
+			return 0, false
+		}
+		change := 0
+		ok := true
+		g.EachOutgoing(node, func(to Node) bool {
+			if levels[to] == levels[node]+1 {
+				var c int
+				if c, ok = promote(to); !ok {
+					return false
+				}
+				change += c
+			}
+			return true
+		})
+		if !ok {
+			return 0, false
+		}
+		levels[node]++
+		moved = append(moved, node)
+		return change + g.IncomingEdgeCount(node) - g.OutgoingEdgeCount(node), true
+	}
+
+	for iteration := 0; maxIterations <= 0 || iteration < maxIterations; iteration++ {
+		improved := false
+		for _, node := range g.Nodes() {
+			if g.OutgoingEdgeCount(node) <= g.IncomingEdgeCount(node) {
+				continue
+			}
+			moved = moved[:0]
+			if change, ok := promote(node); ok && change < 0 {
+				improved = true
+			} else {
+				for _, n := range moved {
+					levels[n]--
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	layers := make([][]Node, last+1)
+	for _, node := range g.Nodes() {
+		layers[levels[node]] = append(layers[levels[node]], node)
+	}
+	compacted := make([][]Node, 0, len(layers))
+	for _, layer := range layers {
+		if len(layer) > 0 {
+			compacted = append(compacted, layer)
+		}
+	}
+	return compacted, nil
+}
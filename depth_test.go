@@ -0,0 +1,76 @@
+package graff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNodeDepthsAndHeights(t *testing.T) {
+	// a diamond with a shortcut and a tail: a->b->d, a->c->d, a->d, d->e
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "d")
+	g.AddEdge("c", "d")
+	g.AddEdge("a", "d")
+	g.AddEdge("d", "e")
+
+	depths, err := g.NodeDepths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[Node]int{"a": 0, "b": 1, "c": 1, "d": 2, "e": 3}; !reflect.DeepEqual(depths, want) {
+		t.Errorf("got the depths %v, want %v", depths, want)
+	}
+	heights, err := g.NodeHeights()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[Node]int{"a": 3, "b": 2, "c": 2, "d": 1, "e": 0}; !reflect.DeepEqual(heights, want) {
+		t.Errorf("got the heights %v, want %v", heights, want)
+	}
+
+	g.AddEdge("e", "a")
+	var cyclic *CyclicGraphError
+	if _, err := g.NodeDepths(); !errors.As(err, &cyclic) {
+		t.Errorf("NodeDepths got %v, want a CyclicGraphError", err)
+	}
+	if _, err := g.NodeHeights(); !errors.As(err, &cyclic) {
+		t.Errorf("NodeHeights got %v, want a CyclicGraphError", err)
+	}
+}
+
+func TestMinSpanLayering(t *testing.T) {
+	// p only feeds the end of the chain, so it belongs next to x2
+	g := NewDirectedGraph()
+	g.AddEdge("x0", "x1")
+	g.AddEdge("x1", "x2")
+	g.AddEdge("x2", "x3")
+	g.AddEdge("p", "x3")
+	layers, err := g.MinSpanLayering(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]Node{{"x0"}, {"x1"}, {"x2", "p"}, {"x3"}}; !reflect.DeepEqual(layers, want) {
+		t.Errorf("got %v, want %v", layers, want)
+	}
+
+	for _, g := range []*DirectedGraph{layeredGraph(60, 2), layeredGraph(100, 4)} {
+		longest, err := g.LongestPathLayering()
+		if err != nil {
+			t.Fatal(err)
+		}
+		minSpan, err := g.MinSpanLayering(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateLayering(g, minSpan, 0); err != nil {
+			t.Fatal(err)
+		}
+		before, after := LayeringMetrics(g, longest), LayeringMetrics(g, minSpan)
+		if after.TotalSpan > before.TotalSpan || len(minSpan) > len(longest) {
+			t.Errorf("the total span went from %d to %d and the layers from %d to %d", before.TotalSpan, after.TotalSpan, len(longest), len(minSpan))
+		}
+	}
+}
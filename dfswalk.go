@@ -0,0 +1,116 @@
+package graff
+
+// EdgeKind classifies an edge by its role in a depth-first search.
+type EdgeKind int
+
+// The kinds of edges encountered by a depth-first search.
+const (
+	// EdgeTree leads to a node discovered through it.
+	EdgeTree EdgeKind = iota
+	// EdgeForward leads to a descendant in the search tree which was
+	// already discovered through another path.
+	EdgeForward
+	// EdgeBack leads to an ancestor in the search tree, or is a self-loop,
+	// and so closes a cycle.
+	EdgeBack
+	// EdgeCross leads to a node in another subtree which was already
+	// finished.
+	EdgeCross
+)
+
+var edgeKindNames = []string{"tree", "forward", "back", "cross"}
+
+func (k EdgeKind) String() string {
+	if k < 0 || int(k) >= len(edgeKindNames) {
+		return "unknown"
+	}
+	return edgeKindNames[k]
+}
+
+// DFSWalkResult holds the timestamps of a depth-first search, see Walk.
+type DFSWalkResult struct {
+	// Discovery and Finish hold the times at which every node was first
+	// reached and left. Both share a single clock starting at 1, so that the
+	// interval of a descendant nests within that of its ancestor.
+	Discovery map[Node]int
+	Finish    map[Node]int
+
+	edges map[Edge]EdgeKind
+	// order holds the edges in the order they were encountered
+	order []Edge
+}
+
+// EdgeClassification returns the kind of every edge of the graph.
+func (r *DFSWalkResult) EdgeClassification() map[Edge]EdgeKind {
+	return r.edges
+}
+
+// BackEdges returns the back edges, each of which closes a cycle, in the
+// order they were encountered. The graph is acyclic if there are none.
+func (r *DFSWalkResult) BackEdges() []Edge {
+	back := make([]Edge, 0)
+	for _, edge := range r.order {
+		if r.edges[edge] == EdgeBack {
+			back = append(back, edge)
+		}
+	}
+	return back
+}
+
+// Walk performs a depth-first search of the whole graph like Sort, starting
+// from the nodes in the same order (see WithRootOrder), but records the
+// discovery and finish time of every node and classifies every edge instead
+// of sorting the nodes. Unlike Sort it tolerates cycles, which show up as
+// back edges.
+// See https://en.wikipedia.org/wiki/Depth-first_search#Output_of_a_depth-first_search
+func (s *DFSSorter) Walk() (*DFSWalkResult, error) {
+	roots, err := s.roots()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &DFSWalkResult{
+		Discovery: make(map[Node]int, s.graph.NodeCount()),
+		Finish:    make(map[Node]int, s.graph.NodeCount()),
+		edges:     make(map[Edge]EdgeKind, s.graph.NodeCount()),
+		order:     make([]Edge, 0, s.graph.NodeCount()),
+	}
+	time := 0
+
+	var visit func(node Node)
+	visit = func(node Node) {
+		time++
+		r.Discovery[node] = time
+		prefetchOutgoing(s.graph, node)
+		eachOutgoing(s.graph, node, func(outgoing Node) bool {
+			edge := Edge{node, outgoing}
+			r.order = append(r.order, edge)
+			_, discovered := r.Discovery[outgoing]
+			_, finished := r.Finish[outgoing]
+			switch {
+			case !discovered:
+				r.edges[edge] = EdgeTree
+				visit(outgoing)
+			case !finished:
+				r.edges[edge] = EdgeBack
+			case r.Discovery[node] < r.Discovery[outgoing]:
+				r.edges[edge] = EdgeForward
+			default:
+				r.edges[edge] = EdgeCross
+			}
+			return true
+		})
+		time++
+		r.Finish[node] = time
+	}
+
+	for _, root := range roots {
+		if _, ok := r.Discovery[root]; !ok {
+			visit(root)
+		}
+	}
+	if err := graphErr(s.graph); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,44 @@
+package graff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalkCLRS(t *testing.T) {
+	// the graph of CLRS figure 22.4, searched in alphabetical order
+	g := NewDirectedGraph()
+	for _, edge := range []Edge{
+		{"u", "v"}, {"u", "x"}, {"v", "y"}, {"w", "y"}, {"w", "z"},
+		{"x", "v"}, {"y", "x"}, {"z", "z"},
+	} {
+		g.AddEdge(edge.From, edge.To)
+	}
+	s, err := NewDFSSorterWithOptions(g, WithRootOrder([]Node{"u", "v", "w", "x", "y", "z"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[Node]int{"u": 1, "v": 2, "y": 3, "x": 4, "w": 9, "z": 10}; !reflect.DeepEqual(r.Discovery, want) {
+		t.Errorf("got the discovery times %v, want %v", r.Discovery, want)
+	}
+	if want := map[Node]int{"x": 5, "y": 6, "v": 7, "u": 8, "z": 11, "w": 12}; !reflect.DeepEqual(r.Finish, want) {
+		t.Errorf("got the finish times %v, want %v", r.Finish, want)
+	}
+	want := map[Edge]EdgeKind{
+		{"u", "v"}: EdgeTree, {"v", "y"}: EdgeTree, {"y", "x"}: EdgeTree, {"w", "z"}: EdgeTree,
+		{"u", "x"}: EdgeForward,
+		{"x", "v"}: EdgeBack, {"z", "z"}: EdgeBack,
+		{"w", "y"}: EdgeCross,
+	}
+	if got := r.EdgeClassification(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got the edge kinds %v, want %v", got, want)
+	}
+	if back := r.BackEdges(); !reflect.DeepEqual(back, []Edge{{"x", "v"}, {"z", "z"}}) {
+		t.Errorf("got the back edges %v, want x->v and z->z", back)
+	}
+}
@@ -1,49 +1,277 @@
 package graff
 
+import (
+	"errors"
+	"sort"
+)
+
+// Errors relating to the DirectedGraph.
+var (
+	ErrNodeNotFound           = errors.New("The node does not exist within the graph")
+	ErrConcurrentModification = errors.New("The graph was modified while it was being sorted")
+)
+
 // DirectedGraph is a graph supporting directed edges between nodes.
 type DirectedGraph struct {
 	*graph
 
-	edges *directedEdgeList
+	edges     *directedEdgeList
+	nodeAttrs *attributeList
+	edgeAttrs *attributeList
+
+	// reachability is the index last built by BuildReachabilityIndex
+	reachability *ReachabilityIndex
+	// observers are notified of mutations, see OnMutate
+	observers *observerList
 }
 
 // NewDirectedGraph creates a graph of nodes with directed edges.
 func NewDirectedGraph() *DirectedGraph {
+	index := newNodeIndex()
 	return &DirectedGraph{
-		graph: newGraph(),
-		edges: newDirectedEdgeList(),
+		graph:     newGraph(index),
+		edges:     newDirectedEdgeList(index),
+		nodeAttrs: newAttributeList(),
+		edgeAttrs: newAttributeList(),
 	}
 }
 
 // Copy returns a clone of the directed graph.
 func (g *DirectedGraph) Copy() *DirectedGraph {
+	index := g.edges.index.Copy()
 	return &DirectedGraph{
-		graph: g.graph.Copy(),
-		edges: g.edges.Copy(),
+		graph:     g.graph.Copy(index),
+		edges:     g.edges.Copy(index),
+		nodeAttrs: g.nodeAttrs.Copy(),
+		edgeAttrs: g.edgeAttrs.Copy(),
+	}
+}
+
+// Grow increases the graph's capacity, if necessary, to guarantee space for
+// the specified number of additional nodes and edges, avoiding the cost of
+// growing the internal storage incrementally when loading large graphs.
+// It is cheapest when called on an empty graph. If either number is
+// negative, Grow panics.
+func (g *DirectedGraph) Grow(nodes int, edges int) {
+	if nodes < 0 || edges < 0 {
+		panic("graff.Grow: negative capacity")
 	}
+	g.graph.nodes.grow(nodes)
+	g.edges.grow(nodes, edges)
 }
 
-// EdgeCount returns the number of direced edges between nodes.
+// EdgeCount returns the number of distinct directed edges between nodes.
 func (g *DirectedGraph) EdgeCount() int {
 	return g.edges.Count()
 }
 
+// AddNode inserts the specified node into the graph.
+// A node can be any value, e.g. int, string, pointer to a struct, map etc.
+// Duplicate nodes are ignored.
+func (g *DirectedGraph) AddNode(node Node) {
+	g.AddNodes(node)
+}
+
+// AddNodes inserts the specified nodes into the graph.
+// A node can be any value, e.g. int, string, pointer to a struct, map etc.
+// Duplicate nodes are ignored.
+func (g *DirectedGraph) AddNodes(nodes ...Node) {
+	added := g.newNodes(nodes...)
+	g.graph.AddNodes(nodes...)
+	g.notify(MutationAddNode, added, nil)
+}
+
+// newNodes returns the distinct nodes which do not exist within the graph
+// yet if mutations are observed, and nil otherwise.
+func (g *DirectedGraph) newNodes(nodes ...Node) []Node {
+	if !g.observing() {
+		return nil
+	}
+	added := make([]Node, 0)
+	seen := make(map[Node]bool, len(nodes))
+	for _, node := range nodes {
+		if g.NodeExists(node) || seen[node] {
+			continue
+		}
+		seen[node] = true
+		added = append(added, node)
+	}
+	return added
+}
+
 // AddEdge adds the edge to the graph.
+// Edges have set semantics: adding an existing edge again has no effect.
+// Self-loops are permitted, but make the graph cyclic so that sorting it
+// fails with a CyclicGraphError.
 func (g *DirectedGraph) AddEdge(from Node, to Node) {
+	added := g.newNodes(from, to)
+	existed := g.edges.Exists(from, to)
+
 	// prevent adding an edge referring to missing nodes
 	if !g.NodeExists(from) {
-		g.AddNode(from)
+		g.graph.AddNode(from)
 	}
 	if !g.NodeExists(to) {
-		g.AddNode(to)
+		g.graph.AddNode(to)
 	}
 
+	// adding the nodes recorded their modification, and re-adding an
+	// existing edge changes nothing
+	if !existed {
+		g.modified()
+	}
 	g.edges.Add(from, to)
+
+	g.notify(MutationAddNode, added, nil)
+	if !existed {
+		g.notify(MutationAddEdge, nil, []Edge{{from, to}})
+	}
+}
+
+// AddEdgeCounted adds the edge to the graph, incrementing its multiplicity if
+// it already exists. Queries and sorters still see a single edge, but it will
+// take as many calls to RemoveEdge to remove it.
+func (g *DirectedGraph) AddEdgeCounted(from Node, to Node) {
+	added := g.newNodes(from, to)
+	if !g.NodeExists(from) {
+		g.graph.AddNode(from)
+	}
+	if !g.NodeExists(to) {
+		g.graph.AddNode(to)
+	}
+
+	g.modified()
+	g.edges.AddCounted(from, to)
+
+	g.notify(MutationAddNode, added, nil)
+	g.notify(MutationAddEdge, nil, []Edge{{from, to}})
+}
+
+// AddEdges adds the edges to the graph like AddEdge, recording a single
+// modification for the whole batch. Use Grow beforehand when the total
+// number of edges is known. Observers are notified once for all nodes and
+// once for all edges added.
+func (g *DirectedGraph) AddEdges(edges ...Edge) {
+	observing := g.observing()
+	var addedNodes []Node
+	var addedEdges []Edge
+
+	for _, edge := range edges {
+		if !g.NodeExists(edge.From) {
+			g.nodes.Add(edge.From)
+			if observing {
+				addedNodes = append(addedNodes, edge.From)
+			}
+		}
+		if !g.NodeExists(edge.To) {
+			g.nodes.Add(edge.To)
+			if observing {
+				addedNodes = append(addedNodes, edge.To)
+			}
+		}
+		if observing && !g.edges.Exists(edge.From, edge.To) {
+			addedEdges = append(addedEdges, edge)
+		}
+		g.edges.Add(edge.From, edge.To)
+	}
+	g.modified()
+
+	g.notify(MutationAddNode, addedNodes, nil)
+	g.notify(MutationAddEdge, nil, addedEdges)
+}
+
+// EdgeMultiplicity returns the number of times the edge was added by
+// AddEdgeCounted, 1 for edges added by AddEdge, or 0 if it does not exist.
+func (g *DirectedGraph) EdgeMultiplicity(from Node, to Node) int {
+	return g.edges.Multiplicity(from, to)
+}
+
+// RemoveNode removes the specified node and all edges to or from it,
+// together with their attributes.
+// If the node does not exist within the graph the call will fail silently,
+// see RemoveNodeChecked.
+func (g *DirectedGraph) RemoveNode(node Node) {
+	g.RemoveNodes(node)
+}
+
+// RemoveNodes removes the specified nodes and all edges to or from them,
+// together with their attributes.
+// If a node does not exist within the graph the call will fail silently.
+func (g *DirectedGraph) RemoveNodes(nodes ...Node) {
+	var removedNodes []Node
+	var removedEdges []Edge
+	if g.observing() {
+		removedNodes = make([]Node, 0, len(nodes))
+		seen := make(map[Node]bool, len(nodes))
+		for _, node := range nodes {
+			if g.NodeExists(node) && !seen[node] {
+				seen[node] = true
+				removedNodes = append(removedNodes, node)
+			}
+		}
+		removedEdges = make([]Edge, 0)
+	}
+	remove := func(from Node, to Node) {
+		if removedEdges != nil && g.edges.Exists(from, to) {
+			removedEdges = append(removedEdges, Edge{from, to})
+		}
+		g.deleteEdge(from, to)
+	}
+
+	for _, node := range nodes {
+		for _, to := range copyNodes(g.OutgoingEdges(node)) {
+			remove(node, to)
+		}
+		for _, from := range copyNodes(g.IncomingEdges(node)) {
+			remove(from, node)
+		}
+	}
+	for _, node := range nodes {
+		g.nodeAttrs.RemoveAll(node)
+	}
+	g.graph.RemoveNodes(copyNodes(nodes)...)
+
+	g.notify(MutationRemoveNode, removedNodes, removedEdges)
 }
 
 // RemoveEdge removes the edge from the graph.
+// For an edge with a multiplicity above one only the multiplicity is
+// decremented. Once the edge is gone its attributes, including its weight,
+// are removed too. If the edge does not exist within the graph the call will
+// fail silently, see RemoveEdgeChecked.
 func (g *DirectedGraph) RemoveEdge(from Node, to Node) {
-	g.edges.Remove(from, to)
+	g.RemoveEdges(Edge{from, to})
+}
+
+// RemoveEdges removes the edges from the graph like RemoveEdge, returning
+// the number of them which existed. Observers are notified of a single
+// MutationRemoveEdge.
+func (g *DirectedGraph) RemoveEdges(edges ...Edge) int {
+	removed := make([]Edge, 0, len(edges))
+	for _, edge := range edges {
+		if !g.edges.Exists(edge.From, edge.To) {
+			continue
+		}
+		g.edges.Remove(edge.From, edge.To)
+		if !g.edges.Exists(edge.From, edge.To) {
+			g.edgeAttrs.RemoveAll(edge)
+		}
+		removed = append(removed, edge)
+	}
+
+	if len(removed) > 0 {
+		g.modified()
+		g.notify(MutationRemoveEdge, nil, removed)
+	}
+	return len(removed)
+}
+
+// deleteEdge removes the edge regardless of its multiplicity, together
+// with its attributes.
+func (g *DirectedGraph) deleteEdge(from Node, to Node) {
+	g.modified()
+	g.edges.Delete(from, to)
+	g.edgeAttrs.RemoveAll(Edge{from, to})
 }
 
 // HasEdges determines whether the graph contains any edges to or from the node.
@@ -77,6 +305,13 @@ func (g *DirectedGraph) IncomingEdgeCount(node Node) int {
 	return g.edges.IncomingEdgeCount(node)
 }
 
+// EachIncoming calls fn for the nodes belonging to directed edges pointing
+// towards the specified node until it returns false, without allocating.
+// The graph must not be modified by fn.
+func (g *DirectedGraph) EachIncoming(node Node, fn func(Node) bool) {
+	g.edges.EachIncoming(node, fn)
+}
+
 // HasOutgoingEdges checks whether the graph contains any directed
 // edges pointing from the node.
 func (g *DirectedGraph) HasOutgoingEdges(node Node) bool {
@@ -95,6 +330,13 @@ func (g *DirectedGraph) OutgoingEdgeCount(node Node) int {
 	return g.edges.OutgoingEdgeCount(node)
 }
 
+// EachOutgoing calls fn for the nodes belonging to directed edges pointing
+// from the specified node until it returns false, without allocating.
+// The graph must not be modified by fn.
+func (g *DirectedGraph) EachOutgoing(node Node, fn func(Node) bool) {
+	g.edges.EachOutgoing(node, fn)
+}
+
 // RootNodes finds the entry-point nodes to the graph, i.e. those without
 // incoming edges.
 func (g *DirectedGraph) RootNodes() []Node {
@@ -118,6 +360,30 @@ func (g *DirectedGraph) IsolatedNodes() []Node {
 	return results
 }
 
+// RemoveIsolatedNodes removes the nodes without edges, e.g. those left behind
+// by removing edges, and returns them in the graph's node order. Observers
+// are notified of a single MutationRemoveNode. Incremental sorters keep the
+// levels of removed nodes, so pass them to the sorter's RemoveNode as well.
+func (g *DirectedGraph) RemoveIsolatedNodes() []Node {
+	return g.RemoveIsolatedNodesExcept(nil)
+}
+
+// RemoveIsolatedNodesExcept removes the nodes without edges like
+// RemoveIsolatedNodes, except for those for which keep returns true, e.g.
+// intentional placeholders. A nil keep removes all isolated nodes.
+func (g *DirectedGraph) RemoveIsolatedNodesExcept(keep func(Node) bool) []Node {
+	removed := make([]Node, 0)
+	for _, node := range g.Nodes() {
+		if !g.HasEdges(node) && (keep == nil || !keep(node)) {
+			removed = append(removed, node)
+		}
+	}
+	if len(removed) > 0 {
+		g.RemoveNodes(removed...)
+	}
+	return removed
+}
+
 // AdjacencyMatrix returns a matrix indicating whether pairs of nodes are
 // adjacent or not within the graph.
 func (g *DirectedGraph) AdjacencyMatrix() map[Node]map[Node]bool {
@@ -134,17 +400,66 @@ func (g *DirectedGraph) AdjacencyMatrix() map[Node]map[Node]bool {
 
 // RemoveTransitives removes any transitive edges so that as fewest possible
 // edges exist while matching the reachability of the original graph.
-func (g *DirectedGraph) RemoveTransitives() {
-	for _, a := range g.Nodes() {
-		for _, b := range g.Nodes() {
+// Transitivity is undefined on cyclic graphs, so when the graph contains a
+// cycle (including a self-loop) it is left unchanged and a CyclicGraphError
+// is returned instead.
+func (g *DirectedGraph) RemoveTransitives() error {
+	return g.RemoveTransitivesExcept(nil)
+}
+
+// RemoveTransitivesExcept removes transitive edges like RemoveTransitives,
+// except for those the keep function reports as protected, e.g. because
+// they were declared explicitly. A nil keep function protects no edges.
+func (g *DirectedGraph) RemoveTransitivesExcept(keep func(from Node, to Node) bool) error {
+	if cycle := g.findCycle(); cycle != nil {
+		return &CyclicGraphError{Cycle: cycle}
+	}
+
+	// the edges are visited in node order, as the edges removed first
+	// decide which remain
+	nodes := g.Nodes()
+	positions := make(map[Node]int, len(nodes))
+	for i, node := range nodes {
+		positions[node] = i
+	}
+
+	var removed []Edge
+	observing := g.observing()
+	for _, a := range nodes {
+		successors := copyNodes(g.OutgoingEdges(a))
+		sort.Slice(successors, func(i, j int) bool {
+			return positions[successors[i]] < positions[successors[j]]
+		})
+		for _, b := range successors {
 			if !g.EdgeExists(a, b) {
 				continue
 			}
-			for _, c := range g.Nodes() {
-				if g.EdgeExists(b, c) {
-					g.RemoveEdge(a, c)
+			for _, c := range g.OutgoingEdges(b) {
+				if keep != nil && g.EdgeExists(a, c) && keep(a, c) {
+					continue
+				}
+				if observing && g.EdgeExists(a, c) {
+					removed = append(removed, Edge{a, c})
 				}
+				g.deleteEdge(a, c)
 			}
 		}
 	}
+	g.notify(MutationRemoveTransitives, nil, removed)
+	return nil
+}
+
+// reaches determines whether a directed path leads from one node to the other,
+// consulting the reachability index if it is not stale and otherwise using a
+// breadth-first search. Every node reaches itself.
+func (g *DirectedGraph) reaches(from Node, to Node) bool {
+	if from == to {
+		return g.NodeExists(from)
+	}
+	if x := g.freshReachability(); x != nil {
+		if ok, err := x.Reaches(from, to); err == nil {
+			return ok
+		}
+	}
+	return g.pathExists(from, to)
 }
@@ -0,0 +1,485 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// layeredGraph returns a random DAG of n nodes, each with up to degree edges
+// to nodes added later, which is the same for the same arguments.
+func layeredGraph(n int, degree int) *DirectedGraph {
+	rng := rand.New(rand.NewSource(int64(n)))
+	g := NewDirectedGraph()
+	g.Grow(n, n*degree)
+	for i := 0; i < n; i++ {
+		g.AddNode(i)
+	}
+	for i := 0; i < n-1; i++ {
+		for j := 0; j < degree; j++ {
+			g.AddEdge(i, i+1+rng.Intn(n-i-1))
+		}
+	}
+	return g
+}
+
+func TestRemoveNodeKeepsIndexConsistent(t *testing.T) {
+	g := layeredGraph(50, 3)
+	copied := g.Copy()
+	for i := 0; i < 50; i += 3 {
+		g.RemoveNode(i)
+	}
+	g.AddEdge(1, "new")
+	g.AddEdge("new", 2)
+	if err := g.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := copied.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if copied.NodeCount() != 50 || !copied.NodeExists(3) {
+		t.Error("removing nodes changed the copy")
+	}
+	if seq, _ := g.InsertionIndex("new"); seq != 50 {
+		t.Errorf("the new node was numbered %d, want 50", seq)
+	}
+}
+
+func TestRemoveNodeClearsEdgeAttrs(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("c", "a")
+	g.SetEdgeAttr("a", "b", WeightAttr, 5)
+	g.SetEdgeAttr("c", "a", "label", "x")
+	g.SetNodeAttr("a", "color", "red")
+
+	g.RemoveNode("a")
+	g.AddEdge("a", "b")
+	g.AddEdge("c", "a")
+	if attrs := g.EdgeAttrs("a", "b"); len(attrs) != 0 {
+		t.Errorf("the re-added edge a -> b has attributes %v", attrs)
+	}
+	if attrs := g.EdgeAttrs("c", "a"); len(attrs) != 0 {
+		t.Errorf("the re-added edge c -> a has attributes %v", attrs)
+	}
+	if weight := g.edgeWeight("a", "b"); weight != 1 {
+		t.Errorf("the re-added edge weighs %v, want 1", weight)
+	}
+	if attrs := g.NodeAttrs("a"); len(attrs) != 0 {
+		t.Errorf("the re-added node has attributes %v", attrs)
+	}
+}
+
+func TestRemoveChecked(t *testing.T) {
+	g := NewEventGraph()
+	g.AddHappensBefore("a", "b")
+	g.AddHappensBefore("a", "c")
+
+	if err := g.RemoveEdgeChecked("a", "b"); !errors.Is(err, ErrEdgeNotFound) {
+		t.Errorf("removing the reversed edge returned %v, want ErrEdgeNotFound", err)
+	}
+	if err := g.RemoveEdgeChecked("b", "a"); err != nil {
+		t.Error(err)
+	}
+	if err := g.RemoveEdgeChecked("b", "typo"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("removing an edge of a missing node returned %v, want ErrNodeNotFound", err)
+	}
+	if n := g.RemoveEdges(Edge{"c", "a"}, Edge{"c", "a"}, Edge{"b", "a"}); n != 1 {
+		t.Errorf("RemoveEdges removed %d edges, want 1", n)
+	}
+	if err := g.RemoveNodeChecked("typo"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("removing a missing node returned %v, want ErrNodeNotFound", err)
+	}
+	if err := g.RemoveNodeChecked("a"); err != nil || g.NodeExists("a") {
+		t.Errorf("removing a returned %v", err)
+	}
+}
+
+func TestRemoveMissingEdgesKeepsGeneration(t *testing.T) {
+	g := layeredGraph(10, 2)
+	generation := g.generation
+	if n := g.RemoveEdges(Edge{0, 0}, Edge{"x", "y"}); n != 0 {
+		t.Errorf("RemoveEdges removed %d missing edges", n)
+	}
+	if g.generation != generation {
+		t.Error("removing missing edges modified the graph")
+	}
+}
+
+func TestAddEdgeTwice(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "b")
+	if outgoing := g.OutgoingEdges("a"); len(outgoing) != 1 || g.EdgeMultiplicity("a", "b") != 1 {
+		t.Errorf("a duplicate edge was added: %v, multiplicity %d", outgoing, g.EdgeMultiplicity("a", "b"))
+	}
+	g.RemoveEdge("a", "b")
+	if g.EdgeExists("a", "b") {
+		t.Error("a single remove left the edge added twice")
+	}
+}
+
+func TestAddEdgeCounted(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdgeCounted("a", "b")
+	g.AddEdgeCounted("a", "b")
+	g.AddEdge("a", "b")
+	if got := g.EdgeMultiplicity("a", "b"); got != 2 {
+		t.Errorf("multiplicity %d, want 2", got)
+	}
+	if len(g.OutgoingEdges("a")) != 1 || len(g.IncomingEdges("b")) != 1 {
+		t.Error("the counted edge is listed more than once")
+	}
+
+	copied := g.Copy()
+	g.RemoveEdge("a", "b")
+	if !g.EdgeExists("a", "b") || g.EdgeMultiplicity("a", "b") != 1 {
+		t.Errorf("one remove of a double edge left multiplicity %d, want 1", g.EdgeMultiplicity("a", "b"))
+	}
+	g.RemoveEdge("a", "b")
+	if g.EdgeExists("a", "b") || g.EdgeMultiplicity("a", "b") != 0 {
+		t.Error("the edge survived as many removes as adds")
+	}
+	if got := copied.EdgeMultiplicity("a", "b"); got != 2 {
+		t.Errorf("the copy has multiplicity %d, want 2", got)
+	}
+
+	e := NewEventGraph()
+	e.AddEdgeCounted("b", "a")
+	e.AddEdgeCounted("b", "a")
+	e.RemoveEdge("b", "a")
+	if got := e.EdgeMultiplicity("b", "a"); got != 1 {
+		t.Errorf("event graph multiplicity %d, want 1", got)
+	}
+}
+
+func TestRemoveTransitives(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("a", "c")
+	if err := g.RemoveTransitives(); err != nil {
+		t.Fatal(err)
+	}
+	if g.EdgeExists("a", "c") || !g.EdgeExists("a", "b") || !g.EdgeExists("b", "c") {
+		t.Errorf("got the edges %v, want a -> b -> c", g.AdjacencyMatrix())
+	}
+}
+
+func TestRemoveTransitivesCyclic(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	g.AddEdge("c", "a")
+	g.AddEdge("c", "b")
+	before := g.AdjacencyMatrix()
+
+	err := g.RemoveTransitives()
+	var cyclic *CyclicGraphError
+	if !errors.As(err, &cyclic) || len(cyclic.Cycle) != 2 {
+		t.Errorf("got %v, want the cycle between a and b", err)
+	}
+	if after := g.AdjacencyMatrix(); !reflect.DeepEqual(after, before) {
+		t.Errorf("the edges changed from %v to %v", before, after)
+	}
+
+	_, err = NewCoffmanGrahamSorter(g, 2).Sort()
+	if !errors.As(err, &cyclic) || errors.Is(err, ErrDependencyOrder) {
+		t.Errorf("the sorter returned %v, want a CyclicGraphError", err)
+	}
+}
+
+func TestRemoveTransitivesExcept(t *testing.T) {
+	protected := func(from Node, to Node) bool { return from == "a" && to == "c" }
+	triangle := func() *DirectedGraph {
+		g := NewDirectedGraph()
+		g.AddEdge("a", "b")
+		g.AddEdge("b", "c")
+		g.AddEdge("a", "c")
+		g.AddEdge("b", "d")
+		g.AddEdge("a", "d")
+		return g
+	}
+
+	g := triangle()
+	if err := g.RemoveTransitivesExcept(protected); err != nil {
+		t.Fatal(err)
+	}
+	if !g.EdgeExists("a", "c") || g.EdgeExists("a", "d") {
+		t.Errorf("got the edges %v, want a -> c kept and a -> d removed", g.AdjacencyMatrix())
+	}
+
+	g = triangle()
+	s, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(2), WithKeepEdges(protected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := s.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.ReducedGraph().EdgeExists("a", "c") || s.ReducedGraph().EdgeExists("a", "d") {
+		t.Error("the sorter did not keep a -> c while reducing")
+	}
+	if err := ValidateLayering(g, layers, 2); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEachEdges(t *testing.T) {
+	g := layeredGraph(100, 4)
+	for _, node := range g.Nodes() {
+		var outgoing, incoming []Node
+		g.EachOutgoing(node, func(to Node) bool {
+			outgoing = append(outgoing, to)
+			return true
+		})
+		g.EachIncoming(node, func(from Node) bool {
+			incoming = append(incoming, from)
+			return true
+		})
+		if !reflect.DeepEqual(outgoing, nilIfEmpty(g.OutgoingEdges(node))) || !reflect.DeepEqual(incoming, nilIfEmpty(g.IncomingEdges(node))) {
+			t.Fatalf("%v: iterated %v and %v, want %v and %v", node, outgoing, incoming, g.OutgoingEdges(node), g.IncomingEdges(node))
+		}
+	}
+
+	visited := 0
+	g.EachOutgoing(0, func(Node) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Errorf("visited %d nodes after stopping at the first", visited)
+	}
+	g.EachOutgoing("missing", func(Node) bool {
+		t.Error("visited an edge of a missing node")
+		return true
+	})
+
+	allocs := testing.AllocsPerRun(10, func() {
+		for _, node := range g.Nodes() {
+			g.EachOutgoing(node, func(Node) bool { return true })
+		}
+	})
+	// the only allocation is the slice returned by Nodes
+	if allocs > 1 {
+		t.Errorf("iterating allocated %v times", allocs)
+	}
+}
+
+func nilIfEmpty(nodes []Node) []Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes
+}
+
+func BenchmarkAddEdge(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		layeredGraph(10000, 4)
+	}
+}
+
+// BenchmarkGrow inserts 5M edges between 1.25M nodes into an empty graph,
+// with and without growing it for them beforehand.
+func BenchmarkGrow(b *testing.B) {
+	const nodes, degree = 1250000, 4
+	edges := make([]Edge, 0, nodes*degree)
+	for i := 0; i < nodes; i++ {
+		for j := 1; j <= degree; j++ {
+			edges = append(edges, Edge{i, (i + j*7919) % nodes})
+		}
+	}
+	for _, grow := range []bool{false, true} {
+		name := "without"
+		if grow {
+			name = "with"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				g := NewDirectedGraph()
+				if grow {
+					g.Grow(nodes, len(edges))
+				}
+				for _, edge := range edges {
+					g.AddEdge(edge.From, edge.To)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkOutgoingEdges(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	nodes := g.Nodes()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, node := range nodes {
+			_ = g.OutgoingEdges(node)
+		}
+	}
+}
+
+func BenchmarkEachOutgoing(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	nodes := g.Nodes()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, node := range nodes {
+			g.EachOutgoing(node, func(Node) bool { return true })
+		}
+	}
+}
+
+func BenchmarkDFSSort(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := g.DFSSort(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLexTopoSort(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := g.LexTopoSort(nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCoffmanGrahamSort(b *testing.B) {
+	g := layeredGraph(500, 4)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := g.CoffmanGrahamSort(8); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMillionNodes measures the heap held by a DAG of 1M nodes with
+// four edges each, and traversing its adjacency.
+func BenchmarkMillionNodes(b *testing.B) {
+	const nodes = 1000000
+	b.Run("build", func(b *testing.B) {
+		b.ReportAllocs()
+		var before, after runtime.MemStats
+		for i := 0; i < b.N; i++ {
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			g := layeredGraph(nodes, 4)
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			runtime.KeepAlive(g)
+		}
+		b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc), "heap-bytes")
+	})
+
+	g := layeredGraph(nodes, 4)
+	order := g.Nodes()
+	b.Run("EachOutgoing", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, node := range order {
+				g.EachOutgoing(node, func(Node) bool { return true })
+			}
+		}
+	})
+	b.Run("OutgoingEdges", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, node := range order {
+				_ = g.OutgoingEdges(node)
+			}
+		}
+	})
+	b.Run("DFSSort", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := g.DFSSort(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("LexTopoSort", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := g.LexTopoSort(nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestRemoveIsolatedNodes(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	for _, node := range []Node{"p", "q", "r"} {
+		g.AddNode(node)
+	}
+	sorter := NewOptimizedCoffmanGrahamSorter(g, 2)
+	if _, err := sorter.EventSort(); err != nil {
+		t.Fatal(err)
+	}
+
+	g.RemoveEdge("b", "c")
+	if got, want := g.IsolatedNodes(), []Node{"c", "p", "q", "r"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got isolated nodes %v, want %v", got, want)
+	}
+	if g.NodeCount() != 6 {
+		t.Errorf("IsolatedNodes removed nodes from the graph")
+	}
+
+	events := make([]MutationEvent, 0)
+	g.OnMutate(func(event MutationEvent) {
+		events = append(events, event)
+	})
+	removed := g.RemoveIsolatedNodesExcept(func(node Node) bool { return node == "q" })
+	if want := []Node{"c", "p", "r"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed %v, want %v", removed, want)
+	}
+	if len(events) != 1 || events[0].Op != MutationRemoveNode || !reflect.DeepEqual(events[0].Nodes, removed) || len(events[0].Edges) != 0 {
+		t.Errorf("observed %v, want a single removal of %v", events, removed)
+	}
+
+	// the sorter forgets the removed nodes and a later sort does not bring
+	// them back
+	for _, node := range removed {
+		if !sorter.RemoveNode(node) {
+			t.Errorf("the sorter had not leveled %v", node)
+		}
+	}
+	g.AddEdge("b", "d")
+	layers, err := sorter.EventSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, layer := range layers {
+		for _, node := range layer {
+			if !g.NodeExists(node) {
+				t.Errorf("the removed node %v was resurrected in %v", node, layers)
+			}
+		}
+	}
+	if got := g.RemoveIsolatedNodes(); !reflect.DeepEqual(got, []Node{"q"}) {
+		t.Errorf("RemoveIsolatedNodes removed %v, want [q]", got)
+	}
+}
@@ -0,0 +1,122 @@
+package graff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// dotAttrKeys are the node and edge attributes which are written as DOT
+// attributes by default.
+var dotAttrKeys = map[string]bool{
+	"label":     true,
+	"color":     true,
+	"style":     true,
+	"shape":     true,
+	"fillcolor": true,
+	"weight":    true,
+	"penwidth":  true,
+}
+
+// DOTOption configures the output of WriteDOT.
+type DOTOption func(*dotConfig)
+
+type dotConfig struct {
+	allAttrs  bool
+	clusterBy string
+}
+
+// WithDOTAttributes includes every node and edge attribute in the output,
+// rather than just the well-known DOT attributes (label, color, style,
+// shape, fillcolor, weight and penwidth).
+func WithDOTAttributes() DOTOption {
+	return func(c *dotConfig) {
+		c.allAttrs = true
+	}
+}
+
+// WithClusterBy groups the nodes into one cluster subgraph per distinct
+// value of the node attribute, named cluster_<value>. Nodes without the
+// attribute and all edges remain at the top level. Clusters are written in
+// order of their names and nodes in insertion order.
+func WithClusterBy(key string) DOTOption {
+	return func(c *dotConfig) {
+		c.clusterBy = key
+	}
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+// Nodes are identified by their fmt.Sprint representation, and are written
+// in insertion order followed by the edges, so the output is deterministic.
+// See https://graphviz.org/doc/info/lang.html
+func (g *DirectedGraph) WriteDOT(w io.Writer, opts ...DOTOption) error {
+	config := &dotConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	b := bufio.NewWriter(w)
+	b.WriteString("digraph {\n")
+	clusters := make(map[string][]Node)
+	for _, node := range g.Nodes() {
+		if config.clusterBy != "" {
+			if value, ok := g.NodeAttr(node, config.clusterBy); ok {
+				name := fmt.Sprint(value)
+				clusters[name] = append(clusters[name], node)
+				continue
+			}
+		}
+		fmt.Fprintf(b, "\t%s%s;\n", dotQuote(fmt.Sprint(node)), config.attrs(g.NodeAttrs(node)))
+	}
+
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(b, "\tsubgraph %s {\n", dotQuote("cluster_"+name))
+		fmt.Fprintf(b, "\t\tlabel=%s;\n", dotQuote(name))
+		for _, node := range clusters[name] {
+			fmt.Fprintf(b, "\t\t%s%s;\n", dotQuote(fmt.Sprint(node)), config.attrs(g.NodeAttrs(node)))
+		}
+		b.WriteString("\t}\n")
+	}
+
+	for _, from := range g.Nodes() {
+		for _, to := range g.OutgoingEdges(from) {
+			fmt.Fprintf(b, "\t%s -> %s%s;\n", dotQuote(fmt.Sprint(from)), dotQuote(fmt.Sprint(to)), config.attrs(g.EdgeAttrs(from, to)))
+		}
+	}
+	b.WriteString("}\n")
+	return b.Flush()
+}
+
+// attrs formats the attributes as a DOT attribute list, sorted by key.
+func (c *dotConfig) attrs(values map[string]interface{}) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if c.allAttrs || dotAttrKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = dotQuote(key) + "=" + dotQuote(fmt.Sprint(values[key]))
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// dotQuote returns the identifier as a quoted DOT string.
+func dotQuote(id string) string {
+	return `"` + dotEscaper.Replace(id) + `"`
+}
@@ -0,0 +1,37 @@
+package graff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDOTQuoteRoundTrip(t *testing.T) {
+	g := NewDirectedGraph()
+	labels := []string{`C:\tmp\`, `a\"b`, "two\nlines", `\n`}
+	for i := 1; i < len(labels); i++ {
+		g.AddEdge(labels[i-1], labels[i])
+	}
+	g.SetEdgeAttr(labels[0], labels[1], "label", `ends in \`)
+
+	var b bytes.Buffer
+	if err := g.WriteDOT(&b, WithDOTAttributes()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"C:\\tmp\\"`) {
+		t.Errorf("backslashes were not escaped:\n%s", b.String())
+	}
+
+	parsed, err := ParseDOT(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, node := range parsed.Nodes() {
+		if node != labels[i] {
+			t.Errorf("node %d was read as %q, want %q", i, node, labels[i])
+		}
+	}
+	if label, _ := parsed.EdgeAttr(labels[0], labels[1], "label"); label != `ends in \` {
+		t.Errorf("the edge label was read as %q", label)
+	}
+}
@@ -0,0 +1,402 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Errors relating to reading the DOT language.
+var (
+	ErrInvalidDOT = errors.New("The data is not a supported DOT digraph")
+)
+
+// ParseDOT reads a directed graph in the subset of the Graphviz DOT language
+// written by WriteDOT: a digraph holding node statements, edge statements
+// chaining any number of nodes with ->, and attribute lists. Node and edge
+// attributes are stored as strings, except for a numeric weight, which is
+// stored as the edge's WeightAttr. Subgraphs are flattened into the graph,
+// and graph attributes and attribute defaults are ignored. Identifiers may
+// be quoted strings, names or numerals, and comments are skipped. Labels
+// become string nodes unless WithLabelMapper is used. Nodes are added in the
+// order they first appear.
+//
+// An error wrapping ErrInvalidDOT, including the line number, is returned if
+// the data is malformed or uses unsupported features, e.g. an undirected
+// graph, subgraphs as edge endpoints, ports or HTML strings, or one wrapping
+// ErrDuplicateNode if two labels map to the same node.
+// See https://graphviz.org/doc/info/lang.html
+func ParseDOT(r io.Reader, opts ...LabelOption) (*DirectedGraph, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := dotTokenize(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dotParser{
+		tokens: tokens,
+		config: newLabelConfig(opts),
+		g:      NewDirectedGraph(),
+		nodes:  make(map[string]Node),
+	}
+	p.owners = newLabeledNodes(p.config)
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.g, nil
+}
+
+// dotToken is a token of the DOT language: an identifier, which may have
+// been quoted, or punctuation such as {, [, = or ->.
+type dotToken struct {
+	text   string
+	id     bool
+	quoted bool
+	line   int
+}
+
+// dotTokenize splits the data into tokens, skipping whitespace and comments.
+func dotTokenize(data string) ([]dotToken, error) {
+	tokens := make([]dotToken, 0)
+	line := 1
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: line %d: %s", ErrInvalidDOT, line, fmt.Sprintf(format, args...))
+	}
+	lineStart := true
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '\n':
+			line++
+			lineStart = true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case c == '#' && lineStart:
+			// preprocessor output lines
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(data[i:], "//"):
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(data[i:], "/*"):
+			end := strings.Index(data[i+2:], "*/")
+			if end < 0 {
+				return nil, fail("unterminated comment")
+			}
+			line += strings.Count(data[i:i+2+end], "\n")
+			i += end + 4
+			continue
+		}
+		lineStart = false
+
+		switch {
+		case c == '"':
+			var b strings.Builder
+			start := line
+			j := i + 1
+			for ; j < len(data) && data[j] != '"'; j++ {
+				switch {
+				case data[j] == '\\' && j+1 < len(data) && (data[j+1] == '"' || data[j+1] == '\\'):
+					b.WriteByte(data[j+1])
+					j++
+				case data[j] == '\\' && j+1 < len(data) && data[j+1] == '\n':
+					// line continuation
+					line++
+					j++
+				case data[j] == '\\' && j+1 < len(data) && data[j+1] == 'n':
+					b.WriteByte('\n')
+					j++
+				default:
+					if data[j] == '\n' {
+						line++
+					}
+					b.WriteByte(data[j])
+				}
+			}
+			if j >= len(data) {
+				line = start
+				return nil, fail("unterminated string")
+			}
+			tokens = append(tokens, dotToken{text: b.String(), id: true, quoted: true, line: start})
+			i = j + 1
+		case strings.HasPrefix(data[i:], "->"), strings.HasPrefix(data[i:], "--"):
+			tokens = append(tokens, dotToken{text: data[i : i+2], line: line})
+			i += 2
+		case strings.ContainsRune("{}[];,=:", rune(c)):
+			tokens = append(tokens, dotToken{text: string(c), line: line})
+			i++
+		case c == '<':
+			return nil, fail("HTML strings are not supported")
+		case c == '-' || c == '.' || unicode.IsDigit(rune(c)):
+			j := i + 1
+			for j < len(data) && (data[j] == '.' || unicode.IsDigit(rune(data[j]))) {
+				j++
+			}
+			tokens = append(tokens, dotToken{text: data[i:j], id: true, line: line})
+			i = j
+		case c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)):
+			j := i + 1
+			for j < len(data) && (data[j] == '_' || data[j] >= 0x80 || unicode.IsLetter(rune(data[j])) || unicode.IsDigit(rune(data[j]))) {
+				j++
+			}
+			tokens = append(tokens, dotToken{text: data[i:j], id: true, line: line})
+			i = j
+		default:
+			return nil, fail("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+// dotParser builds a graph from the tokens of a DOT digraph.
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+
+	config *labelConfig
+	owners *labeledNodes
+	g      *DirectedGraph
+	nodes  map[string]Node
+}
+
+func (p *dotParser) fail(format string, args ...interface{}) error {
+	line := 0
+	if p.pos < len(p.tokens) {
+		line = p.tokens[p.pos].line
+	} else if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf("%w: line %d: %s", ErrInvalidDOT, line, fmt.Sprintf(format, args...))
+}
+
+// peek returns the current token, or an empty one at the end.
+func (p *dotParser) peek() dotToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return dotToken{}
+}
+
+// keyword determines whether the current token is the keyword, which is
+// case-insensitive and never quoted.
+func (p *dotParser) keyword(word string) bool {
+	t := p.peek()
+	return t.id && !t.quoted && strings.EqualFold(t.text, word)
+}
+
+// punct determines whether the current token is the punctuation.
+func (p *dotParser) punct(text string) bool {
+	t := p.peek()
+	return !t.id && t.text == text && p.pos < len(p.tokens)
+}
+
+func (p *dotParser) expect(text string) error {
+	if !p.punct(text) {
+		return p.fail("expected %q", text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *dotParser) parse() error {
+	if p.keyword("strict") {
+		p.pos++
+	}
+	switch {
+	case p.keyword("digraph"):
+		p.pos++
+	case p.keyword("graph"):
+		return p.fail("undirected graphs are not supported")
+	default:
+		return p.fail("expected digraph")
+	}
+	if p.peek().id {
+		p.pos++
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if err := p.statements(); err != nil {
+		return err
+	}
+	if err := p.expect("}"); err != nil {
+		return err
+	}
+	if p.pos < len(p.tokens) {
+		return p.fail("unexpected data after the graph")
+	}
+	return nil
+}
+
+// statements parses statements up to the closing brace.
+func (p *dotParser) statements() error {
+	for p.pos < len(p.tokens) && !p.punct("}") {
+		if p.punct(";") {
+			p.pos++
+			continue
+		}
+		if err := p.statement(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *dotParser) statement() error {
+	switch {
+	case p.keyword("graph"), p.keyword("node"), p.keyword("edge"):
+		// attribute defaults
+		p.pos++
+		_, err := p.attrs()
+		return err
+	case p.keyword("subgraph"), p.punct("{"):
+		if p.keyword("subgraph") {
+			p.pos++
+			if p.peek().id {
+				p.pos++
+			}
+		}
+		if err := p.expect("{"); err != nil {
+			return err
+		}
+		if err := p.statements(); err != nil {
+			return err
+		}
+		if err := p.expect("}"); err != nil {
+			return err
+		}
+		if p.punct("->") {
+			return p.fail("subgraphs as edge endpoints are not supported")
+		}
+		return nil
+	}
+
+	t := p.peek()
+	if !t.id {
+		return p.fail("unexpected %q", t.text)
+	}
+	p.pos++
+	if p.punct("=") {
+		// graph attribute
+		p.pos++
+		if !p.peek().id {
+			return p.fail("expected a value")
+		}
+		p.pos++
+		return nil
+	}
+	if p.punct(":") {
+		return p.fail("ports are not supported")
+	}
+
+	chain := []string{t.text}
+	for p.punct("->") || p.punct("--") {
+		if p.punct("--") {
+			return p.fail("undirected edges are not supported")
+		}
+		p.pos++
+		if p.keyword("subgraph") || p.punct("{") {
+			return p.fail("subgraphs as edge endpoints are not supported")
+		}
+		next := p.peek()
+		if !next.id {
+			return p.fail("expected a node")
+		}
+		p.pos++
+		if p.punct(":") {
+			return p.fail("ports are not supported")
+		}
+		chain = append(chain, next.text)
+	}
+	attrs, err := p.attrs()
+	if err != nil {
+		return err
+	}
+
+	nodes := make([]Node, len(chain))
+	for i, label := range chain {
+		node, err := p.node(label)
+		if err != nil {
+			return err
+		}
+		nodes[i] = node
+	}
+	if len(nodes) == 1 {
+		for key, value := range attrs {
+			p.g.SetNodeAttr(nodes[0], key, value)
+		}
+		return nil
+	}
+	for i := 1; i < len(nodes); i++ {
+		p.g.AddEdge(nodes[i-1], nodes[i])
+		for key, value := range attrs {
+			if key == WeightAttr {
+				if weight, err := strconv.ParseFloat(value, 64); err == nil {
+					p.g.SetEdgeAttr(nodes[i-1], nodes[i], key, weight)
+					continue
+				}
+			}
+			p.g.SetEdgeAttr(nodes[i-1], nodes[i], key, value)
+		}
+	}
+	return nil
+}
+
+// attrs parses any number of attribute lists.
+func (p *dotParser) attrs() (map[string]string, error) {
+	attrs := make(map[string]string)
+	for p.punct("[") {
+		p.pos++
+		for !p.punct("]") {
+			key := p.peek()
+			if !key.id {
+				return nil, p.fail("expected an attribute")
+			}
+			p.pos++
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value := p.peek()
+			if !value.id {
+				return nil, p.fail("expected a value for %q", key.text)
+			}
+			p.pos++
+			attrs[key.text] = value.text
+			if p.punct(",") || p.punct(";") {
+				p.pos++
+			}
+		}
+		p.pos++
+	}
+	return attrs, nil
+}
+
+// node returns the label's node, adding it to the graph when it first
+// appears.
+func (p *dotParser) node(label string) (Node, error) {
+	if node, ok := p.nodes[label]; ok {
+		return node, nil
+	}
+	node, err := p.owners.node(label)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.tokens[p.pos-1].line, err)
+	}
+	p.nodes[label] = node
+	p.g.AddNode(node)
+	return node, nil
+}
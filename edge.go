@@ -1,125 +1,352 @@
 package graff
 
+import (
+	"fmt"
+)
+
+// Edge represents a directed edge between two nodes.
+type Edge struct {
+	From Node
+	To   Node
+}
+
+// directedEdgeList stores the edges between nodes interned to dense IDs by
+// the index shared with the graph's nodes, holding the adjacency of every ID
+// in insertion order.
 type directedEdgeList struct {
-	outgoingEdges map[Node]*nodeList
-	incomingEdges map[Node]*nodeList
+	index    *nodeIndex
+	outgoing [][]int32
+	incoming [][]int32
+	// edges holds the multiplicity of every edge, keyed by edgeKey
+	edges map[uint64]int
+	// added and removed count the distinct edges ever added and removed,
+	// see Checkpoint
+	added   int
+	removed int
 }
 
-func newDirectedEdgeList() *directedEdgeList {
+func newDirectedEdgeList(index *nodeIndex) *directedEdgeList {
 	return &directedEdgeList{
-		outgoingEdges: make(map[Node]*nodeList),
-		incomingEdges: make(map[Node]*nodeList),
+		index:    index,
+		outgoing: make([][]int32, 0),
+		incoming: make([][]int32, 0),
+		edges:    make(map[uint64]int),
 	}
 }
 
-func (l *directedEdgeList) Copy() *directedEdgeList {
-	outgoingEdges := make(map[Node]*nodeList, len(l.outgoingEdges))
-	for node, edges := range l.outgoingEdges {
-		outgoingEdges[node] = edges.Copy()
+// edgeKey packs the IDs of an edge's nodes into a single map key.
+func edgeKey(from int32, to int32) uint64 {
+	return uint64(uint32(from))<<32 | uint64(uint32(to))
+}
+
+// splitEdgeKey returns the IDs of the nodes packed into the key.
+func splitEdgeKey(key uint64) (int32, int32) {
+	return int32(uint32(key >> 32)), int32(uint32(key))
+}
+
+func copyAdjacency(adjacency [][]int32) [][]int32 {
+	result := make([][]int32, len(adjacency))
+	for i, ids := range adjacency {
+		if len(ids) > 0 {
+			result[i] = make([]int32, len(ids))
+			copy(result[i], ids)
+		}
 	}
+	return result
+}
 
-	incomingEdges := make(map[Node]*nodeList, len(l.incomingEdges))
-	for node, edges := range l.incomingEdges {
-		incomingEdges[node] = edges.Copy()
+// Copy returns a copy of the edges using the index, which must be a copy of
+// the list's index.
+func (l *directedEdgeList) Copy(index *nodeIndex) *directedEdgeList {
+	edges := make(map[uint64]int, len(l.edges))
+	for key, count := range l.edges {
+		edges[key] = count
 	}
 
 	return &directedEdgeList{
-		outgoingEdges: outgoingEdges,
-		incomingEdges: incomingEdges,
+		index:    index,
+		outgoing: copyAdjacency(l.outgoing),
+		incoming: copyAdjacency(l.incoming),
+		edges:    edges,
+		added:    l.added,
+		removed:  l.removed,
+	}
+}
+
+// grow ensures there is room for the specified number of additional nodes
+// and edges without reallocating.
+func (l *directedEdgeList) grow(nodes int, edges int) {
+	l.index.grow(nodes)
+	if cap(l.outgoing)-len(l.outgoing) < nodes {
+		outgoing := make([][]int32, len(l.outgoing), len(l.outgoing)+nodes)
+		copy(outgoing, l.outgoing)
+		l.outgoing = outgoing
+
+		incoming := make([][]int32, len(l.incoming), len(l.incoming)+nodes)
+		copy(incoming, l.incoming)
+		l.incoming = incoming
+	}
+
+	if edges > 0 {
+		counts := make(map[uint64]int, len(l.edges)+edges)
+		for key, count := range l.edges {
+			counts[key] = count
+		}
+		l.edges = counts
 	}
 }
 
 func (l *directedEdgeList) Count() int {
-	return len(l.outgoingEdges)
+	return len(l.edges)
 }
 
-func (l *directedEdgeList) HasOutgoingEdges(node Node) bool {
-	_, ok := l.outgoingEdges[node]
-	return ok
+// intern returns the node's ID, growing the adjacency for new IDs. IDs of
+// nodes without edges may have no adjacency yet.
+func (l *directedEdgeList) intern(node Node) int32 {
+	id := l.index.Intern(node)
+	for int(id) >= len(l.outgoing) {
+		l.outgoing = append(l.outgoing, nil)
+		l.incoming = append(l.incoming, nil)
+	}
+	return id
 }
 
-func (l *directedEdgeList) OutgoingEdgeCount(node Node) int {
-	if list := l.outgoingNodeList(node, false); list != nil {
-		return list.Count()
+// each calls fn for the nodes of the IDs in order until it returns false.
+func (l *directedEdgeList) each(ids []int32, fn func(Node) bool) {
+	nodes := l.index.nodes
+	for _, id := range ids {
+		if !fn(nodes[id]) {
+			return
+		}
 	}
-	return 0
 }
 
-func (l *directedEdgeList) outgoingNodeList(node Node, create bool) *nodeList {
-	if list, ok := l.outgoingEdges[node]; ok {
-		return list
+// nodes returns a new slice of the nodes of the IDs.
+func (l *directedEdgeList) nodes(ids []int32) []Node {
+	if len(ids) == 0 {
+		return nil
 	}
-	if create {
-		list := newNodeList()
-		l.outgoingEdges[node] = list
-		return list
+	nodes := make([]Node, len(ids))
+	for i, id := range ids {
+		nodes[i] = l.index.nodes[id]
+	}
+	return nodes
+}
+
+// lookup returns the node's ID if it has adjacency.
+func (l *directedEdgeList) lookup(node Node) (int32, bool) {
+	id, ok := l.index.Lookup(node)
+	return id, ok && int(id) < len(l.outgoing)
+}
+
+func (l *directedEdgeList) outgoingIDs(node Node) []int32 {
+	if id, ok := l.lookup(node); ok {
+		return l.outgoing[id]
 	}
 	return nil
 }
 
-func (l *directedEdgeList) OutgoingEdges(node Node) []Node {
-	if list := l.outgoingNodeList(node, false); list != nil {
-		return list.Nodes()
+func (l *directedEdgeList) incomingIDs(node Node) []int32 {
+	if id, ok := l.lookup(node); ok {
+		return l.incoming[id]
 	}
 	return nil
 }
 
+func (l *directedEdgeList) HasOutgoingEdges(node Node) bool {
+	return len(l.outgoingIDs(node)) > 0
+}
+
+func (l *directedEdgeList) OutgoingEdgeCount(node Node) int {
+	return len(l.outgoingIDs(node))
+}
+
+func (l *directedEdgeList) OutgoingEdges(node Node) []Node {
+	return l.nodes(l.outgoingIDs(node))
+}
+
+// EachOutgoing calls fn for the targets of the node's outgoing edges until it
+// returns false.
+func (l *directedEdgeList) EachOutgoing(node Node, fn func(Node) bool) {
+	l.each(l.outgoingIDs(node), fn)
+}
+
 func (l *directedEdgeList) HasIncomingEdges(node Node) bool {
-	_, ok := l.incomingEdges[node]
-	return ok
+	return len(l.incomingIDs(node)) > 0
 }
 
 func (l *directedEdgeList) IncomingEdgeCount(node Node) int {
-	if list := l.incomingNodeList(node, false); list != nil {
-		return list.Count()
-	}
-	return 0
+	return len(l.incomingIDs(node))
+}
+
+func (l *directedEdgeList) IncomingEdges(node Node) []Node {
+	return l.nodes(l.incomingIDs(node))
 }
 
-func (l *directedEdgeList) incomingNodeList(node Node, create bool) *nodeList {
-	if list, ok := l.incomingEdges[node]; ok {
-		return list
+// EachIncoming calls fn for the sources of the node's incoming edges until it
+// returns false.
+func (l *directedEdgeList) EachIncoming(node Node, fn func(Node) bool) {
+	l.each(l.incomingIDs(node), fn)
+}
+
+// key returns the edge's key, and whether both of its nodes are interned.
+func (l *directedEdgeList) key(from Node, to Node) (uint64, bool) {
+	fromID, ok := l.index.Lookup(from)
+	if !ok {
+		return 0, false
 	}
-	if create {
-		list := newNodeList()
-		l.incomingEdges[node] = list
-		return list
+	toID, ok := l.index.Lookup(to)
+	if !ok {
+		return 0, false
 	}
-	return nil
+	return edgeKey(fromID, toID), true
 }
 
-func (l *directedEdgeList) IncomingEdges(node Node) []Node {
-	if list := l.incomingNodeList(node, false); list != nil {
-		return list.Nodes()
+func (l *directedEdgeList) Add(from Node, to Node) {
+	l.add(from, to, 1)
+}
+
+// add adds the edge with the multiplicity unless it already exists.
+func (l *directedEdgeList) add(from Node, to Node, multiplicity int) {
+	l.addIDs(l.intern(from), l.intern(to), multiplicity)
+}
+
+// addIDs adds the edge between the interned nodes with the multiplicity,
+// returning false if it already exists.
+func (l *directedEdgeList) addIDs(fromID int32, toID int32, multiplicity int) bool {
+	key := edgeKey(fromID, toID)
+	if _, ok := l.edges[key]; ok {
+		return false
 	}
-	return nil
+	l.outgoing[fromID] = append(l.outgoing[fromID], toID)
+	l.incoming[toID] = append(l.incoming[toID], fromID)
+	l.edges[key] = multiplicity
+	l.added++
+	return true
 }
 
-func (l *directedEdgeList) Add(from Node, to Node) {
-	l.outgoingNodeList(from, true).Add(to)
-	l.incomingNodeList(to, true).Add(from)
+func (l *directedEdgeList) AddCounted(from Node, to Node) {
+	if key, ok := l.key(from, to); ok {
+		if count, ok := l.edges[key]; ok {
+			l.edges[key] = count + 1
+			return
+		}
+	}
+	l.Add(from, to)
+}
+
+func (l *directedEdgeList) Multiplicity(from Node, to Node) int {
+	if key, ok := l.key(from, to); ok {
+		return l.edges[key]
+	}
+	return 0
 }
 
+// Remove decrements the edge's multiplicity, removing the edge once
+// the last instance is gone.
 func (l *directedEdgeList) Remove(from Node, to Node) {
-	if list := l.outgoingNodeList(from, false); list != nil {
-		list.Remove(to)
+	key, ok := l.key(from, to)
+	if !ok {
+		return
+	}
+	if count := l.edges[key]; count > 1 {
+		l.edges[key] = count - 1
+		return
+	}
+	l.Delete(from, to)
+}
 
-		if list.Count() == 0 {
-			delete(l.outgoingEdges, from)
-		}
+// Delete removes the edge regardless of its multiplicity.
+func (l *directedEdgeList) Delete(from Node, to Node) {
+	key, ok := l.key(from, to)
+	if !ok {
+		return
 	}
-	if list := l.incomingNodeList(to, false); list != nil {
-		list.Remove(from)
+	if _, ok := l.edges[key]; !ok {
+		return
+	}
+	delete(l.edges, key)
+	l.removed++
+
+	fromID, toID := splitEdgeKey(key)
+	l.outgoing[fromID] = removeID(l.outgoing[fromID], toID)
+	l.incoming[toID] = removeID(l.incoming[toID], fromID)
+}
 
-		if list.Count() == 0 {
-			delete(l.incomingEdges, to)
+// removeID removes the ID from the list, keeping the order of the others.
+func removeID(ids []int32, id int32) []int32 {
+	for i, other := range ids {
+		if other == id {
+			copy(ids[i:], ids[i+1:])
+			ids = ids[:len(ids)-1]
+			break
 		}
 	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids
 }
 
 func (l *directedEdgeList) Exists(from Node, to Node) bool {
-	if list := l.outgoingNodeList(from, false); list != nil {
-		return list.Exists(to)
+	if key, ok := l.key(from, to); ok {
+		_, ok = l.edges[key]
+		return ok
 	}
 	return false
 }
+
+// edgeString formats the edge with the key for error messages.
+func (l *directedEdgeList) edgeString(key uint64) string {
+	from, to := splitEdgeKey(key)
+	return fmt.Sprintf("%v -> %v", l.index.Node(from), l.index.Node(to))
+}
+
+func (l *directedEdgeList) validate(exists func(Node) bool) error {
+	if err := l.index.validate(); err != nil {
+		return fmt.Errorf("node index: %v", err)
+	}
+	if len(l.outgoing) > l.index.Len() || len(l.incoming) != len(l.outgoing) {
+		return fmt.Errorf("adjacency of %d and %d IDs for %d interned", len(l.outgoing), len(l.incoming), l.index.Len())
+	}
+	for node := range l.index.ids {
+		if !exists(node) {
+			return fmt.Errorf("interned node %v is missing from the graph", node)
+		}
+	}
+	check := func(adjacency [][]int32, key func(id int32, other int32) uint64, direction string) error {
+		seen := make(map[uint64]bool, len(l.edges))
+		for id, ids := range adjacency {
+			for _, other := range ids {
+				if int(other) >= l.index.Len() || l.index.Node(other) == nil || l.index.Node(int32(id)) == nil {
+					return fmt.Errorf("%s edge between IDs %d and %d refers to a free ID", direction, id, other)
+				}
+				k := key(int32(id), other)
+				if seen[k] {
+					return fmt.Errorf("%s edge %s is listed twice", direction, l.edgeString(k))
+				}
+				if _, ok := l.edges[k]; !ok {
+					return fmt.Errorf("%s edge %s is not counted", direction, l.edgeString(k))
+				}
+				seen[k] = true
+			}
+		}
+		if len(seen) != len(l.edges) {
+			return fmt.Errorf("%d edges are counted but %d are %s", len(l.edges), len(seen), direction)
+		}
+		return nil
+	}
+	if err := check(l.outgoing, edgeKey, "outgoing"); err != nil {
+		return err
+	}
+	if err := check(l.incoming, func(id int32, other int32) uint64 { return edgeKey(other, id) }, "incoming"); err != nil {
+		return err
+	}
+
+	for key, count := range l.edges {
+		if count < 1 {
+			return fmt.Errorf("edge %s has a multiplicity of %d", l.edgeString(key), count)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package graff
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Errors relating to the edge log.
+var (
+	ErrCorruptLog = errors.New("The edge log is corrupt")
+)
+
+// The kinds of records in an edge log.
+const (
+	logAddNode byte = iota + 1
+	logRemoveNode
+	logAddEdge
+	logRemoveEdge
+	// logDeleteEdge removes an edge regardless of its multiplicity
+	logDeleteEdge
+	logReplaceNode
+)
+
+// EdgeLog journals the mutations of a graph to a writer, so that the graph
+// can be recovered after a crash by ReplayLog, see AttachLog.
+type EdgeLog struct {
+	w     io.Writer
+	codec NodeCodec
+	sub   *Subscription
+	err   error
+	buf   []byte
+}
+
+// AttachLog journals the mutations of the graph to the writer using the
+// DefaultNodeCodec. See AttachLogWithCodec.
+func (g *DirectedGraph) AttachLog(w io.Writer) *EdgeLog {
+	return g.AttachLogWithCodec(w, DefaultNodeCodec)
+}
+
+// AttachLogWithCodec journals the mutations of the graph to the writer,
+// encoding the nodes using the codec, until the log is detached. The log
+// starts with records recreating the graph's current nodes and edges, so
+// it must be written to an empty file. Every addition and removal of a node
+// or edge, including changes of an edge's multiplicity, and every node
+// replacement then appends a record to the log in a single write. A record
+// holds the kind of mutation, the length of its payload and the CRC-32
+// checksum of both, so that a damaged length is never trusted, then the
+// length-prefixed encodings of its nodes, followed by a CRC-32 checksum of
+// the whole record, so that a record torn by a crash is recognized.
+// Attributes are not logged. The edges of an EventGraph are logged in the
+// orientation of the underlying DirectedGraph, see ReplayEventLog.
+// Since mutations cannot fail, the first error writing the log or encoding
+// a node is kept and stops the logging, see Err.
+func (g *DirectedGraph) AttachLogWithCodec(w io.Writer, codec NodeCodec) *EdgeLog {
+	l := &EdgeLog{w: w, codec: codec}
+	for _, node := range g.Nodes() {
+		l.append(logAddNode, node)
+	}
+	for _, from := range g.Nodes() {
+		for _, to := range g.OutgoingEdges(from) {
+			for i := g.EdgeMultiplicity(from, to); i > 0; i-- {
+				l.append(logAddEdge, from, to)
+			}
+		}
+	}
+	l.sub = g.OnMutate(l.record)
+	return l
+}
+
+// Detach stops journaling the graph's mutations. Calling it more than once
+// has no effect.
+func (l *EdgeLog) Detach() {
+	l.sub.Unsubscribe()
+}
+
+// Err returns the first error writing the log or encoding a node, after
+// which no further records were written.
+func (l *EdgeLog) Err() error {
+	return l.err
+}
+
+// record appends the records of the mutation to the log.
+func (l *EdgeLog) record(event MutationEvent) {
+	switch event.Op {
+	case MutationAddNode:
+		for _, node := range event.Nodes {
+			l.append(logAddNode, node)
+		}
+	case MutationAddEdge:
+		for _, edge := range event.Edges {
+			l.append(logAddEdge, edge.From, edge.To)
+		}
+	case MutationRemoveEdge:
+		for _, edge := range event.Edges {
+			l.append(logRemoveEdge, edge.From, edge.To)
+		}
+	case MutationRemoveTransitives:
+		for _, edge := range event.Edges {
+			l.append(logDeleteEdge, edge.From, edge.To)
+		}
+	case MutationRemoveNode:
+		// the edges are removed together with their nodes
+		for _, node := range event.Nodes {
+			l.append(logRemoveNode, node)
+		}
+	case MutationReplaceNode:
+		l.append(logReplaceNode, event.Nodes[0], event.Nodes[1])
+	}
+}
+
+// append writes a record of the kind holding the nodes: the kind, the length
+// of the payload, the CRC-32 checksum of both, the payload holding the
+// length-prefixed node encodings, and the CRC-32 checksum of everything
+// before it.
+func (l *EdgeLog) append(kind byte, nodes ...Node) {
+	if l.err != nil {
+		return
+	}
+	payload := make([]byte, 0)
+	for _, node := range nodes {
+		data, err := l.codec.EncodeNode(node)
+		if err != nil {
+			l.err = err
+			return
+		}
+		payload = binary.AppendUvarint(payload, uint64(len(data)))
+		payload = append(payload, data...)
+	}
+
+	l.buf = append(l.buf[:0], kind)
+	l.buf = binary.AppendUvarint(l.buf, uint64(len(payload)))
+	l.buf = binary.BigEndian.AppendUint32(l.buf, crc32.ChecksumIEEE(l.buf))
+	l.buf = append(l.buf, payload...)
+	l.buf = binary.BigEndian.AppendUint32(l.buf, crc32.ChecksumIEEE(l.buf))
+	if _, err := l.w.Write(l.buf); err != nil {
+		l.err = err
+	}
+}
+
+// ReplayLog reconstructs a graph from a log written by AttachLogWithCodec,
+// decoding the nodes using the codec. Should the log end in a record torn
+// by a crash, i.e. a truncated one or one failing its checksum, replaying
+// stops cleanly before it, returning the graph as of the last complete
+// record. An error wrapping ErrCorruptLog is returned if a record other than
+// the last one is damaged or a record cannot be applied.
+func ReplayLog(r io.Reader, codec NodeCodec) (*DirectedGraph, error) {
+	g := NewDirectedGraph()
+	b := bufio.NewReader(r)
+	for offset := 0; ; {
+		kind, nodes, size, err := readLogRecord(b, codec)
+		if err == io.EOF {
+			return g, nil
+		}
+		if err != nil {
+			if errors.Is(err, errTornRecord) {
+				// a torn record is only expected at the end of the log
+				if _, err := b.Peek(1); err == io.EOF {
+					return g, nil
+				}
+			}
+			return nil, fmt.Errorf("%w: record at offset %d: %v", ErrCorruptLog, offset, err)
+		}
+		if err := applyLogRecord(g, kind, nodes); err != nil {
+			return nil, fmt.Errorf("%w: record at offset %d: %v", ErrCorruptLog, offset, err)
+		}
+		offset += size
+	}
+}
+
+// ReplayEventLog reconstructs an event graph from the log of an EventGraph,
+// see ReplayLog.
+func ReplayEventLog(r io.Reader, codec NodeCodec) (*EventGraph, error) {
+	g, err := ReplayLog(r, codec)
+	if err != nil {
+		return nil, err
+	}
+	return &EventGraph{g}, nil
+}
+
+// errTornRecord reports a record which is truncated or fails its checksum.
+var errTornRecord = errors.New("torn record")
+
+// readLogRecord reads the next record, returning its kind, its nodes and
+// its size in bytes, or io.EOF at the end of the log.
+func readLogRecord(b *bufio.Reader, codec NodeCodec) (byte, []Node, int, error) {
+	kind, err := b.ReadByte()
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	record := []byte{kind}
+	size, err := binary.ReadUvarint(b)
+	if err != nil {
+		return 0, nil, 0, fmt.Errorf("%w: reading the length: %v", errTornRecord, err)
+	}
+	record = binary.AppendUvarint(record, size)
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(b, header); err != nil {
+		return 0, nil, 0, fmt.Errorf("%w: reading the header checksum: %v", errTornRecord, err)
+	}
+	if crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header) {
+		return 0, nil, 0, fmt.Errorf("%w: header checksum mismatch", errTornRecord)
+	}
+	if size > 2*(maxNodeEncoding+binary.MaxVarintLen64) {
+		return 0, nil, 0, fmt.Errorf("%w: payload of %d bytes", errTornRecord, size)
+	}
+	record = append(record, header...)
+	start := len(record)
+	record = append(record, make([]byte, size+4)...)
+	if _, err := io.ReadFull(b, record[start:]); err != nil {
+		return 0, nil, 0, fmt.Errorf("%w: reading the payload: %v", errTornRecord, err)
+	}
+	sum := binary.BigEndian.Uint32(record[len(record)-4:])
+	record = record[:len(record)-4]
+	if crc32.ChecksumIEEE(record) != sum {
+		return 0, nil, 0, fmt.Errorf("%w: checksum mismatch", errTornRecord)
+	}
+
+	nodes := make([]Node, 0, 2)
+	for payload := record[start:]; len(payload) > 0; {
+		length, n := binary.Uvarint(payload)
+		if n <= 0 || uint64(len(payload)-n) < length {
+			return 0, nil, 0, errors.New("malformed node encoding")
+		}
+		node, err := codec.DecodeNode(payload[n : n+int(length)])
+		if err != nil {
+			return 0, nil, 0, err
+		}
+		nodes = append(nodes, node)
+		payload = payload[n+int(length):]
+	}
+	return kind, nodes, len(record) + 4, nil
+}
+
+// applyLogRecord applies the mutation recorded to the graph.
+func applyLogRecord(g *DirectedGraph, kind byte, nodes []Node) error {
+	want := 2
+	if kind == logAddNode || kind == logRemoveNode {
+		want = 1
+	}
+	if len(nodes) != want {
+		return fmt.Errorf("record of kind %d holds %d nodes rather than %d", kind, len(nodes), want)
+	}
+
+	switch kind {
+	case logAddNode:
+		g.AddNode(nodes[0])
+	case logRemoveNode:
+		g.RemoveNode(nodes[0])
+	case logAddEdge:
+		// edges are only logged when they are new or their multiplicity grows
+		g.AddEdgeCounted(nodes[0], nodes[1])
+	case logRemoveEdge:
+		g.RemoveEdge(nodes[0], nodes[1])
+	case logDeleteEdge:
+		g.deleteEdge(nodes[0], nodes[1])
+	case logReplaceNode:
+		return g.ReplaceNode(nodes[0], nodes[1])
+	default:
+		return fmt.Errorf("unknown record kind %d", kind)
+	}
+	return nil
+}
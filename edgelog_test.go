@@ -0,0 +1,168 @@
+package graff
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// graphState describes the nodes in order and the edges with their
+// multiplicities, which is everything the edge log preserves.
+func graphState(g *DirectedGraph) []interface{} {
+	state := make([]interface{}, 0)
+	for _, from := range g.Nodes() {
+		state = append(state, from)
+		for _, to := range g.OutgoingEdges(from) {
+			state = append(state, Edge{from, to}, g.EdgeMultiplicity(from, to))
+		}
+	}
+	return state
+}
+
+// loggedGraph mutates a graph journaled to the returned log, returning the
+// length of the log and the graph's state after every mutation.
+func loggedGraph(t *testing.T) (*bytes.Buffer, []int, [][]interface{}) {
+	var log bytes.Buffer
+	g := NewDirectedGraph()
+	g.AddEdge("seed", 0)
+	l := g.AttachLog(&log)
+
+	lengths := []int{log.Len()}
+	states := [][]interface{}{graphState(g)}
+	step := func() {
+		lengths = append(lengths, log.Len())
+		states = append(states, graphState(g))
+	}
+	for i := 1; i < 8; i++ {
+		g.AddEdge(i-1, i)
+		step()
+		g.AddEdgeCounted(0, i)
+		step()
+	}
+	g.AddEdgeCounted(0, 1)
+	step()
+	g.RemoveEdge(0, 1)
+	step()
+	g.RemoveNode(3)
+	step()
+	if err := g.ReplaceNode(5, "five"); err != nil {
+		t.Fatal(err)
+	}
+	step()
+	g.AddNode("last")
+	step()
+	if err := l.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return &log, lengths, states
+}
+
+func TestReplayLogTruncated(t *testing.T) {
+	log, lengths, states := loggedGraph(t)
+	data := log.Bytes()
+
+	// a mutation may append several records, so the state is tracked per
+	// record
+	boundaries := []int{0}
+	g := NewDirectedGraph()
+	recorded := [][]interface{}{graphState(g)}
+	for b := bufio.NewReader(bytes.NewReader(data)); ; {
+		kind, nodes, size, err := readLogRecord(b, DefaultNodeCodec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := applyLogRecord(g, kind, nodes); err != nil {
+			t.Fatal(err)
+		}
+		boundaries = append(boundaries, boundaries[len(boundaries)-1]+size)
+		recorded = append(recorded, graphState(g))
+	}
+	for i, length := range lengths {
+		j := sort.SearchInts(boundaries, length)
+		if j == len(boundaries) || boundaries[j] != length {
+			t.Fatalf("mutation %d ended mid-record", i)
+		}
+		if !reflect.DeepEqual(recorded[j], states[i]) {
+			t.Fatalf("after mutation %d the log holds %v, want %v", i, recorded[j], states[i])
+		}
+	}
+
+	for cut := 0; cut <= len(data); cut++ {
+		// the state after the last record complete before the cut
+		var want []interface{}
+		for i, boundary := range boundaries {
+			if boundary <= cut {
+				want = recorded[i]
+			}
+		}
+		g, err := ReplayLog(bytes.NewReader(data[:cut]), DefaultNodeCodec)
+		if err != nil {
+			t.Fatalf("cut at %d: %v", cut, err)
+		}
+		if got := graphState(g); !reflect.DeepEqual(got, want) {
+			t.Fatalf("cut at %d: replayed %v, want %v", cut, got, want)
+		}
+	}
+}
+
+func TestReplayLogFlippedByte(t *testing.T) {
+	log, lengths, _ := loggedGraph(t)
+	data := log.Bytes()
+
+	// every byte before the last record is covered by a checksum
+	last := lengths[len(lengths)-2]
+	for i := 0; i < last; i++ {
+		damaged := append([]byte(nil), data...)
+		damaged[i] ^= 0x10
+		if _, err := ReplayLog(bytes.NewReader(damaged), DefaultNodeCodec); !errors.Is(err, ErrCorruptLog) {
+			t.Fatalf("flipping byte %d returned %v, want ErrCorruptLog", i, err)
+		}
+	}
+
+	// damage to the last record is taken for a torn write
+	damaged := append([]byte(nil), data...)
+	damaged[len(damaged)-1] ^= 0x10
+	g, err := ReplayLog(bytes.NewReader(damaged), DefaultNodeCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NodeExists("last") || !g.NodeExists("five") {
+		t.Errorf("replayed the nodes %v", g.Nodes())
+	}
+}
+
+func TestReplayEventLog(t *testing.T) {
+	var log bytes.Buffer
+	g := NewEventGraph()
+	g.AddHappensBefore("a", "b")
+	l := g.AttachLog(&log)
+	g.AddHappensBefore("b", "c")
+	g.AddEdge("d", "c")
+	g.RemoveEdge("b", "a")
+	if err := l.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := ReplayEventLog(&log, DefaultNodeCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range [][2]Node{{"b", "c"}, {"c", "d"}} {
+		if !replayed.HappensBefore(pair[0], pair[1]) {
+			t.Errorf("%v no longer happens before %v", pair[0], pair[1])
+		}
+	}
+	if replayed.HappensBefore("a", "b") || replayed.HappensBefore("c", "b") {
+		t.Error("the replayed events are misordered")
+	}
+	if !reflect.DeepEqual(graphState(replayed.DirectedGraph), graphState(g.DirectedGraph)) {
+		t.Errorf("replayed %v, want %v", graphState(replayed.DirectedGraph), graphState(g.DirectedGraph))
+	}
+}
@@ -0,0 +1,100 @@
+package graff
+
+import (
+	"errors"
+)
+
+// Errors relating to enumerations.
+var (
+	ErrTruncated = errors.New("The enumeration was truncated")
+)
+
+// AllTopologicalSorts enumerates every valid topological order of the graph's
+// nodes by backtracking over the ready nodes (Varol–Rotem style), stopping
+// after max orderings, in which case the orderings found so far are returned
+// together with ErrTruncated. A max of zero or less means no limit.
+// The number of orderings grows factorially, so this is only meant for
+// small graphs; the working state is proportional to the graph size.
+func (g *DirectedGraph) AllTopologicalSorts(max int) ([][]Node, error) {
+	if _, err := g.LexTopoSort(nil); err != nil {
+		return nil, err
+	}
+
+	nodes := g.Nodes()
+	index := make(map[Node]int, len(nodes))
+	for i, node := range nodes {
+		index[node] = i
+	}
+
+	indegree := make([]int, len(nodes))
+	outgoing := make([][]int, len(nodes))
+	for i, node := range nodes {
+		indegree[i] = g.IncomingEdgeCount(node)
+		for _, to := range g.OutgoingEdges(node) {
+			outgoing[i] = append(outgoing[i], index[to])
+		}
+	}
+
+	e := &topologicalEnumerator{
+		nodes:    nodes,
+		indegree: indegree,
+		outgoing: outgoing,
+		used:     make([]bool, len(nodes)),
+		order:    make([]Node, 0, len(nodes)),
+		results:  make([][]Node, 0),
+		max:      max,
+	}
+	if !e.enumerate() {
+		return e.results, ErrTruncated
+	}
+	return e.results, nil
+}
+
+type topologicalEnumerator struct {
+	nodes    []Node
+	indegree []int
+	outgoing [][]int
+	used     []bool
+	order    []Node
+	results  [][]Node
+	max      int
+}
+
+// enumerate extends the current order with every ready node in turn,
+// returning false once the maximum number of results was exceeded.
+func (e *topologicalEnumerator) enumerate() bool {
+	if len(e.order) == len(e.nodes) {
+		if e.max > 0 && len(e.results) == e.max {
+			return false
+		}
+		result := make([]Node, len(e.order))
+		copy(result, e.order)
+		e.results = append(e.results, result)
+		return true
+	}
+
+	for i := range e.nodes {
+		if e.used[i] || e.indegree[i] != 0 {
+			continue
+		}
+
+		e.used[i] = true
+		e.order = append(e.order, e.nodes[i])
+		for _, j := range e.outgoing[i] {
+			e.indegree[j]--
+		}
+
+		ok := e.enumerate()
+
+		for _, j := range e.outgoing[i] {
+			e.indegree[j]++
+		}
+		e.order = e.order[:len(e.order)-1]
+		e.used[i] = false
+
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
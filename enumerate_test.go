@@ -0,0 +1,66 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAllTopologicalSorts(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := 0; i < 4; i++ {
+		g.AddNode(i)
+	}
+	orders, err := g.AllTopologicalSorts(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 24 {
+		t.Fatalf("got %d orderings of 4 independent nodes, want 4! = 24", len(orders))
+	}
+	seen := make(map[string]bool)
+	for _, order := range orders {
+		seen[fmt.Sprint(order)] = true
+	}
+	if len(seen) != 24 {
+		t.Errorf("got %d distinct orderings, want 24", len(seen))
+	}
+
+	// a -> c, b -> c leaves a and b interchangeable
+	g = NewDirectedGraph()
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "c")
+	g.AddNode("d")
+	orders, err = g.AllTopologicalSorts(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 8 {
+		t.Errorf("got %d orderings, want 8", len(orders))
+	}
+	for _, order := range orders {
+		if ok, violation := g.IsLinearExtension(order); !ok {
+			t.Errorf("%v is not a topological order: %v", order, violation)
+		}
+	}
+}
+
+func TestAllTopologicalSortsErrors(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := 0; i < 4; i++ {
+		g.AddNode(i)
+	}
+	orders, err := g.AllTopologicalSorts(5)
+	if !errors.Is(err, ErrTruncated) || len(orders) != 5 {
+		t.Errorf("got %d orderings and %v, want 5 and ErrTruncated", len(orders), err)
+	}
+	if orders, err := g.AllTopologicalSorts(24); err != nil || len(orders) != 24 {
+		t.Errorf("got %d orderings and %v at the exact limit", len(orders), err)
+	}
+
+	g.AddEdge(0, 1)
+	g.AddEdge(1, 0)
+	if _, err := g.AllTopologicalSorts(0); !errors.Is(err, ErrCyclicGraph) {
+		t.Errorf("got %v, want ErrCyclicGraph", err)
+	}
+}
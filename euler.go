@@ -0,0 +1,121 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to Eulerian paths.
+var (
+	ErrNoEulerianPath = errors.New("The graph has no Eulerian path")
+)
+
+// EulerianPathError reports the nodes which prevent the graph from having an
+// Eulerian path: those whose degrees are unbalanced beyond the path's start
+// and end, or otherwise the nodes with edges unreachable from the start.
+// It wraps ErrNoEulerianPath so it can be tested for using errors.Is.
+type EulerianPathError struct {
+	Nodes  []Node
+	Reason string
+}
+
+func (e *EulerianPathError) Error() string {
+	return fmt.Sprintf("%v: %s %v", ErrNoEulerianPath, e.Reason, e.Nodes)
+}
+
+func (e *EulerianPathError) Unwrap() error {
+	return ErrNoEulerianPath
+}
+
+// EulerianPath returns a path which traverses every edge exactly once, as
+// many times as its multiplicity, using Hierholzer's algorithm. It is a
+// circuit, starting and ending at the same node, if the degrees of all nodes
+// are balanced. Given the same graph the path is always identical.
+// A graph without edges yields an empty path.
+// See https://en.wikipedia.org/wiki/Eulerian_path#Hierholzer's_algorithm
+func (g *DirectedGraph) EulerianPath() ([]Node, error) {
+	// the difference between the outdegree and the indegree of every node,
+	// counting edges by their multiplicity
+	balance := make(map[Node]int, g.NodeCount())
+	total := 0
+	for _, from := range g.Nodes() {
+		g.EachOutgoing(from, func(to Node) bool {
+			multiplicity := g.EdgeMultiplicity(from, to)
+			balance[from] += multiplicity
+			balance[to] -= multiplicity
+			total += multiplicity
+			return true
+		})
+	}
+	if total == 0 {
+		return []Node{}, nil
+	}
+
+	var start, end Node
+	unbalanced := make([]Node, 0)
+	valid := true
+	for _, node := range g.Nodes() {
+		switch b := balance[node]; {
+		case b == 0:
+			continue
+		case b == 1 && start == nil:
+			start = node
+		case b == -1 && end == nil:
+			end = node
+		default:
+			valid = false
+		}
+		unbalanced = append(unbalanced, node)
+	}
+	if !valid || (start == nil) != (end == nil) {
+		return nil, &EulerianPathError{Nodes: unbalanced, Reason: "the degrees are unbalanced at"}
+	}
+	if start == nil {
+		for _, node := range g.Nodes() {
+			if g.HasOutgoingEdges(node) {
+				start = node
+				break
+			}
+		}
+	}
+
+	// the targets of the edges not traversed yet, repeated by multiplicity
+	remaining := make(map[Node][]Node, g.NodeCount())
+	for _, from := range g.Nodes() {
+		g.EachOutgoing(from, func(to Node) bool {
+			for i := g.EdgeMultiplicity(from, to); i > 0; i-- {
+				remaining[from] = append(remaining[from], to)
+			}
+			return true
+		})
+	}
+
+	path := make([]Node, 0, total+1)
+	stack := []Node{start}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		if targets := remaining[node]; len(targets) > 0 {
+			remaining[node] = targets[1:]
+			stack = append(stack, targets[0])
+			continue
+		}
+		path = append(path, node)
+		stack = stack[:len(stack)-1]
+	}
+
+	if len(path) != total+1 {
+		untraversed := make([]Node, 0)
+		for _, node := range g.Nodes() {
+			if len(remaining[node]) > 0 {
+				untraversed = append(untraversed, node)
+			}
+		}
+		return nil, &EulerianPathError{Nodes: untraversed, Reason: "edges are unreachable from the start at"}
+	}
+
+	// the path was collected backwards
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
@@ -0,0 +1,89 @@
+package graff
+
+import (
+	"errors"
+	"testing"
+)
+
+// checkEulerianPath fails unless the path traverses every edge of the graph
+// as many times as its multiplicity.
+func checkEulerianPath(t *testing.T, g *DirectedGraph, path []Node) {
+	t.Helper()
+	used := make(map[Edge]int)
+	for i := 1; i < len(path); i++ {
+		used[Edge{path[i-1], path[i]}]++
+	}
+	total := 0
+	for _, from := range g.Nodes() {
+		for _, to := range g.OutgoingEdges(from) {
+			if n, want := used[Edge{from, to}], g.EdgeMultiplicity(from, to); n != want {
+				t.Errorf("the path %v traverses %v->%v %d times, want %d", path, from, to, n, want)
+			}
+			total += g.EdgeMultiplicity(from, to)
+		}
+	}
+	if len(path) != total+1 {
+		t.Errorf("the path %v has %d steps, want %d", path, len(path)-1, total)
+	}
+}
+
+func TestEulerianPath(t *testing.T) {
+	// a circuit through two cycles sharing a, with a doubled edge
+	circuit := NewDirectedGraph()
+	circuit.AddEdge("a", "b")
+	circuit.AddEdge("b", "a")
+	circuit.AddEdgeCounted("a", "c")
+	circuit.AddEdgeCounted("a", "c")
+	circuit.AddEdgeCounted("c", "a")
+	circuit.AddEdgeCounted("c", "a")
+	path, err := circuit.EulerianPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEulerianPath(t, circuit, path)
+	if path[0] != path[len(path)-1] {
+		t.Errorf("the path %v is not a circuit", path)
+	}
+
+	// only a path from s to e
+	open := NewDirectedGraph()
+	open.AddEdge("s", "x")
+	open.AddEdge("x", "y")
+	open.AddEdge("y", "x")
+	open.AddEdge("x", "e")
+	path, err = open.EulerianPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEulerianPath(t, open, path)
+	if path[0] != "s" || path[len(path)-1] != "e" {
+		t.Errorf("the path %v does not lead from s to e", path)
+	}
+}
+
+func TestNoEulerianPath(t *testing.T) {
+	// a and b both have two more outgoing than incoming edges
+	g := NewDirectedGraph()
+	g.AddEdge("a", "c")
+	g.AddEdge("a", "d")
+	g.AddEdge("b", "c")
+	g.AddEdge("b", "d")
+	_, err := g.EulerianPath()
+	var euler *EulerianPathError
+	if !errors.As(err, &euler) || !errors.Is(err, ErrNoEulerianPath) {
+		t.Fatalf("got %v, want an EulerianPathError", err)
+	}
+	if len(euler.Nodes) != 4 {
+		t.Errorf("got the nodes %v, want all four", euler.Nodes)
+	}
+
+	// balanced, but in two separate cycles
+	g = NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "c")
+	if _, err := g.EulerianPath(); !errors.Is(err, ErrNoEulerianPath) {
+		t.Errorf("got %v for disconnected cycles, want ErrNoEulerianPath", err)
+	}
+}
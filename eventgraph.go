@@ -8,20 +8,14 @@ type EventGraph struct {
 // NewDirectedGraph creates a graph of nodes with directed edges.
 func NewEventGraph() *EventGraph {
 	return &EventGraph{
-		&DirectedGraph {
-			graph: newGraph(),
-			edges: newDirectedEdgeList(),
-		},
+		NewDirectedGraph(),
 	}
 }
 
 // Copy returns a clone of the directed graph.
 func (g *EventGraph) Copy() *EventGraph {
 	return &EventGraph{
-		&DirectedGraph{
-			graph: g.graph.Copy(),
-			edges: g.edges.Copy(),
-		},
+		g.DirectedGraph.Copy(),
 	}
 }
 
@@ -30,16 +24,107 @@ func (g *EventGraph) AddEdge(from Node, to Node) {
 	g.DirectedGraph.AddEdge(to, from);
 }
 
+// AddEdgeCounted adds the edge to the graph, incrementing its multiplicity
+// if it already exists.
+func (g *EventGraph) AddEdgeCounted(from Node, to Node) {
+	g.DirectedGraph.AddEdgeCounted(to, from)
+}
+
+// AddEdges adds the edges to the graph, see DirectedGraph.AddEdges.
+func (g *EventGraph) AddEdges(edges ...Edge) {
+	reversed := make([]Edge, len(edges))
+	for i, edge := range edges {
+		reversed[i] = Edge{From: edge.To, To: edge.From}
+	}
+	g.DirectedGraph.AddEdges(reversed...)
+}
+
+// EdgeMultiplicity returns the number of times the edge was added.
+func (g *EventGraph) EdgeMultiplicity(from Node, to Node) int {
+	return g.DirectedGraph.EdgeMultiplicity(to, from)
+}
+
 // RemoveEdge removes the edge from the graph.
 func (g *EventGraph) RemoveEdge(from Node, to Node) {
 	g.DirectedGraph.RemoveEdge(to, from)
 }
 
+// RemoveEdges removes the edges from the graph, returning the number of them
+// which existed, see DirectedGraph.RemoveEdges.
+func (g *EventGraph) RemoveEdges(edges ...Edge) int {
+	reversed := make([]Edge, len(edges))
+	for i, edge := range edges {
+		reversed[i] = Edge{From: edge.To, To: edge.From}
+	}
+	return g.DirectedGraph.RemoveEdges(reversed...)
+}
+
+// SetEdgeAttr sets the edge's attribute to the specified value.
+func (g *EventGraph) SetEdgeAttr(from Node, to Node, key string, value interface{}) error {
+	return g.DirectedGraph.SetEdgeAttr(to, from, key, value)
+}
+
+// EdgeAttr returns the value of the edge's attribute, if set.
+func (g *EventGraph) EdgeAttr(from Node, to Node, key string) (interface{}, bool) {
+	return g.DirectedGraph.EdgeAttr(to, from, key)
+}
+
+// EdgeAttrs returns a copy of all of the edge's attributes.
+func (g *EventGraph) EdgeAttrs(from Node, to Node) map[string]interface{} {
+	return g.DirectedGraph.EdgeAttrs(to, from)
+}
+
+// RemoveEdgeAttr removes the edge's attribute.
+func (g *EventGraph) RemoveEdgeAttr(from Node, to Node, key string) {
+	g.DirectedGraph.RemoveEdgeAttr(to, from, key)
+}
+
 // EdgeExists checks whether the edge exists within the graph.
 func (g *EventGraph) EdgeExists(from Node, to Node) bool {
 	return g.DirectedGraph.EdgeExists(to, from)
 }
 
+// HappensBefore determines whether the event a causally precedes the event b,
+// i.e. whether b transitively depends on a. An event does not happen before
+// itself.
+func (g *EventGraph) HappensBefore(a Node, b Node) bool {
+	return a != b && g.DirectedGraph.reaches(a, b)
+}
 
+// NewEventGraphFromPairs creates an event graph from happens-before pairs,
+// where the first event of each pair happens before the second.
+func NewEventGraphFromPairs(pairs [][2]Node) *EventGraph {
+	g := NewEventGraph()
+	g.AddBatch(pairs)
+	return g
+}
 
+// AddHappensBefore records that the earlier event happens before the later
+// one, i.e. that the later event depends on the earlier one. It is the same
+// as AddEdge(later, earlier), so that HappensBefore(earlier, later) holds.
+func (g *EventGraph) AddHappensBefore(earlier Node, later Node) {
+	g.DirectedGraph.AddEdge(earlier, later)
+}
+
+// AddBatch records happens-before pairs like AddHappensBefore, where the
+// first event of each pair happens before the second, in a single batch.
+func (g *EventGraph) AddBatch(pairs [][2]Node) {
+	edges := make([]Edge, len(pairs))
+	for i, pair := range pairs {
+		edges[i] = Edge{From: pair[0], To: pair[1]}
+	}
+	g.DirectedGraph.AddEdges(edges...)
+}
 
+// CausalOrder groups the events into generations, the earliest first, so
+// that every event happens after some event of the previous generation and
+// after none of its own or a later one. It levels the graph with an
+// OptimizedCoffmanGrahamSorter of unlimited width.
+// A CyclicGraphError is returned if the graph contains a cycle.
+func (g *EventGraph) CausalOrder() ([][]Node, error) {
+	width := g.NodeCount()
+	if width == 0 {
+		width = 1
+	}
+	return g.DirectedGraph.OptimizedCoffmanGrahamSorter(width).EventSort()
+}
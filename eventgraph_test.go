@@ -0,0 +1,54 @@
+package graff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAddHappensBefore(t *testing.T) {
+	pairs := [][2]Node{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"x", "d"}}
+	g := NewEventGraph()
+	for _, pair := range pairs {
+		g.AddHappensBefore(pair[0], pair[1])
+	}
+	for _, pair := range pairs {
+		earlier, later := pair[0], pair[1]
+		if !g.HappensBefore(earlier, later) || g.HappensBefore(later, earlier) {
+			t.Errorf("AddHappensBefore(%v, %v) does not imply %v happens before %v", earlier, later, earlier, later)
+		}
+		if !g.EdgeExists(later, earlier) {
+			t.Errorf("AddHappensBefore(%v, %v) did not make %v depend on %v", earlier, later, later, earlier)
+		}
+	}
+	if !g.HappensBefore("a", "d") || g.HappensBefore("x", "a") {
+		t.Error("the transitive happens-before relation is wrong")
+	}
+
+	batched := NewEventGraphFromPairs(pairs)
+	if !reflect.DeepEqual(batched.Nodes(), g.Nodes()) || batched.EdgeCount() != g.EdgeCount() {
+		t.Errorf("NewEventGraphFromPairs created %v, want %v", batched, g)
+	}
+	for _, pair := range pairs {
+		if !batched.HappensBefore(pair[0], pair[1]) {
+			t.Errorf("NewEventGraphFromPairs lost %v happening before %v", pair[0], pair[1])
+		}
+	}
+}
+
+func TestCausalOrder(t *testing.T) {
+	g := NewEventGraphFromPairs([][2]Node{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"x", "d"}, {"x", "e"}})
+	got, err := g.CausalOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]Node{{"a", "x"}, {"b", "c", "e"}, {"d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got generations %v, want %v", got, want)
+	}
+
+	g.AddHappensBefore("d", "a")
+	if _, err := g.CausalOrder(); !errors.Is(err, ErrCyclicGraph) {
+		t.Errorf("got %v for a cycle, want ErrCyclicGraph", err)
+	}
+}
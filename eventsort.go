@@ -1,144 +1,232 @@
 package graff
 
-import (
-//	"fmt"
-)
-
-
-// CoffmanGrahamSorter sorts a graph's nodes into a sequence of levels,
-// arranging so that a node which comes after another in the order is
-// assigned to a lower level, and that a level never exceeds the width.
+// OptimizedCoffmanGrahamSorter sorts a graph's nodes into a sequence of
+// levels like the CoffmanGrahamSorter, optimized for incrementally growing
+// graphs such as an EventGraph: every call to EventSort only levels the nodes
+// added since the previous call. The graph may be any implementation of
+// Directed; one which is not a DirectedGraph is copied by every sort.
 // See https://en.wikipedia.org/wiki/Coffman–Graham_algorithm
 type OptimizedCoffmanGrahamSorter struct {
-	graph *DirectedGraph
-	width int
+	graph Directed
+	*leveler
 
-	layers [][]Node
-	levels map[Node]int
 	level int
 }
 
-// Sort returns the sorted nodes.
+// EventSort returns the sorted nodes.
 // This version is to optimize for reverse graph (not the original directed graph)
+//
+// Levels are stable: a node which was assigned a level by a previous call
+// keeps it, even when edges added later would require it to move. Use
+// CheckConsistency to find the edges violating the level ordering as a
+// result, or WithStrictOrdering to make EventSort fail instead.
+//
+// The index of every layer is its level, so that the layers below the base
+// level (see WithBaseLevel) and the pruned ones (see PruneBelow) are empty.
+//
+// Within a layer the nodes are in arrival order, i.e. ordered by their
+// InsertionIndex, unless WithLayerOrder is used, which breaks ties by
+// arrival. Layer callbacks see the nodes in the order they were assigned.
 func (s *OptimizedCoffmanGrahamSorter) EventSort() ([][]Node, error) {
-	// create a copy of the graph and remove transitive edges
-	//fmt.Println("-start-- layers", s.layers)
-
-	reduced := s.graph.Copy()
-	reduced.RemoveTransitives()
-
-	// topologically sort the graph nodes
-	nodes, err := reduced.DFSSort()
+	graph, err := snapshot(s.graph)
+	if err != nil {
+		return nil, err
+	}
+	maxLevel, err := s.leveler.sort(graph, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	//fmt.Println("--- DFS nodes", nodes);
-
-//	layers := make([][]Node, 0)
-//	levels := make(map[Node]int, len(nodes))
-
-	layers := s.layers
-	levels := s.levels
-	//level := s.level
-
-//	fmt.Println("---SSS level", level)
-//	fmt.Println("---SSS levels", levels, "layers", layers)
-
-	maxLevel := -1
-
-	for _, node := range nodes {
-		//fmt.Println("--- node", node)
-
-		_, ok := levels[node]
-		if ok {
-			// if already assigned a level, dont need to assign again
-			continue
-		}
+	s.level = maxLevel
+	return s.layers, nil
+}
 
-		//fmt.Println("--- node  -- not assigned", node)
+// EventSortLimited levels the nodes like EventSort, but assigns at most
+// maxNodes of them per call, unless it is zero or less, so that a burst of
+// new nodes can be leveled in chunks without blocking the caller for long.
+// It reports whether all nodes were assigned. The nodes are assigned in
+// topological order, so a node is never assigned before its dependencies,
+// and once all nodes were assigned the layers are those a single call to
+// EventSort would have returned.
+//
+// The topological order is kept between calls, so that the next call resumes
+// where the previous one stopped, unless the graph was modified in between,
+// in which case it is recomputed. A graph which is not a DirectedGraph is
+// copied and sorted anew by every call.
+func (s *OptimizedCoffmanGrahamSorter) EventSortLimited(maxNodes int) ([][]Node, bool, error) {
+	graph, err := snapshot(s.graph)
+	if err != nil {
+		return nil, false, err
+	}
+	maxLevel, done, err := s.leveler.sortLimited(graph, nil, maxNodes)
+	if err != nil {
+		return nil, false, err
+	}
 
-		dependantLevel := -1
-		for _, dependant := range reduced.IncomingEdges(node) {
+	s.level = maxLevel
+	return s.layers, done, nil
+}
 
-			level, ok := levels[dependant]
-			//fmt.Println("--- dependant", node, "level", level)
+// CheckConsistency returns the edges which violate the level ordering since
+// their target was assigned a level before the edge was added, in the
+// orientation of the underlying directed graph. The result is empty when the
+// current levels are consistent with the graph.
+func (s *OptimizedCoffmanGrahamSorter) CheckConsistency() []Edge {
+	return s.checkConsistency(asDirectedGraph(s.graph))
+}
 
-			if !ok {
-				return nil, ErrDependencyOrder
-			}
-			if level > dependantLevel {
-				dependantLevel = level
-			}
-//			fmt.Println("--- dependantLevel", dependantLevel)
-		}
+// RemoveNode forgets the level of a node removed from the graph and removes
+// it from its layer, compacting the layer. Layers are never removed, so
+// the levels of the other nodes are unaffected, although a layer may become
+// empty. It returns whether the node had been assigned a level.
+func (s *OptimizedCoffmanGrahamSorter) RemoveNode(node Node) bool {
+	return s.remove(node)
+}
 
-		level := -1
+// RenameNode transfers the level of a node replaced by ReplaceNode to its
+// replacement, so that it keeps its place in the layers. It returns whether
+// the old node had been assigned a level.
+func (s *OptimizedCoffmanGrahamSorter) RenameNode(old Node, new Node) bool {
+	return s.rename(old, new)
+}
 
-		// find the first ununfilled layer outgoing the dependent layer
-		// skip this if the dependent layer is the last
+// Recompute discards all levels assigned by previous calls to EventSort and
+// sorts the current graph from scratch, so that subsequent calls behave like
+// those of a fresh sorter. This reconciles the levels after CheckConsistency
+// reported violations. If sorting fails the previous levels are kept.
+func (s *OptimizedCoffmanGrahamSorter) Recompute() ([][]Node, error) {
+	layers, _, err := s.RecomputeWithChanges()
+	return layers, err
+}
 
-//		fmt.Println("--- dependantLevel=", dependantLevel, ", len(layers) - 1 = ", len(layers) - 1)
+// RecomputeWithChanges recomputes the levels like Recompute, additionally
+// reporting the nodes whose level changed compared to the previous levels
+// in the graph's node order.
+func (s *OptimizedCoffmanGrahamSorter) RecomputeWithChanges() ([][]Node, []LevelChange, error) {
+	graph, err := snapshot(s.graph)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxLevel, changes, err := s.recompute(graph)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		if dependantLevel < len(layers)-1 {
-//			fmt.Println("--- len(layers)-1=", len(layers)-1)
+	s.level = maxLevel
+	return s.layers, changes, nil
+}
 
-			for i := dependantLevel + 1; i < len(layers); i++ {
-				// ensure the layer doesn't exceed the desired width
-				if len(layers[i]) < s.width {
-					level = i
-					break
-				}
-			}
-		}
+// Reset discards all levels and rebinds the sorter to the graph, which may
+// differ from the one it was created for, keeping its configuration and
+// reusing its internal state. A sorter must not be used by multiple
+// goroutines at once.
+func (s *OptimizedCoffmanGrahamSorter) Reset(graph Directed) {
+	s.graph = directedView(graph)
+	s.level = 0
+	s.reset()
+}
 
-//		fmt.Println("HEEERR level ", level);
+// ReducedGraph returns the transitively reduced copy of the graph leveled by
+// the last successful sort, or nil before the first one. It is the sorter's
+// internal copy and must not be modified. With WithoutTransitiveReduction it
+// is the graph itself.
+func (s *OptimizedCoffmanGrahamSorter) ReducedGraph() *DirectedGraph {
+	return s.reducedGraph()
+}
 
-		// create a new layer new none was found
-		if level == -1 {
-			layers = append(layers, make([]Node, 0, 1))
-			level = len(layers) - 1
+// RemovedTransitiveEdges returns the transitive edges which were removed from
+// the graph before it was leveled by the last successful sort, in the graph's
+// node order, or nil before the first one. Together with the edges of
+// ReducedGraph they make up the graph's edges at the time.
+func (s *OptimizedCoffmanGrahamSorter) RemovedTransitiveEdges() []Edge {
+	return s.removedTransitiveEdges()
+}
 
-//			fmt.Println("CREATING NEW level=", level, " , layers=", layers);
-		}
+// DeferredAtLayer returns the nodes whose dependencies allowed placing them
+// on the level, but which were placed on a later level because the level had
+// no room left for them, ordered by their level. A node is only reported for
+// the first level it was eligible for, so the count tells how many more
+// nodes the level would have taken given more width. The result is empty for
+// levels which never filled up or do not exist.
+func (s *OptimizedCoffmanGrahamSorter) DeferredAtLayer(level int) []Node {
+	return s.deferredAt(level)
+}
 
-//		fmt.Println("- LOOOOOOOP --- level ", level);
+// LevelOf returns the level the node was assigned, which is also the index of
+// its layer in the layers returned, and whether it was assigned one.
+func (s *OptimizedCoffmanGrahamSorter) LevelOf(node Node) (int, bool) {
+	return s.levelOf(node)
+}
 
-		layers[level] = append(layers[level], node)
-		levels[node] = level
+// PruneBelow forgets the nodes on the levels below the level and empties
+// their layers to free memory, e.g. for events every replica has seen,
+// returning the pruned nodes in level order. The remaining nodes keep their
+// levels, and nodes sorted later are never placed below the level, even
+// without dependencies or after Recompute. The pruned nodes should also be
+// removed from the graph, as they would otherwise be leveled again.
+func (s *OptimizedCoffmanGrahamSorter) PruneBelow(level int) []Node {
+	return s.pruneBelow(level)
+}
 
-		if maxLevel == -1 || maxLevel < level {
-			maxLevel = level
-		}
-	}
+// PendingCount returns the number of the graph's nodes which were not
+// assigned a level yet, e.g. to monitor the backlog between calls to
+// EventSortLimited. It takes constant time as it subtracts the number of
+// leveled nodes from the graph's, so it is only accurate if the nodes removed
+// from the graph were also removed from the sorter by RemoveNode, and the
+// nodes pruned by PruneBelow were removed from the graph.
+func (s *OptimizedCoffmanGrahamSorter) PendingCount() int {
+	return s.pendingCount(s.graph)
+}
 
-	s.level = maxLevel;
-	s.layers = layers;
-	s.levels = levels;
+// Pending returns the graph's nodes which were not assigned a level yet, in
+// the order the next call to EventSortLimited assigns them while one is in
+// progress, and in the graph's node order otherwise. Unlike PendingCount it
+// visits every node.
+func (s *OptimizedCoffmanGrahamSorter) Pending() []Node {
+	return s.pendingNodes(s.graph)
+}
 
-//	fmt.Println("ENDDDING level=", s.level);
-//	fmt.Println("ENDDDING --- levels", s.levels,
-//		 "\n layers", s.layers)
+// OverCapacity returns the nodes whose demand exceeds the capacity in some
+// dimension, see WithCapacities, each of which was placed in a new layer of
+// its own, ordered by their level. It is empty without capacities.
+func (s *OptimizedCoffmanGrahamSorter) OverCapacity() []Node {
+	return s.overCapacity()
+}
 
-	return layers, nil
+// Sort returns the sorted nodes, see EventSort.
+func (s *OptimizedCoffmanGrahamSorter) Sort() ([][]Node, error) {
+	return s.EventSort()
 }
 
 func (g *DirectedGraph) OptimizedCoffmanGrahamSorter(width int) (*OptimizedCoffmanGrahamSorter) {
-	sorter := NewOptimizedCoffmanGrahamSorter(g, width)
+	sorter := newOptimizedCoffmanGrahamSorter(g, &sorterConfig{width: width, widthSet: true})
 	return sorter
 }
 
-// NewCoffmanGrahamSorter returns a new Coffman-Graham sorter.
-func NewOptimizedCoffmanGrahamSorter(graph *DirectedGraph, width int) *OptimizedCoffmanGrahamSorter {
-	layers := make([][]Node, 0)
-	levels := make(map[Node]int, 0)
-	level := 0
+// NewOptimizedCoffmanGrahamSorter returns a new incremental Coffman-Graham sorter.
+//
+// Deprecated: Use NewOptimizedCoffmanGrahamSorterWithOptions with WithWidth instead.
+func NewOptimizedCoffmanGrahamSorter(graph Directed, width int) *OptimizedCoffmanGrahamSorter {
+	return newOptimizedCoffmanGrahamSorter(graph, &sorterConfig{width: width, widthSet: true})
+}
+
+// NewOptimizedCoffmanGrahamSorterWithOptions returns a new incremental
+// Coffman-Graham sorter configured by the specified options.
+// The options are validated eagerly and a descriptive error is returned if
+// they are missing or conflicting.
+func NewOptimizedCoffmanGrahamSorterWithOptions(graph Directed, opts ...SorterOption) (*OptimizedCoffmanGrahamSorter, error) {
+	config, err := newSorterConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return newOptimizedCoffmanGrahamSorter(graph, config), nil
+}
 
+func newOptimizedCoffmanGrahamSorter(graph Directed, config *sorterConfig) *OptimizedCoffmanGrahamSorter {
+	leveler := newLeveler(config)
+	leveler.arrival = true
 	return &OptimizedCoffmanGrahamSorter{
-		graph: graph,
-		width: width,
-		layers : layers,
-		levels : levels,
-		level : level,
+		graph:   directedView(graph),
+		leveler: leveler,
 	}
 }
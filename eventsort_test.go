@@ -0,0 +1,198 @@
+package graff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventSortLimitedChunks(t *testing.T) {
+	g := layeredGraph(10000, 3)
+	// the reduction of the graph would dominate the test's running time
+	newSorter := func() *OptimizedCoffmanGrahamSorter {
+		s, err := NewOptimizedCoffmanGrahamSorterWithOptions(g, WithWidth(8), WithoutTransitiveReduction())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	want, err := newSorter().EventSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newSorter()
+	assigned := 0
+	for calls := 1; ; calls++ {
+		layers, done, err := s.EventSortLimited(100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		count := 0
+		for _, layer := range layers {
+			for _, node := range layer {
+				count++
+				level, _ := s.LevelOf(node)
+				for _, dependency := range g.IncomingEdges(node) {
+					if before, ok := s.LevelOf(dependency); !ok || before >= level {
+						t.Fatalf("call %d assigned %v before its dependency %v", calls, node, dependency)
+					}
+				}
+			}
+		}
+		if count-assigned > 100 {
+			t.Fatalf("call %d assigned %d nodes", calls, count-assigned)
+		}
+		assigned = count
+
+		if done {
+			if calls != 100 {
+				t.Errorf("the sort took %d calls, want 100", calls)
+			}
+			if !reflect.DeepEqual(layers, want) {
+				t.Error("the chunked layering differs from a single EventSort")
+			}
+			break
+		}
+	}
+}
+
+func TestEventSortArrivalOrder(t *testing.T) {
+	// the events arrive out of causal order, the dependencies of z last
+	g := NewEventGraph()
+	for _, event := range []Node{"z", "q", "c", "b", "a", "y"} {
+		g.AddNode(event)
+	}
+	g.AddEdge("z", "c")
+	g.AddEdge("z", "a")
+	g.AddEdge("y", "b")
+
+	if index, ok := g.InsertionIndex("c"); !ok || index != 2 {
+		t.Errorf("got insertion index %d, %v for c, want 2", index, ok)
+	}
+	g.RemoveNode("q")
+	g.AddNode("q")
+	if index, _ := g.InsertionIndex("q"); index != 6 {
+		t.Errorf("got insertion index %d for the re-added q, want 6", index)
+	}
+
+	sorter := NewOptimizedCoffmanGrahamSorter(g.DirectedGraph, 4)
+	layers, err := sorter.EventSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]Node{{"c", "b", "a", "q"}, {"z", "y"}}
+	if !reflect.DeepEqual(layers, want) {
+		t.Errorf("got layers %v, want them in arrival order %v", layers, want)
+	}
+
+	// events leveled by a later sort follow those of earlier ones
+	g.AddNode("d")
+	g.AddEdge("x", "d")
+	layers, err = sorter.EventSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = [][]Node{{"c", "b", "a", "q"}, {"z", "y", "d"}, {"x"}}
+	if !reflect.DeepEqual(layers, want) {
+		t.Errorf("got layers %v after a second sort, want %v", layers, want)
+	}
+}
+
+func TestEventSortBaseLevel(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddNode("c")
+	sorter, err := NewOptimizedCoffmanGrahamSorterWithOptions(g, WithWidth(2), WithBaseLevel(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := sorter.EventSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]Node{{}, {}, {}, {"a", "c"}, {"b"}}; !reflect.DeepEqual(layers, want) {
+		t.Errorf("got layers %v, want %v", layers, want)
+	}
+	if level, _ := sorter.LevelOf("b"); level != 4 {
+		t.Errorf("b is on level %d, want 4", level)
+	}
+}
+
+func TestEventSortPruneKeepsLevels(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := 0; i < 12; i++ {
+		g.AddEdge(i, i+1)
+	}
+	sorter := NewOptimizedCoffmanGrahamSorter(g, 2)
+	if _, err := sorter.EventSort(); err != nil {
+		t.Fatal(err)
+	}
+
+	pruned := sorter.PruneBelow(10)
+	if want := []Node{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}; !reflect.DeepEqual(pruned, want) {
+		t.Errorf("pruned %v, want %v", pruned, want)
+	}
+	g.RemoveNodes(pruned...)
+	g.AddEdge(12, "dependant")
+	g.AddNode("root")
+	if _, err := sorter.EventSort(); err != nil {
+		t.Fatal(err)
+	}
+	// the new root is not placed below the pruned levels
+	for node, want := range map[Node]int{10: 10, 12: 12, "dependant": 13, "root": 10} {
+		if level, ok := sorter.LevelOf(node); !ok || level != want {
+			t.Errorf("%v is on level %d, want %d", node, level, want)
+		}
+	}
+}
+
+func TestEventSortPending(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := 0; i < 9; i++ {
+		g.AddEdge(i, i+1)
+	}
+	sorter := NewOptimizedCoffmanGrahamSorter(g, 1)
+	check := func(step string, want int) {
+		t.Helper()
+		pending := sorter.Pending()
+		if sorter.PendingCount() != want || len(pending) != want {
+			t.Errorf("%s: %d pending, %v, want %d", step, sorter.PendingCount(), pending, want)
+		}
+		for _, node := range pending {
+			if _, ok := sorter.LevelOf(node); ok {
+				t.Errorf("%s: the pending node %v has a level", step, node)
+			}
+		}
+	}
+	check("new sorter", 10)
+
+	// the count drops with every chunk, and the chunk in progress lists the
+	// pending nodes in the order it assigns them
+	for _, want := range []int{6, 2} {
+		if _, _, err := sorter.EventSortLimited(4); err != nil {
+			t.Fatal(err)
+		}
+		check("chunk", want)
+		if sorter.Pending()[0] != 10-want {
+			t.Errorf("the first pending node is %v, want %d", sorter.Pending()[0], 10-want)
+		}
+	}
+	if _, _, err := sorter.EventSortLimited(4); err != nil {
+		t.Fatal(err)
+	}
+	check("all sorted", 0)
+
+	g.AddEdge(9, 10)
+	g.AddNode(11)
+	check("added", 2)
+
+	g.RemoveNode(11)
+	check("removed", 1)
+
+	g.RemoveNodes(sorter.PruneBelow(3)...)
+	check("pruned", 1)
+	if _, err := sorter.EventSort(); err != nil {
+		t.Fatal(err)
+	}
+	check("sorted", 0)
+}
@@ -0,0 +1,74 @@
+package graff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"sort"
+)
+
+// Fingerprint hashes a canonical representation of the graph's structure,
+// so that graphs with the same node keys and edges produce the same digest
+// regardless of the order in which they were built. The nodes are written
+// sorted by their key, followed by the edges sorted by the keys of their
+// source and target, every key prefixed by its length. Edge multiplicities
+// and attributes are not part of the fingerprint.
+// A nil key function uses the fmt.Sprint representation of the nodes. If two
+// nodes share a key an error wrapping ErrDuplicateNode is returned.
+// The representation is written to h, returning h.Sum(nil).
+func (g *DirectedGraph) Fingerprint(h hash.Hash, nodeKey func(Node) []byte) ([]byte, error) {
+	if nodeKey == nil {
+		nodeKey = func(node Node) []byte { return []byte(fmt.Sprint(node)) }
+	}
+
+	nodes := g.Nodes()
+	keys := make(map[Node][]byte, len(nodes))
+	sorted := make([][]byte, 0, len(nodes))
+	for _, node := range nodes {
+		key := nodeKey(node)
+		keys[node] = key
+		sorted = append(sorted, key)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	for i := 1; i < len(sorted); i++ {
+		if bytes.Equal(sorted[i-1], sorted[i]) {
+			return nil, fmt.Errorf("%w: two nodes have the key %q", ErrDuplicateNode, sorted[i])
+		}
+	}
+
+	edges := make([][2][]byte, 0, g.EdgeCount())
+	for _, from := range nodes {
+		g.EachOutgoing(from, func(to Node) bool {
+			edges = append(edges, [2][]byte{keys[from], keys[to]})
+			return true
+		})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if c := bytes.Compare(edges[i][0], edges[j][0]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(edges[i][1], edges[j][1]) < 0
+	})
+
+	buf := make([]byte, 0, binary.MaxVarintLen64)
+	write := func(key []byte) {
+		buf = binary.AppendUvarint(buf[:0], uint64(len(key)))
+		h.Write(buf)
+		h.Write(key)
+	}
+	buf = binary.AppendUvarint(buf[:0], uint64(len(sorted)))
+	h.Write(buf)
+	for _, key := range sorted {
+		write(key)
+	}
+	buf = binary.AppendUvarint(buf[:0], uint64(len(edges)))
+	h.Write(buf)
+	for _, edge := range edges {
+		write(edge[0])
+		write(edge[1])
+	}
+	return h.Sum(nil), nil
+}
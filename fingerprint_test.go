@@ -0,0 +1,51 @@
+package graff
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	edges := [][2]Node{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}}
+	build := func(edges [][2]Node) *DirectedGraph {
+		g := NewDirectedGraph()
+		for _, edge := range edges {
+			g.AddEdge(edge[0], edge[1])
+		}
+		return g
+	}
+	fingerprint := func(g *DirectedGraph) []byte {
+		digest, err := g.Fingerprint(sha256.New(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return digest
+	}
+
+	want := fingerprint(build(edges))
+	reversed := make([][2]Node, len(edges))
+	for i, edge := range edges {
+		reversed[len(edges)-1-i] = edge
+	}
+	if got := fingerprint(build(reversed)); !bytes.Equal(got, want) {
+		t.Error("building the graph in reverse changed its fingerprint")
+	}
+
+	// the same nodes and edge count, but b->d is replaced by b->c
+	changed := build([][2]Node{{"a", "b"}, {"a", "c"}, {"b", "c"}, {"c", "d"}})
+	if bytes.Equal(fingerprint(changed), want) {
+		t.Error("a different graph has the same fingerprint")
+	}
+	// the keys are length-prefixed, so "ab"+"c" differs from "a"+"bc"
+	if bytes.Equal(fingerprint(build([][2]Node{{"ab", "c"}})), fingerprint(build([][2]Node{{"a", "bc"}}))) {
+		t.Error("concatenated keys collide")
+	}
+
+	g := NewDirectedGraph()
+	g.AddEdge(1, "1")
+	if _, err := g.Fingerprint(sha256.New(), nil); !errors.Is(err, ErrDuplicateNode) {
+		t.Errorf("got %v, want ErrDuplicateNode", err)
+	}
+}
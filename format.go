@@ -0,0 +1,88 @@
+package graff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatLayers renders the layers as human-readable text for logging, one
+// layer per line in the form "L3: [e17 e19 e22]", formatting every node using
+// nodeFmt, or fmt.Sprint if it is nil.
+func FormatLayers(layers [][]Node, nodeFmt func(Node) string) string {
+	return formatLayers(layers, nodeFmt, 0)
+}
+
+// formatLayers renders the layers like FormatLayers, truncating every layer
+// to at most limit nodes unless it is zero.
+func formatLayers(layers [][]Node, nodeFmt func(Node) string, limit int) string {
+	if nodeFmt == nil {
+		nodeFmt = func(node Node) string { return fmt.Sprint(node) }
+	}
+
+	var b strings.Builder
+	for level, layer := range layers {
+		if level > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "L%d: ", level)
+		writeNodes(&b, layer, nodeFmt, limit)
+	}
+	return b.String()
+}
+
+// formatOrder renders the nodes of a topological order in the form
+// "[e17 e19 e22]", truncated to at most limit nodes unless it is zero.
+func formatOrder(order []Node, limit int) string {
+	var b strings.Builder
+	writeNodes(&b, order, func(node Node) string { return fmt.Sprint(node) }, limit)
+	return b.String()
+}
+
+// writeNodes writes the bracketed nodes, see formatLayers.
+func writeNodes(b *strings.Builder, nodes []Node, nodeFmt func(Node) string, limit int) {
+	b.WriteByte('[')
+	shown := nodes
+	if limit > 0 && len(nodes) > limit {
+		shown = nodes[:limit]
+	}
+	for i, node := range shown {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(nodeFmt(node))
+	}
+	if len(shown) < len(nodes) {
+		fmt.Fprintf(b, " … (+%d more)", len(nodes)-len(shown))
+	}
+	b.WriteByte(']')
+}
+
+// String renders the layers assigned so far, see FormatLayers and
+// WithFormatLimit.
+func (s *CoffmanGrahamSorter) String() string {
+	return formatLayers(s.layers, nil, s.config.formatLimit)
+}
+
+// String renders the layers assigned so far, see FormatLayers and
+// WithFormatLimit.
+func (s *OptimizedCoffmanGrahamSorter) String() string {
+	return formatLayers(s.layers, nil, s.config.formatLimit)
+}
+
+// String renders the nodes sorted by the last successful sort in the form
+// "[e17 e19 e22]", see WithFormatLimit.
+func (s *DFSSorter) String() string {
+	return formatOrder(s.result, s.config.formatLimit)
+}
+
+// String renders the nodes sorted by the last successful sort in the form
+// "[e17 e19 e22]".
+func (s *KahnSorter) String() string {
+	return formatOrder(s.result, 0)
+}
+
+// String renders the nodes sorted by the last successful sort in the form
+// "[e17 e19 e22]".
+func (s *RandomSorter) String() string {
+	return formatOrder(s.result, 0)
+}
@@ -0,0 +1,91 @@
+package graff
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestFormatLayers(t *testing.T) {
+	layers := [][]Node{{"e17", "e19", "e22"}, {"e8"}, {}}
+	want := "L0: [e17 e19 e22]\nL1: [e8]\nL2: []"
+	if got := FormatLayers(layers, nil); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	upper := func(node Node) string { return "<" + node.(string) + ">" }
+	want = "L0: [<e17> <e19> <e22>]\nL1: [<e8>]\nL2: []"
+	if got := FormatLayers(layers, upper); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCoffmanGrahamSorterString(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "c")
+	g.AddEdge("d", "c")
+	sorter, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(3), WithFormatLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sorter.Sort(); err != nil {
+		t.Fatal(err)
+	}
+	want := "L0: [d b … (+1 more)]\nL1: [c]"
+	if got := sorter.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTopologicalSorterString(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge(1, 2)
+	g.AddEdge(2, 3)
+
+	sorters := map[string]interface {
+		TopologicalSorter
+		String() string
+	}{
+		"dfs":    NewDFSSorter(g),
+		"kahn":   NewKahnSorter(g, nil),
+		"random": NewRandomSorter(g, rand.New(rand.NewSource(1))),
+	}
+	for name, sorter := range sorters {
+		if got := sorter.String(); got != "[]" {
+			t.Errorf("%s: got %q before sorting, want []", name, got)
+		}
+		if _, err := sorter.Sort(); err != nil {
+			t.Fatal(err)
+		}
+		if got := sorter.String(); got != "[1 2 3]" {
+			t.Errorf("%s: got %q, want [1 2 3]", name, got)
+		}
+	}
+
+	g.AddEdge(3, 1)
+	for name, sorter := range sorters {
+		if _, err := sorter.Sort(); err == nil {
+			t.Fatalf("%s: sorted a cyclic graph", name)
+		}
+		if got := sorter.String(); got != "[1 2 3]" {
+			t.Errorf("%s: got %q after a failed sort, want the last result", name, got)
+		}
+	}
+}
+
+func TestDFSSorterStringLimit(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := 0; i < 4; i++ {
+		g.AddEdge(i, i+1)
+	}
+	sorter, err := NewDFSSorterWithOptions(g, WithFormatLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sorter.Sort(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sorter.String(), "[0 1 … (+3 more)]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
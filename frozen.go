@@ -0,0 +1,284 @@
+package graff
+
+// FrozenGraph is an immutable snapshot of a DirectedGraph, see Freeze.
+// Its adjacency is stored in flat slices built once, so that queries neither
+// hash edges nor check for modifications, and it is safe for concurrent use
+// by multiple goroutines without locking. This includes its sorting and
+// centrality methods, which read the snapshot directly and keep their state
+// in the sorter of each call. The Coffman-Graham sorts remove the transitive
+// edges, so every call reduces a private DirectedGraph copied from the
+// snapshot, and e.g. several sorts of different widths may run on one
+// snapshot at once. Options holding state of their own, like the generator
+// given to WithRandomOrdering, must not be shared between concurrent calls.
+type FrozenGraph struct {
+	nodes []Node
+	ids   map[Node]int32
+
+	// the adjacency of the node with ID i is held by out[outStart[i]:outStart[i+1]]
+	// and in[inStart[i]:inStart[i+1]] in insertion order, and as nodes at the
+	// same positions of outNodes and inNodes, which are returned without
+	// allocating
+	outStart []int32
+	out      []int32
+	outNodes []Node
+	inStart  []int32
+	in       []int32
+	inNodes  []Node
+}
+
+// Freeze returns an immutable snapshot of the graph's nodes and edges.
+// Attributes and edge multiplicities are not retained. Later mutations of
+// the graph do not affect the snapshot.
+func (g *DirectedGraph) Freeze() *FrozenGraph {
+	nodes := copyNodes(g.Nodes())
+	f := &FrozenGraph{
+		nodes:    nodes,
+		ids:      make(map[Node]int32, len(nodes)),
+		outStart: make([]int32, len(nodes)+1),
+		out:      make([]int32, 0, g.EdgeCount()),
+		outNodes: make([]Node, 0, g.EdgeCount()),
+		inStart:  make([]int32, len(nodes)+1),
+		in:       make([]int32, 0, g.EdgeCount()),
+		inNodes:  make([]Node, 0, g.EdgeCount()),
+	}
+	for i, node := range nodes {
+		f.ids[node] = int32(i)
+	}
+	for i, node := range nodes {
+		for _, to := range g.OutgoingEdges(node) {
+			f.out = append(f.out, f.ids[to])
+			f.outNodes = append(f.outNodes, to)
+		}
+		f.outStart[i+1] = int32(len(f.out))
+		for _, from := range g.IncomingEdges(node) {
+			f.in = append(f.in, f.ids[from])
+			f.inNodes = append(f.inNodes, from)
+		}
+		f.inStart[i+1] = int32(len(f.in))
+	}
+	return f
+}
+
+// Thaw returns a mutable copy of the frozen graph.
+func (f *FrozenGraph) Thaw() *DirectedGraph {
+	g := NewDirectedGraph()
+	g.Grow(len(f.nodes), len(f.out))
+	g.AddNodes(f.nodes...)
+	for i, from := range f.nodes {
+		for _, to := range f.outgoingIDs(int32(i)) {
+			g.edges.Add(from, f.nodes[to])
+		}
+	}
+	return g
+}
+
+// Nodes returns the graph's nodes in insertion order.
+// The slice is shared for performance reasons and must not be mutated.
+func (f *FrozenGraph) Nodes() []Node {
+	return f.nodes
+}
+
+// NodeCount returns the number of nodes.
+func (f *FrozenGraph) NodeCount() int {
+	return len(f.nodes)
+}
+
+// NodeExists determines whether the specified node exists within the graph.
+func (f *FrozenGraph) NodeExists(node Node) bool {
+	_, ok := f.ids[node]
+	return ok
+}
+
+// EdgeCount returns the number of directed edges between nodes.
+func (f *FrozenGraph) EdgeCount() int {
+	return len(f.out)
+}
+
+// Edges returns the graph's edges ordered by their source in node order.
+func (f *FrozenGraph) Edges() []Edge {
+	edges := make([]Edge, 0, len(f.out))
+	for i, from := range f.nodes {
+		for _, to := range f.outgoingIDs(int32(i)) {
+			edges = append(edges, Edge{from, f.nodes[to]})
+		}
+	}
+	return edges
+}
+
+func (f *FrozenGraph) outgoingIDs(id int32) []int32 {
+	return f.out[f.outStart[id]:f.outStart[id+1]]
+}
+
+func (f *FrozenGraph) incomingIDs(id int32) []int32 {
+	return f.in[f.inStart[id]:f.inStart[id+1]]
+}
+
+// OutgoingEdges returns the nodes belonging to directed edges pointing
+// from the specified node.
+// The slice is shared for performance reasons and must not be mutated.
+func (f *FrozenGraph) OutgoingEdges(node Node) []Node {
+	id, ok := f.ids[node]
+	if !ok || f.outStart[id] == f.outStart[id+1] {
+		return nil
+	}
+	return f.outNodes[f.outStart[id]:f.outStart[id+1]:f.outStart[id+1]]
+}
+
+// IncomingEdges returns the nodes belonging to directed edges pointing
+// towards the specified node.
+// The slice is shared for performance reasons and must not be mutated.
+func (f *FrozenGraph) IncomingEdges(node Node) []Node {
+	id, ok := f.ids[node]
+	if !ok || f.inStart[id] == f.inStart[id+1] {
+		return nil
+	}
+	return f.inNodes[f.inStart[id]:f.inStart[id+1]:f.inStart[id+1]]
+}
+
+// OutgoingEdgeCount returns the number of edges pointing from the node.
+func (f *FrozenGraph) OutgoingEdgeCount(node Node) int {
+	id, ok := f.ids[node]
+	if !ok {
+		return 0
+	}
+	return len(f.outgoingIDs(id))
+}
+
+// IncomingEdgeCount returns the number of edges pointing towards the node.
+func (f *FrozenGraph) IncomingEdgeCount(node Node) int {
+	id, ok := f.ids[node]
+	if !ok {
+		return 0
+	}
+	return len(f.incomingIDs(id))
+}
+
+// EachOutgoing calls fn for the nodes belonging to directed edges pointing
+// from the specified node until it returns false, without allocating.
+func (f *FrozenGraph) EachOutgoing(node Node, fn func(Node) bool) {
+	if id, ok := f.ids[node]; ok {
+		for _, to := range f.outgoingIDs(id) {
+			if !fn(f.nodes[to]) {
+				return
+			}
+		}
+	}
+}
+
+// EachIncoming calls fn for the nodes belonging to directed edges pointing
+// towards the specified node until it returns false, without allocating.
+func (f *FrozenGraph) EachIncoming(node Node, fn func(Node) bool) {
+	if id, ok := f.ids[node]; ok {
+		for _, from := range f.incomingIDs(id) {
+			if !fn(f.nodes[from]) {
+				return
+			}
+		}
+	}
+}
+
+// EdgeExists checks whether the edge exists within the graph.
+func (f *FrozenGraph) EdgeExists(from Node, to Node) bool {
+	fromID, ok := f.ids[from]
+	if !ok {
+		return false
+	}
+	toID, ok := f.ids[to]
+	if !ok {
+		return false
+	}
+
+	// scan the shorter of the two adjacency lists
+	outgoing, incoming := f.outgoingIDs(fromID), f.incomingIDs(toID)
+	if len(outgoing) <= len(incoming) {
+		return containsID(outgoing, toID)
+	}
+	return containsID(incoming, fromID)
+}
+
+func containsID(ids []int32, id int32) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAncestor determines whether a is an ancestor of b, i.e. whether a directed
+// path leads from a to b, see DirectedGraph.IsAncestor.
+// An error wrapping ErrNodeNotFound is returned if either node does not exist.
+func (f *FrozenGraph) IsAncestor(a Node, b Node) (bool, error) {
+	fromID, ok := f.ids[a]
+	if !ok {
+		return false, &NodeNotFoundError{Node: a}
+	}
+	toID, ok := f.ids[b]
+	if !ok {
+		return false, &NodeNotFoundError{Node: b}
+	}
+	return f.pathExists(fromID, toID), nil
+}
+
+// IsDescendant determines whether a is a descendant of b, i.e. whether a
+// directed path leads from b to a. See IsAncestor.
+func (f *FrozenGraph) IsDescendant(a Node, b Node) (bool, error) {
+	return f.IsAncestor(b, a)
+}
+
+// pathExists determines whether a directed path of at least one edge leads
+// from one node to the other using a breadth-first search.
+func (f *FrozenGraph) pathExists(from int32, to int32) bool {
+	visited := make([]bool, len(f.nodes))
+	queue := []int32{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range f.outgoingIDs(id) {
+			if next == to {
+				return true
+			}
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// DFSSort returns the graph's nodes in topological order, see
+// DirectedGraph.DFSSort.
+func (f *FrozenGraph) DFSSort() ([]Node, error) {
+	return NewDFSSorter(f).Sort()
+}
+
+// LexTopoSort returns the graph's nodes in the lexicographically smallest
+// topological order under the comparison function, see
+// DirectedGraph.LexTopoSort.
+func (f *FrozenGraph) LexTopoSort(less func(a, b Node) bool) ([]Node, error) {
+	return NewKahnSorter(f, less).Sort()
+}
+
+// CoffmanGrahamSort levels the graph's nodes, see
+// DirectedGraph.CoffmanGrahamSort.
+func (f *FrozenGraph) CoffmanGrahamSort(width int) ([][]Node, error) {
+	return NewCoffmanGrahamSorter(f, width).Sort()
+}
+
+// CoffmanGrahamSortWithOptions levels the graph's nodes using a sorter
+// configured by the specified options, see
+// NewCoffmanGrahamSorterWithOptions.
+func (f *FrozenGraph) CoffmanGrahamSortWithOptions(opts ...SorterOption) ([][]Node, error) {
+	sorter, err := NewCoffmanGrahamSorterWithOptions(f, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return sorter.Sort()
+}
+
+// BetweennessCentrality computes the betweenness centrality of every node,
+// see DirectedGraph.BetweennessCentrality.
+func (f *FrozenGraph) BetweennessCentrality() (map[Node]float64, error) {
+	return betweennessCentrality(f, false)
+}
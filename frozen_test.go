@@ -0,0 +1,159 @@
+package graff
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestFrozenGraphConcurrentSorts runs the sorts on one shared snapshot at
+// once, which is meant to be run with -race.
+func TestFrozenGraphConcurrentSorts(t *testing.T) {
+	g := layeredGraph(300, 3)
+	f := g.Freeze()
+
+	wantOrder, err := g.DFSSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCentrality, err := g.BetweennessCentrality()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	run := func(name string, fn func() (interface{}, error), want interface{}) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := fn()
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				return
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s differs from the mutable graph's", name)
+			}
+		}()
+	}
+
+	run("DFSSort", func() (interface{}, error) { return f.DFSSort() }, wantOrder)
+	run("BetweennessCentrality", func() (interface{}, error) { return f.BetweennessCentrality() }, wantCentrality)
+	for _, width := range []int{2, 3, 5} {
+		width := width
+		want, err := g.CoffmanGrahamSort(width)
+		if err != nil {
+			t.Fatal(err)
+		}
+		run("CoffmanGrahamSort", func() (interface{}, error) { return f.CoffmanGrahamSort(width) }, want)
+	}
+	wg.Wait()
+}
+
+func TestFrozenGraphIsolatedFromMutations(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	f := g.Freeze()
+	g.AddEdge("b", "c")
+	g.RemoveEdge("a", "b")
+
+	if f.NodeCount() != 2 || !f.EdgeExists("a", "b") || f.NodeExists("c") {
+		t.Error("the snapshot changed with the graph")
+	}
+	if outgoing := f.OutgoingEdges("a"); len(outgoing) != 1 || outgoing[0] != "b" {
+		t.Errorf("outgoing edges of a are %v, want [b]", outgoing)
+	}
+}
+
+func BenchmarkFrozenEdgeExists(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	graphs := map[string]Directed{"mutable": g, "frozen": g.Freeze()}
+	for _, name := range []string{"mutable", "frozen"} {
+		d := graphs[name]
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				d.EdgeExists(i%10000, (i*7)%10000)
+			}
+		})
+	}
+}
+
+func BenchmarkFrozenOutgoingEdges(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	graphs := map[string]Directed{"mutable": g, "frozen": g.Freeze()}
+	for _, name := range []string{"mutable", "frozen"} {
+		d := graphs[name]
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = d.OutgoingEdges(i % 10000)
+			}
+		})
+	}
+}
+
+func BenchmarkFrozenIsAncestor(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	f := g.Freeze()
+	b.Run("mutable", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			g.IsAncestor(i%100, 9999-i%100)
+		}
+	})
+	b.Run("frozen", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			f.IsAncestor(i%100, 9999-i%100)
+		}
+	})
+}
+
+func BenchmarkFrozenDFSSort(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	f := g.Freeze()
+	b.Run("mutable", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.DFSSort()
+		}
+	})
+	b.Run("frozen", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			f.DFSSort()
+		}
+	})
+}
+
+func TestFrozenGraphMatchesGraph(t *testing.T) {
+	g := layeredGraph(200, 4)
+	g.AddNode("isolated")
+	f := g.Freeze()
+
+	if !reflect.DeepEqual(f.Nodes(), g.Nodes()) || f.EdgeCount() != g.EdgeCount() {
+		t.Fatalf("froze %d nodes and %d edges, want %d and %d", f.NodeCount(), f.EdgeCount(), g.NodeCount(), g.EdgeCount())
+	}
+	for _, node := range g.Nodes() {
+		if !reflect.DeepEqual(f.OutgoingEdges(node), g.OutgoingEdges(node)) || !reflect.DeepEqual(f.IncomingEdges(node), g.IncomingEdges(node)) {
+			t.Errorf("the edges of %v differ: %v %v, want %v %v", node, f.OutgoingEdges(node), f.IncomingEdges(node), g.OutgoingEdges(node), g.IncomingEdges(node))
+		}
+	}
+	for _, pair := range [][2]Node{{0, 199}, {5, 6}, {199, 0}, {"isolated", 0}} {
+		got, err := f.IsAncestor(pair[0], pair[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, _ := g.IsAncestor(pair[0], pair[1]); got != want {
+			t.Errorf("IsAncestor(%v, %v) is %v, want %v", pair[0], pair[1], got, want)
+		}
+	}
+	if _, err := f.IsAncestor(0, "missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("got %v, want ErrNodeNotFound", err)
+	}
+
+	thawed := f.Thaw()
+	thawed.AddEdge("isolated", 0)
+	if f.EdgeExists("isolated", 0) || !reflect.DeepEqual(thawed.Nodes(), g.Nodes()) {
+		t.Error("the thawed copy is not independent of the snapshot")
+	}
+}
@@ -0,0 +1,200 @@
+// Package gonumgraph adapts graff graphs to the gonum graph interfaces, so
+// that gonum's network algorithms can be run on them without copying.
+package gonumgraph
+
+import (
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/iterator"
+
+	graff "github.com/quan8/cofgra"
+)
+
+// Node is a graff node as seen by gonum, identified by an int64 ID.
+type Node struct {
+	id    int64
+	Value graff.Node
+}
+
+// ID returns the node's ID.
+func (n Node) ID() int64 {
+	return n.id
+}
+
+// Edge is a directed edge between two adapted nodes.
+type Edge struct {
+	F, T Node
+}
+
+// From returns the source of the edge.
+func (e Edge) From() graph.Node {
+	return e.F
+}
+
+// To returns the target of the edge.
+func (e Edge) To() graph.Node {
+	return e.T
+}
+
+// ReversedEdge returns the edge with its source and target swapped.
+func (e Edge) ReversedEdge() graph.Edge {
+	return Edge{F: e.T, T: e.F}
+}
+
+// Graph wraps a *graff.DirectedGraph, implementing graph.Directed.
+// Nodes are assigned IDs in the order they are first seen, and keep their ID
+// for the lifetime of the Graph even if they are removed from the underlying
+// graph. The underlying graph may be changed between calls, but a Graph is
+// not safe for concurrent use since reading it may assign new IDs.
+type Graph struct {
+	graph *graff.DirectedGraph
+	ids   map[graff.Node]int64
+	nodes map[int64]graff.Node
+}
+
+// Ensure Graph implements graph.Directed.
+var _ graph.Directed = (*Graph)(nil)
+
+// New returns a Graph wrapping the specified graph, assigning IDs to its
+// nodes in insertion order.
+func New(g *graff.DirectedGraph) *Graph {
+	a := &Graph{
+		graph: g,
+		ids:   make(map[graff.Node]int64, g.NodeCount()),
+		nodes: make(map[int64]graff.Node, g.NodeCount()),
+	}
+	for _, node := range g.Nodes() {
+		a.intern(node)
+	}
+	return a
+}
+
+// intern returns the ID of the node, assigning it the next ID if it has none.
+func (a *Graph) intern(node graff.Node) int64 {
+	if id, ok := a.ids[node]; ok {
+		return id
+	}
+	id := int64(len(a.ids))
+	a.ids[node] = id
+	a.nodes[id] = node
+	return id
+}
+
+// ID returns the ID of a node of the underlying graph, and whether it exists.
+func (a *Graph) ID(node graff.Node) (int64, bool) {
+	if !a.graph.NodeExists(node) {
+		return 0, false
+	}
+	return a.intern(node), true
+}
+
+// Value returns the underlying graph's node with the specified ID, and
+// whether it exists.
+func (a *Graph) Value(id int64) (graff.Node, bool) {
+	node, ok := a.nodes[id]
+	if !ok || !a.graph.NodeExists(node) {
+		return nil, false
+	}
+	return node, true
+}
+
+func (a *Graph) node(node graff.Node) Node {
+	return Node{id: a.intern(node), Value: node}
+}
+
+func (a *Graph) iterate(nodes []graff.Node) graph.Nodes {
+	if len(nodes) == 0 {
+		return graph.Empty
+	}
+	adapted := make([]graph.Node, len(nodes))
+	for i, node := range nodes {
+		adapted[i] = a.node(node)
+	}
+	return iterator.NewOrderedNodes(adapted)
+}
+
+// Node returns the node with the specified ID, or nil if it does not exist.
+func (a *Graph) Node(id int64) graph.Node {
+	node, ok := a.Value(id)
+	if !ok {
+		return nil
+	}
+	return Node{id: id, Value: node}
+}
+
+// Nodes returns all the nodes in insertion order.
+func (a *Graph) Nodes() graph.Nodes {
+	return a.iterate(a.graph.Nodes())
+}
+
+// From returns the nodes reachable by an edge from the node with the
+// specified ID.
+func (a *Graph) From(id int64) graph.Nodes {
+	node, ok := a.Value(id)
+	if !ok {
+		return graph.Empty
+	}
+	return a.iterate(a.graph.OutgoingEdges(node))
+}
+
+// To returns the nodes with an edge to the node with the specified ID.
+func (a *Graph) To(id int64) graph.Nodes {
+	node, ok := a.Value(id)
+	if !ok {
+		return graph.Empty
+	}
+	return a.iterate(a.graph.IncomingEdges(node))
+}
+
+// HasEdgeBetween returns whether an edge exists between the nodes with the
+// specified IDs in either direction.
+func (a *Graph) HasEdgeBetween(xid, yid int64) bool {
+	return a.HasEdgeFromTo(xid, yid) || a.HasEdgeFromTo(yid, xid)
+}
+
+// HasEdgeFromTo returns whether an edge exists from the node with ID uid to
+// the node with ID vid.
+func (a *Graph) HasEdgeFromTo(uid, vid int64) bool {
+	u, ok := a.Value(uid)
+	if !ok {
+		return false
+	}
+	v, ok := a.Value(vid)
+	if !ok {
+		return false
+	}
+	return a.graph.EdgeExists(u, v)
+}
+
+// Edge returns the edge from the node with ID uid to the node with ID vid,
+// or nil if it does not exist.
+func (a *Graph) Edge(uid, vid int64) graph.Edge {
+	if !a.HasEdgeFromTo(uid, vid) {
+		return nil
+	}
+	return Edge{F: Node{id: uid, Value: a.nodes[uid]}, T: Node{id: vid, Value: a.nodes[vid]}}
+}
+
+// FromGonum converts a gonum graph to a *graff.DirectedGraph. Nodes created
+// by a Graph are converted back to their original values, all other nodes
+// are represented by their int64 IDs.
+func FromGonum(g graph.Directed) *graff.DirectedGraph {
+	out := graff.NewDirectedGraph()
+	value := func(node graph.Node) graff.Node {
+		if n, ok := node.(Node); ok {
+			return n.Value
+		}
+		return node.ID()
+	}
+
+	nodes := g.Nodes()
+	for nodes.Next() {
+		node := nodes.Node()
+		out.AddNode(value(node))
+
+		to := g.From(node.ID())
+		for to.Next() {
+			out.AddEdge(value(node), value(to.Node()))
+		}
+	}
+	return out
+}
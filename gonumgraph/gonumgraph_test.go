@@ -0,0 +1,154 @@
+package gonumgraph
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+	"gonum.org/v1/gonum/graph/topo"
+
+	graff "github.com/quan8/cofgra"
+)
+
+// randomDAG returns a DAG of n nodes with edges only to nodes added later.
+func randomDAG(n int, degree int, seed int64) *graff.DirectedGraph {
+	rng := rand.New(rand.NewSource(seed))
+	g := graff.NewDirectedGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(i)
+	}
+	for i := 0; i < n-1; i++ {
+		for j := 0; j < degree; j++ {
+			g.AddEdge(i, i+1+rng.Intn(n-i-1))
+		}
+	}
+	return g
+}
+
+// values converts the adapted nodes back to graff nodes.
+func values(nodes []graph.Node) []graff.Node {
+	result := make([]graff.Node, len(nodes))
+	for i, node := range nodes {
+		result[i] = node.(Node).Value
+	}
+	return result
+}
+
+func TestTopoSortAgreesWithDFSSort(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		g := randomDAG(200, 3, seed)
+		want, err := g.DFSSort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sorted, err := topo.Sort(New(g))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := values(sorted)
+		if len(got) != len(want) {
+			t.Fatalf("topo.Sort returned %d nodes, want %d", len(got), len(want))
+		}
+
+		// both orders must be topological, though they need not be equal
+		for _, order := range [][]graff.Node{got, want} {
+			positions := make(map[graff.Node]int, len(order))
+			for i, node := range order {
+				positions[node] = i
+			}
+			for _, from := range g.Nodes() {
+				for _, to := range g.OutgoingEdges(from) {
+					if positions[from] >= positions[to] {
+						t.Fatalf("seed %d: %v comes after %v in %v", seed, from, to, order)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTopoSortAgreesOnCycles(t *testing.T) {
+	g := graff.NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "a")
+	g.AddEdge("c", "d")
+
+	if _, err := g.DFSSort(); err == nil {
+		t.Fatal("DFSSort accepted a cycle")
+	}
+	_, err := topo.Sort(New(g))
+	unorderable, ok := err.(topo.Unorderable)
+	if !ok || len(unorderable) != 1 {
+		t.Fatalf("got %v, want the one cycle as unorderable", err)
+	}
+	cycle := values(unorderable[0])
+	if len(cycle) != 3 {
+		t.Errorf("got the component %v, want a, b and c", cycle)
+	}
+}
+
+func TestIDs(t *testing.T) {
+	g := graff.NewDirectedGraph()
+	g.AddEdge("a", "b")
+	a := New(g)
+
+	id, ok := a.ID("b")
+	if !ok || id != 1 {
+		t.Fatalf("b has the ID %d, %v, want 1", id, ok)
+	}
+	if value, ok := a.Value(id); !ok || value != "b" {
+		t.Errorf("the ID %d maps to %v", id, value)
+	}
+	if _, ok := a.ID("missing"); ok {
+		t.Error("a missing node has an ID")
+	}
+
+	// nodes added later get the next ID, and removed nodes keep theirs
+	g.AddEdge("b", "c")
+	if id, _ := a.ID("c"); id != 2 {
+		t.Errorf("c has the ID %d, want 2", id)
+	}
+	g.RemoveNode("a")
+	if a.Node(0) != nil || a.HasEdgeFromTo(0, 1) {
+		t.Error("the removed node is still adapted")
+	}
+	g.AddNode("a")
+	if id, _ := a.ID("a"); id != 0 {
+		t.Errorf("the re-added node has the ID %d, want 0", id)
+	}
+
+	if !a.HasEdgeBetween(2, 1) || a.HasEdgeFromTo(2, 1) {
+		t.Error("the edge b -> c is adapted in the wrong direction")
+	}
+	edge := a.Edge(1, 2)
+	if edge == nil || edge.From().(Node).Value != "b" || edge.ReversedEdge().From().(Node).Value != "c" {
+		t.Errorf("got the edge %v", edge)
+	}
+	if a.Edge(2, 1) != nil {
+		t.Error("got an edge for a missing edge")
+	}
+}
+
+func TestFromGonum(t *testing.T) {
+	g := graff.NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddEdge("c", "b")
+	g.AddNode("isolated")
+	back := FromGonum(New(g))
+	if !reflect.DeepEqual(back.Nodes(), g.Nodes()) || !reflect.DeepEqual(back.AdjacencyMatrix(), g.AdjacencyMatrix()) {
+		t.Errorf("converted back to %v, want %v", back.AdjacencyMatrix(), g.AdjacencyMatrix())
+	}
+
+	// the nodes of other graphs are represented by their IDs
+	other := simple.NewDirectedGraph()
+	other.SetEdge(other.NewEdge(simple.Node(1), simple.Node(2)))
+	other.AddNode(simple.Node(3))
+	converted := FromGonum(other)
+	if converted.NodeCount() != 3 || !converted.EdgeExists(int64(1), int64(2)) || !converted.NodeExists(int64(3)) {
+		t.Errorf("converted to %v", converted.AdjacencyMatrix())
+	}
+}
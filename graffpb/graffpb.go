@@ -0,0 +1,113 @@
+// Package graffpb converts graff graphs to and from the Graph message of
+// graph.proto, the canonical format for exchanging graphs between services.
+// Nodes are encoded by a graff.NodeCodec, and edges reference them by their
+// index, which keeps the messages small.
+//
+// The message types are generated from graph.proto by protoc-gen-go and
+// implement proto.Message, so they can be marshaled with the proto package
+// and embedded in the messages of gRPC services.
+package graffpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative graph.proto
+
+import (
+	"errors"
+	"fmt"
+
+	graff "github.com/quan8/cofgra"
+)
+
+// Errors relating to the conversion of messages.
+var (
+	ErrInvalidGraph = errors.New("The message does not describe a valid graph")
+)
+
+// ToProto converts the graph to a message, encoding its nodes in insertion
+// order with the codec, followed by its edges in the order of their source
+// and then their target, so the message is deterministic. Edge
+// multiplicities and attributes are not included.
+func ToProto(g *graff.DirectedGraph, codec graff.NodeCodec) (*Graph, error) {
+	return toProto(g, codec, g.OutgoingEdges)
+}
+
+// FromProto converts the message to a graph, decoding its nodes with the
+// codec. An error wrapping ErrInvalidGraph is returned if a node is listed
+// twice, or an edge is listed twice or references an index out of range.
+func FromProto(pb *Graph, codec graff.NodeCodec) (*graff.DirectedGraph, error) {
+	g := graff.NewDirectedGraph()
+	if err := fromProto(pb, codec, g, g.AddEdge, g.EdgeExists); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// EventGraphToProto converts the event graph to a message like ToProto,
+// with its edges in the orientation they were passed to EventGraph.AddEdge,
+// so that EventGraphFromProto restores the same graph.
+func EventGraphToProto(g *graff.EventGraph, codec graff.NodeCodec) (*Graph, error) {
+	// the event graph's edges are stored reversed
+	return toProto(g.DirectedGraph, codec, g.DirectedGraph.IncomingEdges)
+}
+
+// EventGraphFromProto converts a message created by EventGraphToProto back
+// to an event graph, see FromProto.
+func EventGraphFromProto(pb *Graph, codec graff.NodeCodec) (*graff.EventGraph, error) {
+	g := graff.NewEventGraph()
+	if err := fromProto(pb, codec, g.DirectedGraph, g.AddEdge, g.EdgeExists); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func toProto(g *graff.DirectedGraph, codec graff.NodeCodec, targets func(graff.Node) []graff.Node) (*Graph, error) {
+	nodes := g.Nodes()
+	indices := make(map[graff.Node]uint32, len(nodes))
+	pb := &Graph{
+		Nodes: make([][]byte, len(nodes)),
+		Edges: make([]*Edge, 0, g.EdgeCount()),
+	}
+	for i, node := range nodes {
+		data, err := codec.EncodeNode(node)
+		if err != nil {
+			return nil, err
+		}
+		indices[node] = uint32(i)
+		pb.Nodes[i] = data
+	}
+
+	for _, from := range nodes {
+		for _, to := range targets(from) {
+			pb.Edges = append(pb.Edges, &Edge{FromIndex: indices[from], ToIndex: indices[to]})
+		}
+	}
+	return pb, nil
+}
+
+func fromProto(pb *Graph, codec graff.NodeCodec, g *graff.DirectedGraph, addEdge func(from, to graff.Node), edgeExists func(from, to graff.Node) bool) error {
+	nodes := make([]graff.Node, len(pb.GetNodes()))
+	for i, data := range pb.GetNodes() {
+		node, err := codec.DecodeNode(data)
+		if err != nil {
+			return err
+		}
+		if g.NodeExists(node) {
+			return fmt.Errorf("%w: duplicate node %v", ErrInvalidGraph, node)
+		}
+		g.AddNode(node)
+		nodes[i] = node
+	}
+
+	for _, edge := range pb.GetEdges() {
+		from, to := edge.GetFromIndex(), edge.GetToIndex()
+		for _, index := range []uint32{from, to} {
+			if int(index) >= len(nodes) {
+				return fmt.Errorf("%w: edge references node %d of %d", ErrInvalidGraph, index, len(nodes))
+			}
+		}
+		if edgeExists(nodes[from], nodes[to]) {
+			return fmt.Errorf("%w: duplicate edge %v -> %v", ErrInvalidGraph, nodes[from], nodes[to])
+		}
+		addEdge(nodes[from], nodes[to])
+	}
+	return nil
+}
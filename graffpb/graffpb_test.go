@@ -0,0 +1,111 @@
+package graffpb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	graff "github.com/quan8/cofgra"
+)
+
+func TestRoundTrip(t *testing.T) {
+	g := graff.NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddEdge("c", "b")
+	g.AddNode("d")
+
+	pb, err := ToProto(g, graff.DefaultNodeCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var read Graph
+	if err := proto.Unmarshal(data, &read); err != nil {
+		t.Fatal(err)
+	}
+	h, err := FromProto(&read, graff.DefaultNodeCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nodes := h.Nodes(); len(nodes) != 4 || nodes[0] != "a" || nodes[3] != "d" {
+		t.Errorf("nodes are %v, want a, b, c and d", nodes)
+	}
+	for _, edge := range []graff.Edge{{From: "a", To: "b"}, {From: "a", To: "c"}, {From: "c", To: "b"}} {
+		if !h.EdgeExists(edge.From, edge.To) {
+			t.Errorf("edge %v -> %v is missing", edge.From, edge.To)
+		}
+	}
+	if h.EdgeCount() != 3 {
+		t.Errorf("%d edges, want 3", h.EdgeCount())
+	}
+}
+
+func TestEventGraphRoundTrip(t *testing.T) {
+	g := graff.NewEventGraph()
+	g.AddHappensBefore("boot", "login")
+	g.AddHappensBefore("login", "logout")
+
+	pb, err := EventGraphToProto(g, graff.DefaultNodeCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := EventGraphFromProto(pb, graff.DefaultNodeCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.HappensBefore("boot", "login") || !h.HappensBefore("login", "logout") {
+		t.Error("the happens-before relation was not restored")
+	}
+	if h.HappensBefore("logout", "boot") {
+		t.Error("the edges were reversed")
+	}
+}
+
+// TestWireFormat checks the encoding of a message, which omits fields
+// holding zero.
+func TestWireFormat(t *testing.T) {
+	pb := &Graph{
+		Nodes: [][]byte{[]byte("a"), []byte("b")},
+		Edges: []*Edge{{FromIndex: 0, ToIndex: 1}},
+	}
+	want := []byte{0x0a, 0x01, 'a', 0x0a, 0x01, 'b', 0x12, 0x02, 0x10, 0x01}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("encoded % x, want % x", data, want)
+	}
+	if name := pb.ProtoReflect().Descriptor().FullName(); name != "graff.Graph" {
+		t.Errorf("the message is described as %s, want graff.Graph", name)
+	}
+}
+
+func TestInvalidMessages(t *testing.T) {
+	pb := &Graph{
+		Nodes: [][]byte{encode(t, "a")},
+		Edges: []*Edge{{FromIndex: 0, ToIndex: 5}},
+	}
+	if _, err := FromProto(pb, graff.DefaultNodeCodec); !errors.Is(err, ErrInvalidGraph) {
+		t.Errorf("got %v, want ErrInvalidGraph", err)
+	}
+	var read Graph
+	if err := proto.Unmarshal([]byte{0x0a, 0x05, 'a'}, &read); err == nil {
+		t.Error("a truncated message was decoded")
+	}
+}
+
+func encode(t *testing.T, node graff.Node) []byte {
+	data, err := graff.DefaultNodeCodec.EncodeNode(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -2,18 +2,21 @@ package graff
 
 type graph struct {
 	nodes *nodeList
+	// generation is incremented by every mutation, see modified
+	generation uint64
 }
 
-func newGraph() *graph {
+func newGraph(index *nodeIndex) *graph {
 	return &graph{
-		nodes: newNodeList(),
+		nodes: newNodeList(index),
 	}
 }
 
-// Copy returns a clone of the graph.
-func (g *graph) Copy() *graph {
+// Copy returns a clone of the graph using the index, which must be a copy of
+// the graph's index.
+func (g *graph) Copy(index *nodeIndex) *graph {
 	return &graph{
-		nodes: g.nodes.Copy(),
+		nodes: g.nodes.Copy(index),
 	}
 }
 
@@ -40,6 +43,7 @@ func (g *graph) AddNode(node Node) {
 // Duplicate nodes are ignored.
 func (g *graph) AddNodes(nodes ...Node) {
 	g.nodes.Add(nodes...)
+	g.modified()
 }
 
 // RemoveNode removes the specified nodes from the graph.
@@ -52,9 +56,68 @@ func (g *graph) RemoveNode(node Node) {
 // If a node does not exist within the graph the call will fail silently.
 func (g *graph) RemoveNodes(nodes ...Node) {
 	g.nodes.Remove(nodes...)
+	g.modified()
 }
 
 // NodeExists determines whether the specified node exists within the graph.
 func (g *graph) NodeExists(node Node) bool {
 	return g.nodes.Exists(node)
 }
+
+// HasNode determines whether the specified node exists within the graph.
+// It is equivalent to NodeExists.
+func (g *graph) HasNode(node Node) bool {
+	return g.NodeExists(node)
+}
+
+// InsertionIndex returns the sequence number the node was given when it was
+// added to the graph, and whether it exists. Nodes are numbered from 0 in
+// arrival order, whether added directly or by adding an edge. Numbers are
+// never reused or shifted, so a removed and re-added node gets a new one.
+// Copies keep the numbers, and ReplaceNode passes the number on.
+func (g *graph) InsertionIndex(node Node) (int, bool) {
+	return g.nodes.Sequence(node)
+}
+
+// HasNodes returns the specified nodes which do not exist within the graph,
+// in the order given, so that all of them can be reported at once.
+// The result is empty if all of them exist.
+func (g *graph) HasNodes(nodes ...Node) []Node {
+	missing := make([]Node, 0)
+	for _, node := range nodes {
+		if !g.NodeExists(node) {
+			missing = append(missing, node)
+		}
+	}
+	return missing
+}
+
+// modified records a mutation of the graph.
+func (g *graph) modified() {
+	g.generation++
+}
+
+// modificationGuard detects mutations of a graph since it was created.
+type modificationGuard struct {
+	graph      *graph
+	generation uint64
+	// view is a graph other than a DirectedGraph which may fail
+	view failer
+}
+
+// guard returns a modificationGuard for the graph's current state.
+func (g *graph) guard() modificationGuard {
+	return modificationGuard{graph: g, generation: g.generation}
+}
+
+// check returns ErrConcurrentModification if the graph was mutated since
+// the guard was created, or the error reported by the view.
+func (m modificationGuard) check() error {
+	if m.graph != nil && m.graph.generation != m.generation {
+		return ErrConcurrentModification
+	}
+	if m.view != nil {
+		return m.view.Err()
+	}
+	return nil
+}
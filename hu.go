@@ -0,0 +1,44 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to Hu's algorithm.
+var (
+	ErrNotTree = errors.New("The graph is not an in-tree or forest")
+)
+
+// HuSchedule schedules the graph's nodes as unit-time tasks onto width
+// processors using Hu's algorithm, returning one layer per time step.
+// The schedule is optimal for in-trees, i.e. graphs in which (after removing
+// transitive edges) every node has at most one outgoing edge. For other
+// graphs an error wrapping ErrNotTree is returned, so that callers can fall
+// back to CoffmanGrahamSort.
+// See https://en.wikipedia.org/wiki/Hu%27s_algorithm
+func (g *DirectedGraph) HuSchedule(width int) ([][]Node, error) {
+	if width <= 0 {
+		return nil, fmt.Errorf("%w: width must be positive, got %d", ErrInvalidOption, width)
+	}
+
+	reduced := g.Copy()
+	if err := reduced.RemoveTransitives(); err != nil {
+		return nil, err
+	}
+	for _, node := range reduced.Nodes() {
+		if reduced.OutgoingEdgeCount(node) > 1 {
+			return nil, fmt.Errorf("%w: %v has %d successors", ErrNotTree, node, reduced.OutgoingEdgeCount(node))
+		}
+	}
+
+	// the priority of a node is its distance to the root of its tree
+	distances, err := reduced.NodeHeights()
+	if err != nil {
+		return nil, err
+	}
+
+	return listSchedule(reduced, width, func(a, b Node) bool {
+		return distances[a] > distances[b]
+	})
+}
@@ -0,0 +1,61 @@
+package graff
+
+import (
+	"errors"
+	"testing"
+)
+
+// huTree returns an in-tree whose root r has four leaves and a chain of
+// three nodes below it, inserting the leaves first so that scheduling in
+// insertion order delays the chain.
+func huTree() *DirectedGraph {
+	g := NewDirectedGraph()
+	for _, leaf := range []Node{"l1", "l2", "l3", "l4"} {
+		g.AddEdge(leaf, "r")
+	}
+	g.AddEdge("c1", "c2")
+	g.AddEdge("c2", "c3")
+	g.AddEdge("c3", "r")
+	return g
+}
+
+func TestHuSchedule(t *testing.T) {
+	g := huTree()
+	// the chain and the root take four steps, during which the chain
+	// leaves a slot free for only three of the four leaves
+	for width, want := range map[int]int{1: 8, 2: 5, 3: 4, 5: 4} {
+		layers, err := g.HuSchedule(width)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateLayering(g, layers, width); err != nil {
+			t.Errorf("width %d: %v", width, err)
+		}
+		if len(layers) != want {
+			t.Errorf("width %d: scheduled in %d steps %v, want %d", width, len(layers), layers, want)
+		}
+	}
+}
+
+func TestHuScheduleErrors(t *testing.T) {
+	g := huTree()
+	if _, err := g.HuSchedule(0); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v for width 0, want ErrInvalidOption", err)
+	}
+
+	// the transitive edge does not make c1 a node with two successors
+	g.AddEdge("c1", "c3")
+	if _, err := g.HuSchedule(2); err != nil {
+		t.Errorf("got %v for a transitive edge", err)
+	}
+
+	g.AddEdge("c1", "l1")
+	if _, err := g.HuSchedule(2); !errors.Is(err, ErrNotTree) {
+		t.Errorf("got %v, want ErrNotTree", err)
+	}
+
+	g.AddEdge("r", "c1")
+	if _, err := g.HuSchedule(2); !errors.Is(err, ErrCyclicGraph) {
+		t.Errorf("got %v, want ErrCyclicGraph", err)
+	}
+}
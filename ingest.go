@@ -0,0 +1,99 @@
+package graff
+
+import (
+	"context"
+)
+
+// DefaultStreamBatchSize is the number of edges buffered before they are
+// added to the graph when no batch size is given.
+const DefaultStreamBatchSize = 1024
+
+// StreamOption configures the construction of a graph from a stream of edges.
+type StreamOption func(*streamConfig)
+
+type streamConfig struct {
+	batchSize       int
+	checkpointEvery int
+	checkpoint      func(nodes int, edges int)
+}
+
+// WithStreamBatchSize adds the received edges to the graph in batches of
+// the specified size, see AddEdges. Sizes below one are ignored.
+func WithStreamBatchSize(size int) StreamOption {
+	return func(c *streamConfig) {
+		if size > 0 {
+			c.batchSize = size
+		}
+	}
+}
+
+// WithCheckpoint calls fn with the graph's node and edge counts every time
+// at least every further edges were received and added, e.g. to report
+// progress or commit consumer offsets, and once more when the stream ends.
+func WithCheckpoint(every int, fn func(nodes int, edges int)) StreamOption {
+	return func(c *streamConfig) {
+		c.checkpointEvery = every
+		c.checkpoint = fn
+	}
+}
+
+// NewDirectedGraphFromStream builds a graph from the edges received over the
+// channel until it is closed, adding them in batches so that only a batch
+// of edges is buffered at a time. If the context is cancelled first, its
+// error is returned instead of the graph.
+func NewDirectedGraphFromStream(ctx context.Context, edges <-chan Edge, opts ...StreamOption) (*DirectedGraph, error) {
+	g := NewDirectedGraph()
+	if err := g.consume(ctx, edges, g.AddEdges, opts); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// NewEventGraphFromStream builds an event graph from the edges received over
+// the channel, see NewDirectedGraphFromStream.
+func NewEventGraphFromStream(ctx context.Context, edges <-chan Edge, opts ...StreamOption) (*EventGraph, error) {
+	g := NewEventGraph()
+	if err := g.consume(ctx, edges, g.AddEdges, opts); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// consume adds the edges received over the channel using add in batches.
+func (g *DirectedGraph) consume(ctx context.Context, edges <-chan Edge, add func(edges ...Edge), opts []StreamOption) error {
+	config := &streamConfig{batchSize: DefaultStreamBatchSize}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	batch := make([]Edge, 0, config.batchSize)
+	received, checkpointed := 0, 0
+	flush := func() {
+		add(batch...)
+		batch = batch[:0]
+		if config.checkpoint != nil && received-checkpointed >= config.checkpointEvery {
+			config.checkpoint(g.NodeCount(), g.EdgeCount())
+			checkpointed = received
+		}
+	}
+
+	for {
+		select {
+		case edge, ok := <-edges:
+			if !ok {
+				flush()
+				if config.checkpoint != nil && checkpointed != received {
+					config.checkpoint(g.NodeCount(), g.EdgeCount())
+				}
+				return nil
+			}
+			batch = append(batch, edge)
+			received++
+			if len(batch) == config.batchSize {
+				flush()
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package graff
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// streamEdges sends a chain of n edges over the returned channel, closing it
+// once they are all sent.
+func streamEdges(n int) <-chan Edge {
+	edges := make(chan Edge)
+	go func() {
+		defer close(edges)
+		for i := 0; i < n; i++ {
+			edges <- Edge{i, i + 1}
+		}
+	}()
+	return edges
+}
+
+func TestNewDirectedGraphFromStream(t *testing.T) {
+	var checkpoints []int
+	g, err := NewDirectedGraphFromStream(context.Background(), streamEdges(100000),
+		WithStreamBatchSize(1000),
+		WithCheckpoint(30000, func(nodes int, edges int) {
+			checkpoints = append(checkpoints, edges)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NodeCount() != 100001 || g.EdgeCount() != 100000 {
+		t.Errorf("got %d nodes and %d edges, want 100001 and 100000", g.NodeCount(), g.EdgeCount())
+	}
+	// the checkpoints follow the first batch reaching the interval, and the
+	// end of the stream
+	if want := []int{30000, 60000, 90000, 100000}; !reflect.DeepEqual(checkpoints, want) {
+		t.Errorf("checkpointed after %v edges, want %v", checkpoints, want)
+	}
+}
+
+func TestNewEventGraphFromStream(t *testing.T) {
+	g, err := NewEventGraphFromStream(context.Background(), streamEdges(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every edge points from an event to the one it depends on
+	if !g.EdgeExists(3, 4) || !g.HappensBefore(4, 3) {
+		t.Error("the streamed edges were not added in the orientation of AddEdge")
+	}
+}
+
+func TestGraphFromStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	edges := make(chan Edge)
+	go func() {
+		// cancel midway without closing the channel
+		for i := 0; i < 50000; i++ {
+			edges <- Edge{i, i + 1}
+		}
+		cancel()
+	}()
+	g, err := NewDirectedGraphFromStream(ctx, edges)
+	if !errors.Is(err, context.Canceled) || g != nil {
+		t.Errorf("got %v, %v, want context.Canceled", g, err)
+	}
+}
@@ -0,0 +1,111 @@
+package graff
+
+import (
+	"fmt"
+)
+
+// IntersectionOptions configures IntersectionWithOptions.
+type IntersectionOptions struct {
+	// Key matches the nodes of the two graphs by the value it returns for
+	// them rather than by equality, e.g. when they are of different types.
+	Key func(Node) interface{}
+	// Combine computes the value of the attributes set on a node or edge in
+	// both graphs from their two values. Without it attributes are dropped.
+	Combine func(key string, a interface{}, b interface{}) interface{}
+}
+
+// Intersection returns a graph of the nodes and edges present in both
+// graphs, with the multiplicity of an edge being the smaller of its two
+// multiplicities. Nodes and edges are in the order of the first graph, and
+// attributes are dropped, see IntersectionWithOptions.
+func Intersection(a *DirectedGraph, b *DirectedGraph) *DirectedGraph {
+	// without a key function the nodes cannot collide
+	result, _ := IntersectionWithOptions(a, b, IntersectionOptions{})
+	return result
+}
+
+// IntersectionWithOptions returns the intersection of the graphs like
+// Intersection, configured by the specified options. The result holds the
+// first graph's nodes. Only attributes set in both graphs are carried over,
+// combined by the Combine function.
+// If two nodes of the same graph have the same key an error wrapping
+// ErrDuplicateNode is returned.
+func IntersectionWithOptions(a *DirectedGraph, b *DirectedGraph, opts IntersectionOptions) (*DirectedGraph, error) {
+	key := opts.Key
+	if key == nil {
+		key = func(node Node) interface{} { return node }
+	}
+
+	// matches maps the nodes of the first graph to those of the second
+	matches := make(map[Node]Node)
+	keys := make(map[interface{}]Node, b.NodeCount())
+	for _, node := range b.Nodes() {
+		k := key(node)
+		if other, ok := keys[k]; ok {
+			return nil, fmt.Errorf("%w: %v and %v both have the key %v", ErrDuplicateNode, other, node, k)
+		}
+		keys[k] = node
+	}
+	owners := make(map[interface{}]Node, a.NodeCount())
+	for _, node := range a.Nodes() {
+		k := key(node)
+		if other, ok := owners[k]; ok {
+			return nil, fmt.Errorf("%w: %v and %v both have the key %v", ErrDuplicateNode, other, node, k)
+		}
+		owners[k] = node
+		if match, ok := keys[k]; ok {
+			matches[node] = match
+		}
+	}
+
+	result := NewDirectedGraph()
+	for _, node := range a.Nodes() {
+		match, ok := matches[node]
+		if !ok {
+			continue
+		}
+		result.AddNode(node)
+		if opts.Combine != nil {
+			combineAttrs(a.NodeAttrs(node), b.NodeAttrs(match), opts.Combine, func(k string, value interface{}) {
+				result.nodeAttrs.Set(node, k, value)
+			})
+		}
+	}
+	for _, from := range a.Nodes() {
+		matchFrom, ok := matches[from]
+		if !ok {
+			continue
+		}
+		a.EachOutgoing(from, func(to Node) bool {
+			matchTo, ok := matches[to]
+			if !ok || !b.EdgeExists(matchFrom, matchTo) {
+				return true
+			}
+			result.AddEdge(from, to)
+			multiplicity := a.EdgeMultiplicity(from, to)
+			if m := b.EdgeMultiplicity(matchFrom, matchTo); m < multiplicity {
+				multiplicity = m
+			}
+			for i := 1; i < multiplicity; i++ {
+				result.AddEdgeCounted(from, to)
+			}
+			if opts.Combine != nil {
+				combineAttrs(a.EdgeAttrs(from, to), b.EdgeAttrs(matchFrom, matchTo), opts.Combine, func(k string, value interface{}) {
+					result.edgeAttrs.Set(Edge{from, to}, k, value)
+				})
+			}
+			return true
+		})
+	}
+	return result, nil
+}
+
+// combineAttrs calls set with the combined value of every attribute present
+// in both sets.
+func combineAttrs(a map[string]interface{}, b map[string]interface{}, combine func(key string, a interface{}, b interface{}) interface{}, set func(key string, value interface{})) {
+	for key, value := range a {
+		if other, ok := b[key]; ok {
+			set(key, combine(key, value, other))
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestIntersectionWithCopy(t *testing.T) {
+	g := layeredGraph(50, 3)
+	g.AddEdgeCounted(0, 49)
+	g.AddEdgeCounted(0, 49)
+	got := Intersection(g, g.Copy())
+	if !reflect.DeepEqual(got.Nodes(), g.Nodes()) || !reflect.DeepEqual(got.AdjacencyMatrix(), g.AdjacencyMatrix()) {
+		t.Error("the intersection with a copy differs from the graph")
+	}
+	if m := got.EdgeMultiplicity(0, 49); m != g.EdgeMultiplicity(0, 49) {
+		t.Errorf("got the multiplicity %d, want %d", m, g.EdgeMultiplicity(0, 49))
+	}
+}
+
+func TestIntersectionWithOptions(t *testing.T) {
+	// the revisions name their steps differently and share b -> c only
+	a := NewDirectedGraph()
+	a.AddEdge(1, 2)
+	a.AddEdge(2, 3)
+	a.SetNodeAttr(2, "cost", 5)
+	a.SetNodeAttr(3, "cost", 1)
+	b := NewDirectedGraph()
+	b.AddEdge("3", "4")
+	b.AddEdge("2", "3")
+	b.SetNodeAttr("2", "cost", 7)
+
+	got, err := IntersectionWithOptions(a, b, IntersectionOptions{
+		Key: func(node Node) interface{} { return fmt.Sprint(node) },
+		Combine: func(key string, x interface{}, y interface{}) interface{} {
+			return x.(int) + y.(int)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Nodes(), []Node{2, 3}) || !got.EdgeExists(2, 3) || got.EdgeCount() != 1 {
+		t.Errorf("got the nodes %v and edges %v", got.Nodes(), got.AdjacencyMatrix())
+	}
+	if cost, _ := got.NodeAttr(2, "cost"); cost != 12 {
+		t.Errorf("got the combined cost %v, want 12", cost)
+	}
+	if _, ok := got.NodeAttr(3, "cost"); ok {
+		t.Error("an attribute set in one graph only was carried over")
+	}
+
+	b.AddNode(2)
+	if _, err := IntersectionWithOptions(a, b, IntersectionOptions{Key: func(node Node) interface{} { return fmt.Sprint(node) }}); !errors.Is(err, ErrDuplicateNode) {
+		t.Errorf("got %v for colliding keys, want ErrDuplicateNode", err)
+	}
+}
@@ -0,0 +1,97 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// Errors relating to causal intervals.
+var (
+	ErrNotCausallyOrdered = errors.New("The events are not causally ordered")
+)
+
+// CausalInterval returns the events on some causal path from lo to hi, i.e.
+// the events happening after lo and before hi, together with lo and hi, in a
+// causal order starting with lo and ending with hi. Concurrent events are in
+// insertion order. This explains why hi required lo.
+// If the graph's ReachabilityIndex is fresh the events are found by testing
+// the descendants of lo in its bit sets, otherwise by searching the ancestors
+// of hi among the descendants of lo.
+// An error wrapping ErrNotCausallyOrdered is returned unless lo happens
+// before hi, or one wrapping ErrNodeNotFound if either does not exist.
+func (g *EventGraph) CausalInterval(lo Node, hi Node) ([]Node, error) {
+	if err := g.checkNodes([]Node{lo, hi}); err != nil {
+		return nil, err
+	}
+	if !g.HappensBefore(lo, hi) {
+		return nil, fmt.Errorf("%w: %v does not happen before %v", ErrNotCausallyOrdered, lo, hi)
+	}
+
+	var interval map[Node]bool
+	if x := g.freshReachability(); x != nil {
+		interval = x.between(lo, hi)
+	} else {
+		interval = g.between(lo, hi)
+	}
+
+	// order the events by sorting the subgraph they induce
+	nodes := make([]Node, 0, len(interval))
+	for node := range interval {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		a, _ := g.InsertionIndex(nodes[i])
+		b, _ := g.InsertionIndex(nodes[j])
+		return a < b
+	})
+	sub := NewDirectedGraph()
+	sub.AddNodes(nodes...)
+	for _, from := range nodes {
+		g.EachOutgoing(from, func(to Node) bool {
+			if interval[to] {
+				sub.AddEdge(from, to)
+			}
+			return true
+		})
+	}
+	return sub.LexTopoSort(nil)
+}
+
+// between returns the nodes on some path from one node to the other,
+// including both, by searching the ancestors of the latter among the
+// descendants of the former.
+func (g *DirectedGraph) between(from Node, to Node) map[Node]bool {
+	descendants := g.descendants([]Node{from})
+	between := map[Node]bool{to: true}
+	queue := []Node{to}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		g.EachIncoming(node, func(incoming Node) bool {
+			if descendants[incoming] && !between[incoming] {
+				between[incoming] = true
+				queue = append(queue, incoming)
+			}
+			return true
+		})
+	}
+	return between
+}
+
+// between returns the nodes on some path from one node to the other,
+// including both, as the descendants of the former reaching the latter.
+func (x *ReachabilityIndex) between(from Node, to Node) map[Node]bool {
+	i, j := x.ids[from], x.ids[to]
+	between := map[Node]bool{from: true, to: true}
+	for w, word := range x.reach[i] {
+		for ; word != 0; word &= word - 1 {
+			k := w*64 + bits.TrailingZeros64(word)
+			if x.reach[k][j/64]&(1<<uint(j%64)) != 0 {
+				between[x.nodes[k]] = true
+			}
+		}
+	}
+	return between
+}
@@ -0,0 +1,51 @@
+package graff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCausalIntervalDiamond(t *testing.T) {
+	// two diamonds in a row, a -> {b, c} -> d -> {e, f} -> g, with x
+	// concurrent to all of them and y following b only
+	g := NewEventGraph()
+	for _, pair := range [][2]Node{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"d", "e"}, {"d", "f"}, {"e", "g"}, {"f", "g"}, {"b", "y"}} {
+		g.AddHappensBefore(pair[0], pair[1])
+	}
+	g.AddNode("x")
+
+	tests := []struct {
+		lo, hi Node
+		want   []Node
+	}{
+		{"a", "d", []Node{"a", "b", "c", "d"}},
+		{"a", "g", []Node{"a", "b", "c", "d", "e", "f", "g"}},
+		{"b", "e", []Node{"b", "d", "e"}},
+		{"a", "y", []Node{"a", "b", "y"}},
+	}
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			if _, err := g.BuildReachabilityIndex(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, test := range tests {
+			got, err := g.CausalInterval(test.lo, test.hi)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("indexed %v: the interval from %v to %v is %v, want %v", indexed, test.lo, test.hi, got, test.want)
+			}
+		}
+		for _, pair := range [][2]Node{{"d", "a"}, {"b", "c"}, {"x", "g"}, {"a", "a"}} {
+			if _, err := g.CausalInterval(pair[0], pair[1]); !errors.Is(err, ErrNotCausallyOrdered) {
+				t.Errorf("indexed %v: the interval from %v to %v returned %v", indexed, pair[0], pair[1], err)
+			}
+		}
+	}
+	if _, err := g.CausalInterval("a", "missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("an interval to a missing event returned %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package graff
+
+// InvalidationOrder returns the order in which to rebuild the dirty nodes and
+// everything downstream of them, i.e. the nodes reachable from the dirty
+// ones, including themselves, in topological order like DFSSortFrom.
+// Every other node is skipped. It also returns the causes of every node
+// returned, i.e. the dirty nodes it is reachable from, including itself if
+// it is dirty, in the order the dirty nodes were given, to explain why the
+// node needs rebuilding.
+// An error wrapping ErrNodeNotFound is returned for an unknown dirty node,
+// and a CyclicGraphError if a cycle is reachable from the dirty nodes.
+func (g *DirectedGraph) InvalidationOrder(dirty ...Node) ([]Node, map[Node][]Node, error) {
+	order, err := g.DFSSortFrom(dirty...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	causes := make(map[Node][]Node, len(order))
+	seen := make(map[Node]bool, len(dirty))
+	for _, root := range dirty {
+		if seen[root] {
+			continue
+		}
+		seen[root] = true
+		for node := range g.descendants([]Node{root}) {
+			causes[node] = append(causes[node], root)
+		}
+	}
+	return order, causes, nil
+}
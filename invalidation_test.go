@@ -0,0 +1,62 @@
+package graff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInvalidationOrderOverlappingCones(t *testing.T) {
+	// the cones of the dirty nodes lib and gen overlap from app downwards
+	g := NewDirectedGraph()
+	for _, edge := range []Edge{
+		{"base", "lib"}, {"base", "gen"}, {"lib", "app"}, {"gen", "app"},
+		{"gen", "docs"}, {"app", "image"}, {"lib", "tests"}, {"other", "image"},
+	} {
+		g.AddEdge(edge.From, edge.To)
+	}
+
+	order, causes, err := g.InvalidationOrder("lib", "gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rank := make(map[Node]int, len(order))
+	for i, node := range order {
+		rank[node] = i
+	}
+	if len(order) != 6 {
+		t.Errorf("the order is %v", order)
+	}
+	for _, node := range []Node{"base", "other"} {
+		if _, ok := rank[node]; ok {
+			t.Errorf("%v is rebuilt although it is upstream", node)
+		}
+	}
+	for _, from := range order {
+		for _, to := range g.OutgoingEdges(from) {
+			if r, ok := rank[to]; !ok || r < rank[from] {
+				t.Errorf("%v is not rebuilt after %v", to, from)
+			}
+		}
+	}
+
+	want := map[Node][]Node{
+		"lib":   {"lib"},
+		"gen":   {"gen"},
+		"app":   {"lib", "gen"},
+		"image": {"lib", "gen"},
+		"tests": {"lib"},
+		"docs":  {"gen"},
+	}
+	if !reflect.DeepEqual(causes, want) {
+		t.Errorf("the causes are %v, want %v", causes, want)
+	}
+
+	if _, _, err := g.InvalidationOrder("missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("an unknown dirty node returned %v", err)
+	}
+	g.AddEdge("image", "gen")
+	if _, _, err := g.InvalidationOrder("lib"); !errors.Is(err, ErrCyclicGraph) {
+		t.Errorf("a reachable cycle returned %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to invariant checks.
+var (
+	ErrInconsistentIndex = errors.New("The reachability index disagrees with the graph")
+)
+
+// InvariantOption adds a check to Invariants.
+type InvariantOption func(*invariantConfig)
+
+type invariantConfig struct {
+	layers [][]Node
+	width  int
+	order  []Node
+}
+
+// WithLayering makes Invariants verify that the layers are a valid layering
+// of the graph within the width, see ValidateLayering.
+func WithLayering(layers [][]Node, width int) InvariantOption {
+	return func(c *invariantConfig) {
+		c.layers = layers
+		c.width = width
+	}
+}
+
+// WithOrder makes Invariants verify that the order is a linear extension of
+// the graph, see IsLinearExtension.
+func WithOrder(order []Node) InvariantOption {
+	return func(c *invariantConfig) {
+		c.order = order
+	}
+}
+
+// Invariants verifies in one call everything this package can verify about
+// the graph, returning the first violation found, so that it can be called
+// from fuzz targets:
+//   - the graph's internal invariants, see Validate, or for graphs other
+//     than a DirectedGraph that every node is listed once and the edge
+//     methods agree with each other, otherwise an error wrapping
+//     ErrInvalidGraph is returned,
+//   - the ReachabilityIndex last built by BuildReachabilityIndex, unless it
+//     is stale, agrees with the graph, otherwise an error wrapping
+//     ErrInconsistentIndex is returned,
+//   - the layering given by WithLayering is valid,
+//   - the order given by WithOrder is a linear extension, otherwise its
+//     OrderViolation is returned.
+//
+// For example, a fuzz target for the Coffman-Graham sorter, building a DAG
+// from pairs of bytes and shrinking failing graphs before reporting them:
+//
+//	func FuzzSorter(f *testing.F) {
+//		f.Fuzz(func(t *testing.T, data []byte) {
+//			g := graff.NewDirectedGraph()
+//			for i := 0; i+1 < len(data); i += 2 {
+//				if from, to := data[i]%32, data[i+1]%32; from < to {
+//					g.AddEdge(int(from), int(to))
+//				}
+//			}
+//			check := func(g *graff.DirectedGraph) error {
+//				layers, err := graff.NewCoffmanGrahamSorter(g, 3).Sort()
+//				if err != nil {
+//					return err
+//				}
+//				return graff.Invariants(g, graff.WithLayering(layers, 3))
+//			}
+//			if err := check(g); err != nil {
+//				shrunk := graff.Shrink(g, func(g *graff.DirectedGraph) bool {
+//					return check(g) != nil
+//				})
+//				var dot strings.Builder
+//				shrunk.WriteDOT(&dot)
+//				t.Fatalf("%v\n%s", err, dot.String())
+//			}
+//		})
+//	}
+func Invariants(g Directed, opts ...InvariantOption) error {
+	config := &invariantConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	given := g
+	g = directedView(g)
+	if dg, ok := g.(*DirectedGraph); ok {
+		if err := dg.Validate(); err != nil {
+			return err
+		}
+		if x := dg.freshReachability(); x != nil {
+			if err := x.agrees(dg); err != nil {
+				return err
+			}
+		}
+	} else if err := validateView(g); err != nil {
+		return err
+	}
+	if config.layers != nil {
+		if err := ValidateLayering(g, config.layers, config.width); err != nil {
+			return err
+		}
+	}
+	if config.order != nil {
+		ok, violation := isLinearExtension(g, config.order)
+		if e, isEvent := given.(*EventGraph); isEvent {
+			ok, violation = e.IsLinearExtension(config.order)
+		}
+		if !ok {
+			return violation
+		}
+	}
+	return nil
+}
+
+// agrees verifies that the index holds the graph's nodes and reachability by
+// comparing it to a rebuilt one.
+func (x *ReachabilityIndex) agrees(g *DirectedGraph) error {
+	fresh, err := NewReachabilityIndex(g)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInconsistentIndex, err)
+	}
+	if len(x.ids) != len(fresh.ids) {
+		return fmt.Errorf("%w: it holds %d nodes rather than %d", ErrInconsistentIndex, len(x.ids), len(fresh.ids))
+	}
+	for _, from := range fresh.nodes {
+		if _, ok := x.ids[from]; !ok {
+			return fmt.Errorf("%w: %v is missing", ErrInconsistentIndex, from)
+		}
+	}
+	for _, from := range fresh.nodes {
+		for _, to := range fresh.nodes {
+			want, _ := fresh.Reaches(from, to)
+			got, _ := x.Reaches(from, to)
+			if got != want {
+				return fmt.Errorf("%w: it reports that %v reaches %v is %v", ErrInconsistentIndex, from, to, got)
+			}
+		}
+	}
+	return nil
+}
+
+// Shrink minimizes a graph on which a check fails to help reproduce the
+// failure: it returns a copy of the graph from which as many nodes and edges
+// as possible were removed while failing still returns true. Nodes are
+// removed before edges, in halves, quarters and so on down to single ones,
+// and passes are repeated until nothing more can be removed, so the result
+// is minimal in that removing any single node or edge makes the check pass.
+// The check is given copies, which it may modify, and the graph itself is
+// not modified. If the check does not fail on the graph, an unchanged copy
+// is returned.
+func Shrink(g *DirectedGraph, failing func(*DirectedGraph) bool) *DirectedGraph {
+	shrunk := g.Copy()
+	if !failing(shrunk.Copy()) {
+		return shrunk
+	}
+
+	for {
+		nodes := shrunk.Nodes()
+		var removedNodes, removedEdges bool
+		shrunk, removedNodes = shrinkChunks(shrunk, len(nodes), failing, func(h *DirectedGraph, lo int, hi int) {
+			h.RemoveNodes(nodes[lo:hi]...)
+		}, func(lo int, hi int) {
+			nodes = append(nodes[:lo:lo], nodes[hi:]...)
+		})
+
+		edges := make([]Edge, 0, shrunk.EdgeCount())
+		for _, from := range shrunk.Nodes() {
+			for _, to := range shrunk.OutgoingEdges(from) {
+				edges = append(edges, Edge{from, to})
+			}
+		}
+		shrunk, removedEdges = shrinkChunks(shrunk, len(edges), failing, func(h *DirectedGraph, lo int, hi int) {
+			for _, edge := range edges[lo:hi] {
+				h.deleteEdge(edge.From, edge.To)
+			}
+		}, func(lo int, hi int) {
+			edges = append(edges[:lo:lo], edges[hi:]...)
+		})
+
+		if !removedNodes && !removedEdges {
+			return shrunk
+		}
+	}
+}
+
+// shrinkChunks removes chunks of the n items from the graph, halving their
+// size down to one item, for as long as the check keeps failing. remove
+// removes the items from lo to hi from a copy of the graph, and drop drops
+// them from the items once the removal is kept. It returns the shrunk graph
+// and whether any item was removed.
+func shrinkChunks(g *DirectedGraph, n int, failing func(*DirectedGraph) bool, remove func(h *DirectedGraph, lo int, hi int), drop func(lo int, hi int)) (*DirectedGraph, bool) {
+	removed := false
+	size := n / 2
+	if size == 0 {
+		size = n
+	}
+	for ; size >= 1; size /= 2 {
+		for lo := 0; lo < n; {
+			hi := lo + size
+			if hi > n {
+				hi = n
+			}
+			candidate := g.Copy()
+			remove(candidate, lo, hi)
+			if failing(candidate.Copy()) {
+				g = candidate
+				drop(lo, hi)
+				n -= hi - lo
+				removed = true
+				continue
+			}
+			lo = hi
+		}
+	}
+	return g, removed
+}
@@ -0,0 +1,81 @@
+package graff
+
+import (
+	"strings"
+	"testing"
+)
+
+// fuzzGraph builds a DAG of up to 32 nodes from pairs of bytes, each adding
+// an edge from the lower to the higher node.
+func fuzzGraph(data []byte) *DirectedGraph {
+	g := NewDirectedGraph()
+	for i := 0; i+1 < len(data); i += 2 {
+		if from, to := data[i]%32, data[i+1]%32; from < to {
+			g.AddEdge(int(from), int(to))
+		}
+	}
+	return g
+}
+
+func FuzzSorter(f *testing.F) {
+	f.Add([]byte{0, 1, 1, 2, 0, 2})
+	f.Add([]byte{0, 5, 1, 5, 2, 5, 3, 5, 5, 6, 4, 6})
+	f.Add([]byte{3, 9, 9, 12, 3, 12, 1, 30, 2, 30, 30, 31})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		check := func(g *DirectedGraph) error {
+			order, err := g.DFSSort()
+			if err != nil {
+				return err
+			}
+			layers, err := NewCoffmanGrahamSorter(g, 3).Sort()
+			if err != nil {
+				return err
+			}
+			return Invariants(g, WithOrder(order), WithLayering(layers, 3))
+		}
+		g := fuzzGraph(data)
+		if err := check(g); err != nil {
+			shrunk := Shrink(g, func(g *DirectedGraph) bool {
+				return check(g) != nil
+			})
+			var dot strings.Builder
+			shrunk.WriteDOT(&dot)
+			t.Fatalf("%v\n%s", err, dot.String())
+		}
+	})
+}
+
+func TestInvariantsCatchesBadOrder(t *testing.T) {
+	g := fuzzGraph([]byte{0, 1, 1, 2})
+	if err := Invariants(g, WithOrder([]Node{1, 0, 2})); err == nil {
+		t.Error("the reversed edge went unnoticed")
+	}
+	if err := Invariants(g, WithLayering([][]Node{{0}, {1, 2}}, 2)); err == nil {
+		t.Error("the edge within a layer went unnoticed")
+	}
+	if err := Invariants(g, WithOrder([]Node{0, 1, 2}), WithLayering([][]Node{{0}, {1}, {2}}, 1)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestShrink(t *testing.T) {
+	g := layeredGraph(40, 3)
+	// fails while 5 still reaches 30
+	failing := func(h *DirectedGraph) bool {
+		ok, _ := h.IsAncestor(5, 30)
+		return ok
+	}
+	if !failing(g) {
+		t.Fatal("the fixture does not fail")
+	}
+	shrunk := Shrink(g, failing)
+	if !failing(shrunk) {
+		t.Fatal("the shrunk graph passes")
+	}
+	if shrunk.NodeCount() != shrunk.EdgeCount()+1 {
+		t.Errorf("the shrunk graph is not a single path: %d nodes, %d edges", shrunk.NodeCount(), shrunk.EdgeCount())
+	}
+	if g.NodeCount() != 40 {
+		t.Error("the graph was modified")
+	}
+}
@@ -0,0 +1,177 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to the isomorphism check.
+var (
+	ErrTooLarge = errors.New("The graph is too large")
+)
+
+// DefaultIsomorphismLimit is the maximum number of nodes AreIsomorphic
+// accepts unless WithIsomorphismLimit is used.
+const DefaultIsomorphismLimit = 1000
+
+// IsomorphismOption configures AreIsomorphic.
+type IsomorphismOption func(*isomorphismConfig)
+
+type isomorphismConfig struct {
+	limit int
+}
+
+// WithIsomorphismLimit sets the maximum number of nodes AreIsomorphic accepts.
+// A limit of zero or less means no limit.
+func WithIsomorphismLimit(limit int) IsomorphismOption {
+	return func(c *isomorphismConfig) {
+		c.limit = limit
+	}
+}
+
+// nodeSignature holds the properties of a node which an isomorphism preserves.
+type nodeSignature struct {
+	indegree, outdegree int
+	depth, height       int
+}
+
+// AreIsomorphic determines whether the two DAGs have the same structure, i.e.
+// whether a bijection of their nodes exists which maps every edge of one graph
+// to an edge of the other, returning such a mapping from the nodes of a to the
+// nodes of b. Attributes and edge multiplicities are ignored.
+// Nodes are only matched with nodes of the same degrees, depth and height,
+// and the remaining candidates are searched by backtracking, which takes
+// exponential time in the worst case. Graphs with more nodes than the limit
+// (see WithIsomorphismLimit) are rejected with an error wrapping ErrTooLarge.
+// A CyclicGraphError is returned if either graph contains a cycle.
+func AreIsomorphic(a *DirectedGraph, b *DirectedGraph, opts ...IsomorphismOption) (bool, map[Node]Node, error) {
+	config := &isomorphismConfig{limit: DefaultIsomorphismLimit}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	for _, g := range []*DirectedGraph{a, b} {
+		if config.limit > 0 && g.NodeCount() > config.limit {
+			return false, nil, fmt.Errorf("%w: %d nodes exceed the limit of %d", ErrTooLarge, g.NodeCount(), config.limit)
+		}
+	}
+
+	signaturesA, err := a.signatures()
+	if err != nil {
+		return false, nil, err
+	}
+	signaturesB, err := b.signatures()
+	if err != nil {
+		return false, nil, err
+	}
+	if a.NodeCount() != b.NodeCount() || a.EdgeCount() != b.EdgeCount() {
+		return false, nil, nil
+	}
+
+	counts := make(map[nodeSignature]int)
+	for _, signature := range signaturesA {
+		counts[signature]++
+	}
+	for _, signature := range signaturesB {
+		counts[signature]--
+	}
+	for _, count := range counts {
+		if count != 0 {
+			return false, nil, nil
+		}
+	}
+
+	// map the nodes in topological order, so that the predecessors of a node
+	// are mapped before it and narrow down its candidates
+	nodes, err := a.LexTopoSort(nil)
+	if err != nil {
+		return false, nil, err
+	}
+	bySignature := make(map[nodeSignature][]Node)
+	for _, node := range b.Nodes() {
+		signature := signaturesB[node]
+		bySignature[signature] = append(bySignature[signature], node)
+	}
+
+	mapping := make(map[Node]Node, len(nodes))
+	used := make(map[Node]bool, len(nodes))
+
+	// consistent determines whether mapping node to candidate preserves the
+	// edges between node and the nodes mapped so far
+	consistent := func(node Node, candidate Node) bool {
+		ok := true
+		a.EachIncoming(node, func(from Node) bool {
+			if mapped, found := mapping[from]; found && !b.EdgeExists(mapped, candidate) {
+				ok = false
+			}
+			return ok
+		})
+		if !ok {
+			return false
+		}
+		a.EachOutgoing(node, func(to Node) bool {
+			if mapped, found := mapping[to]; found && !b.EdgeExists(candidate, mapped) {
+				ok = false
+			}
+			return ok
+		})
+		return ok
+	}
+
+	var match func(i int) bool
+	match = func(i int) bool {
+		if i == len(nodes) {
+			return true
+		}
+		node := nodes[i]
+		signature := signaturesA[node]
+
+		candidates := bySignature[signature]
+		if predecessors := a.IncomingEdges(node); len(predecessors) > 0 {
+			// a mapped predecessor limits the candidates to its successors
+			candidates = b.OutgoingEdges(mapping[predecessors[0]])
+		}
+
+		for _, candidate := range candidates {
+			if used[candidate] || signaturesB[candidate] != signature || !consistent(node, candidate) {
+				continue
+			}
+			mapping[node] = candidate
+			used[candidate] = true
+			if match(i + 1) {
+				return true
+			}
+			delete(mapping, node)
+			delete(used, candidate)
+		}
+		return false
+	}
+
+	if !match(0) {
+		return false, nil, nil
+	}
+	return true, mapping, nil
+}
+
+// signatures returns the signature of every node.
+func (g *DirectedGraph) signatures() (map[Node]nodeSignature, error) {
+	depths, err := g.NodeDepths()
+	if err != nil {
+		return nil, err
+	}
+	heights, err := g.NodeHeights()
+	if err != nil {
+		return nil, err
+	}
+
+	signatures := make(map[Node]nodeSignature, g.NodeCount())
+	for _, node := range g.Nodes() {
+		signatures[node] = nodeSignature{
+			indegree:  g.IncomingEdgeCount(node),
+			outdegree: g.OutgoingEdgeCount(node),
+			depth:     depths[node],
+			height:    heights[node],
+		}
+	}
+	return signatures, nil
+}
@@ -0,0 +1,58 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// bipartiteCycles returns four sources and four sinks connected by edges of
+// degree two, forming either a single cycle of eight nodes or two of four
+// when the edges are undirected. All nodes of a kind share a signature.
+func bipartiteCycles(single bool, prefix string) *DirectedGraph {
+	g := NewDirectedGraph()
+	for i := 0; i < 4; i++ {
+		j := i + 1
+		if !single {
+			j = i ^ 1
+		}
+		g.AddEdge(fmt.Sprint(prefix, "s", i), fmt.Sprint(prefix, "t", i))
+		g.AddEdge(fmt.Sprint(prefix, "s", i), fmt.Sprint(prefix, "t", j%4))
+	}
+	return g
+}
+
+func TestAreIsomorphic(t *testing.T) {
+	a, b := bipartiteCycles(true, "a"), bipartiteCycles(true, "b")
+	ok, mapping, err := AreIsomorphic(a, b)
+	if err != nil || !ok {
+		t.Fatalf("two single cycles are not isomorphic: %v", err)
+	}
+	for _, from := range a.Nodes() {
+		for _, to := range a.OutgoingEdges(from) {
+			if !b.EdgeExists(mapping[from], mapping[to]) {
+				t.Errorf("the edge %v->%v maps to %v->%v, which does not exist", from, to, mapping[from], mapping[to])
+			}
+		}
+	}
+
+	if ok, _, err := AreIsomorphic(a, bipartiteCycles(false, "b")); err != nil || ok {
+		t.Errorf("a single cycle is isomorphic to two: %v", err)
+	}
+}
+
+func TestAreIsomorphicErrors(t *testing.T) {
+	a, b := bipartiteCycles(true, "a"), bipartiteCycles(true, "b")
+	if _, _, err := AreIsomorphic(a, b, WithIsomorphismLimit(7)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got %v, want ErrTooLarge", err)
+	}
+	if _, _, err := AreIsomorphic(a, b, WithIsomorphismLimit(0)); err != nil {
+		t.Errorf("got %v without a limit", err)
+	}
+
+	b.AddEdge("bt0", "bs0")
+	var cyclic *CyclicGraphError
+	if _, _, err := AreIsomorphic(a, b); !errors.As(err, &cyclic) {
+		t.Errorf("got %v, want a CyclicGraphError", err)
+	}
+}
@@ -0,0 +1,235 @@
+package graff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Errors relating to the JSON Lines edge format.
+var (
+	ErrInvalidJSONL = errors.New("The data is not a valid JSON Lines edge stream")
+)
+
+// maxJSONLFragment is the maximum length of the offending line quoted by a
+// JSONLError.
+const maxJSONLFragment = 80
+
+// JSONLError reports the line of a JSON Lines edge stream which could not be
+// read, numbered from 1, together with a fragment of it and the cause.
+// It wraps ErrInvalidJSONL and the cause so both can be tested for using
+// errors.Is.
+type JSONLError struct {
+	Line     int
+	Fragment string
+	Err      error
+}
+
+func (e *JSONLError) Error() string {
+	return fmt.Sprintf("%v: line %d: %v: %s", ErrInvalidJSONL, e.Line, e.Err, e.Fragment)
+}
+
+func (e *JSONLError) Unwrap() []error {
+	return []error{ErrInvalidJSONL, e.Err}
+}
+
+// JSONLOption configures ReadJSONL and WriteJSONL.
+type JSONLOption func(*jsonlConfig)
+
+type jsonlConfig struct {
+	from   string
+	to     string
+	weight string
+	attrs  []string
+}
+
+// WithJSONLFields sets the names of the fields holding the source and target
+// of an edge, which default to "from" and "to".
+func WithJSONLFields(from string, to string) JSONLOption {
+	return func(c *jsonlConfig) {
+		c.from = from
+		c.to = to
+	}
+}
+
+// WithJSONLWeight sets the name of the numeric field mapped onto the edge's
+// WeightAttr, which defaults to "weight". An empty name disables it.
+func WithJSONLWeight(field string) JSONLOption {
+	return func(c *jsonlConfig) {
+		c.weight = field
+	}
+}
+
+// WithJSONLAttrs maps the fields onto the edge attributes of the same name.
+// Fields missing from a line are left unset.
+func WithJSONLAttrs(fields ...string) JSONLOption {
+	return func(c *jsonlConfig) {
+		c.attrs = append(c.attrs, fields...)
+	}
+}
+
+func newJSONLConfig(opts []JSONLOption) *jsonlConfig {
+	config := &jsonlConfig{
+		from:   "from",
+		to:     "to",
+		weight: WeightAttr,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// ReadJSONL reads a graph from a stream of JSON objects, one edge per line,
+// such as {"from": "a", "to": "b", "weight": 1.2}. The stream is decoded line
+// by line, so memory use does not depend on its length. Nodes are strings,
+// or ints or float64s if given as numbers. The weight and attribute fields
+// are optional, see WithJSONLWeight and WithJSONLAttrs. Blank lines are
+// skipped and edges repeated on later lines overwrite the attributes of
+// earlier ones.
+// A JSONLError is returned for the first line which cannot be read.
+func ReadJSONL(r io.Reader, opts ...JSONLOption) (*DirectedGraph, error) {
+	config := newJSONLConfig(opts)
+	g := NewDirectedGraph()
+	b := bufio.NewReader(r)
+
+	for line := 1; ; line++ {
+		data, err := b.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			if err := config.readEdge(g, trimmed); err != nil {
+				return nil, &JSONLError{Line: line, Fragment: jsonlFragment(trimmed), Err: err}
+			}
+		}
+		if err == io.EOF {
+			return g, nil
+		}
+	}
+}
+
+// readEdge decodes a line and adds its edge to the graph.
+func (c *jsonlConfig) readEdge(g *DirectedGraph, data []byte) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var fields map[string]interface{}
+	if err := d.Decode(&fields); err != nil {
+		return err
+	}
+	if d.More() {
+		return errors.New("unexpected data after the object")
+	}
+
+	from, err := jsonlNode(fields, c.from)
+	if err != nil {
+		return err
+	}
+	to, err := jsonlNode(fields, c.to)
+	if err != nil {
+		return err
+	}
+	g.AddEdge(from, to)
+
+	if value, ok := fields[c.weight]; ok && c.weight != "" {
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("field %q is not a number", c.weight)
+		}
+		weight, err := number.Float64()
+		if err != nil {
+			return fmt.Errorf("field %q: %v", c.weight, err)
+		}
+		g.SetEdgeAttr(from, to, WeightAttr, weight)
+	}
+	for _, key := range c.attrs {
+		if value, ok := fields[key]; ok {
+			g.SetEdgeAttr(from, to, key, jsonlValue(value))
+		}
+	}
+	return nil
+}
+
+// jsonlNode returns the node held by the field, which must be a string or a
+// number.
+func jsonlNode(fields map[string]interface{}, key string) (Node, error) {
+	value, ok := fields[key]
+	if !ok {
+		return nil, fmt.Errorf("field %q is missing", key)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return jsonlValue(v), nil
+	}
+	return nil, fmt.Errorf("field %q is neither a string nor a number", key)
+}
+
+// jsonlValue converts numbers decoded as json.Number to an int if they are
+// integral and fit, and to a float64 otherwise, recursing into arrays and
+// objects.
+func jsonlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := strconv.Atoi(v.String()); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = jsonlValue(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = jsonlValue(v[key])
+		}
+	}
+	return value
+}
+
+// jsonlFragment returns the line, shortened to maxJSONLFragment bytes.
+func jsonlFragment(data []byte) string {
+	if len(data) > maxJSONLFragment {
+		return string(data[:maxJSONLFragment]) + "…"
+	}
+	return string(data)
+}
+
+// WriteJSONL writes the graph's edges as JSON Lines in the format read by
+// ReadJSONL, including the weight and attribute fields of the edges which
+// have them set. Edges are written in the order of their source in the
+// graph's node order, and then of their target, so the output is
+// deterministic. Nodes without edges are not written, and nodes which are
+// not strings or numbers should be mapped beforehand, see MapNodes.
+func (g *DirectedGraph) WriteJSONL(w io.Writer, opts ...JSONLOption) error {
+	config := newJSONLConfig(opts)
+	b := bufio.NewWriter(w)
+	e := json.NewEncoder(b)
+
+	for _, from := range g.Nodes() {
+		for _, to := range g.OutgoingEdges(from) {
+			fields := map[string]interface{}{config.from: from, config.to: to}
+			if config.weight != "" {
+				if weight, ok := g.EdgeAttr(from, to, WeightAttr); ok {
+					fields[config.weight] = weight
+				}
+			}
+			for _, key := range config.attrs {
+				if value, ok := g.EdgeAttr(from, to, key); ok {
+					fields[key] = value
+				}
+			}
+			// maps are encoded with sorted keys
+			if err := e.Encode(fields); err != nil {
+				return err
+			}
+		}
+	}
+	return b.Flush()
+}
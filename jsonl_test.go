@@ -0,0 +1,125 @@
+package graff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestJSONLRoundTrip(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("b", "c")
+	g.AddEdge("a", "c")
+	g.AddEdge("a", 7)
+	g.SetEdgeAttr("a", "c", WeightAttr, 1.5)
+	g.SetEdgeAttr("b", "c", "label", "x")
+
+	var buf bytes.Buffer
+	if err := g.WriteJSONL(&buf, WithJSONLAttrs("label")); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"from":"b","label":"x","to":"c"}
+{"from":"a","to":"c","weight":1.5}
+{"from":"a","to":7}
+`
+	if buf.String() != want {
+		t.Errorf("wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	read, err := ReadJSONL(strings.NewReader("\n"+buf.String()), WithJSONLAttrs("label"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read.Nodes(), g.Nodes()) || read.EdgeCount() != g.EdgeCount() {
+		t.Errorf("read %v, want %v", read, g)
+	}
+	if weight, _ := read.EdgeAttr("a", "c", WeightAttr); weight != 1.5 {
+		t.Errorf("read weight %v, want 1.5", weight)
+	}
+	if label, _ := read.EdgeAttr("b", "c", "label"); label != "x" {
+		t.Errorf("read label %v, want x", label)
+	}
+}
+
+func TestReadJSONLErrors(t *testing.T) {
+	cases := map[string]struct {
+		data string
+		line int
+	}{
+		"not an object":  {"{\"from\":\"a\",\"to\":\"b\"}\n\n[1, 2]\n", 3},
+		"missing target": {"{\"from\":\"a\"}", 1},
+		"nested node":    {"{\"from\":\"a\",\"to\":\"b\"}\n{\"from\":{},\"to\":\"b\"}", 2},
+		"text weight":    {"{\"from\":\"a\",\"to\":\"b\",\"weight\":\"heavy\"}", 1},
+		"trailing data":  {"{\"from\":\"a\",\"to\":\"b\"} {}", 1},
+	}
+	for name, c := range cases {
+		_, err := ReadJSONL(strings.NewReader(c.data))
+		var jsonlErr *JSONLError
+		if !errors.As(err, &jsonlErr) || !errors.Is(err, ErrInvalidJSONL) {
+			t.Errorf("%s: got %v, want a JSONLError", name, err)
+			continue
+		}
+		lines := strings.Split(c.data, "\n")
+		if jsonlErr.Line != c.line || jsonlErr.Fragment != strings.TrimSpace(lines[c.line-1]) {
+			t.Errorf("%s: got line %d with %q, want line %d", name, jsonlErr.Line, jsonlErr.Fragment, c.line)
+		}
+	}
+
+	long := fmt.Sprintf("{\"from\":\"%s\",\"to\":1", strings.Repeat("a", 200))
+	_, err := ReadJSONL(strings.NewReader(long))
+	var jsonlErr *JSONLError
+	if !errors.As(err, &jsonlErr) || jsonlErr.Fragment != long[:maxJSONLFragment]+"…" {
+		t.Errorf("got %v, want the line shortened to %d bytes", err, maxJSONLFragment)
+	}
+}
+
+// edgeStream generates JSON Lines on demand, cycling through a few edges so
+// that the graph read from it stays small however long the stream is. Every
+// 10000 lines it records the live heap, which grows if the lines read so far
+// are retained.
+type edgeStream struct {
+	lines   int
+	pending []byte
+	heap    []uint64
+}
+
+func (s *edgeStream) Read(p []byte) (int, error) {
+	if len(s.pending) == 0 {
+		if s.lines == 0 {
+			return 0, io.EOF
+		}
+		s.lines--
+		if s.lines%10000 == 0 {
+			var stats runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			s.heap = append(s.heap, stats.HeapAlloc)
+		}
+		s.pending = []byte(fmt.Sprintf("{\"from\": \"n%d\", \"to\": \"n%d\", \"weight\": %d.5}\n", s.lines%10, s.lines%10+1, s.lines))
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func TestReadJSONLStreams(t *testing.T) {
+	// the stream is several megabytes long
+	stream := &edgeStream{lines: 100000}
+	g, err := ReadJSONL(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.EdgeCount() != 10 {
+		t.Errorf("read %d edges, want 10", g.EdgeCount())
+	}
+	for i, heap := range stream.heap {
+		if grown := int64(heap) - int64(stream.heap[0]); grown > 1<<20 {
+			t.Errorf("the heap grew by %d bytes after %d lines", grown, i*10000)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package graff
+
+import (
+	"container/heap"
+)
+
+// KahnSorter topologically sorts a directed graph's nodes using Kahn's
+// algorithm, repeatedly emitting a node whose dependencies have all been
+// emitted. Among the ready nodes the smallest according to the comparison
+// function is emitted first, which makes the result the lexicographically
+// smallest topological order. Ties, and all nodes when no comparison function
+// is given, are broken by insertion order, so the output is deterministic.
+// See https://en.wikipedia.org/wiki/Topological_sorting#Kahn's_algorithm
+type KahnSorter struct {
+	graph Directed
+	less  func(a, b Node) bool
+	// result holds the nodes sorted by the last successful sort, see String
+	result []Node
+}
+
+// NewKahnSorter returns a new Kahn sorter. The comparison function may be nil.
+func NewKahnSorter(graph Directed, less func(a, b Node) bool) *KahnSorter {
+	return &KahnSorter{
+		graph: directedView(graph),
+		less:  less,
+	}
+}
+
+// Sort returns the sorted nodes.
+func (s *KahnSorter) Sort() ([]Node, error) {
+	sorted := make([]Node, 0, s.graph.NodeCount())
+	err := s.walk(func(node Node) error {
+		sorted = append(sorted, node)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.result = sorted
+	return sorted, nil
+}
+
+// walk calls visit with every node in sorted order, stopping at the first
+// error. Nodes on or behind a cycle are never visited, and once all others
+// were visited a CyclicGraphError is returned.
+func (s *KahnSorter) walk(visit func(Node) error) error {
+	ready := newReadyState(s.graph)
+	queue := newReadyQueue(s.graph, s.less)
+	for _, node := range ready.Roots() {
+		queue.Push(node)
+	}
+
+	// the method value is bound once rather than for every release
+	push := queue.Push
+	count := 0
+	for queue.Len() > 0 {
+		node := queue.Pop()
+		if err := visit(node); err != nil {
+			return err
+		}
+		count++
+		ready.Release(node, push)
+	}
+
+	if count != s.graph.NodeCount() {
+		return cycleError(s.graph)
+	}
+	return graphErr(s.graph)
+}
+
+// LexTopoSort returns the graph's nodes in the lexicographically smallest
+// topological order under the specified comparison function, see KahnSorter.
+// Given the same graph and comparison function the order is always identical,
+// which also makes it a deterministic ordering for the Coffman-Graham sorters:
+//
+//	WithTopologicalSorter(func(g *DirectedGraph) TopologicalSorter {
+//		return NewKahnSorter(g, less)
+//	})
+func (g *DirectedGraph) LexTopoSort(less func(a, b Node) bool) ([]Node, error) {
+	sorter := NewKahnSorter(g, less)
+	return sorter.Sort()
+}
+
+// TopologicalRanks returns the position of every node in the topological
+// order of LexTopoSort without a comparison function, which is deterministic,
+// so that nodes can be ordered by comparing their ranks, see CompareTopo.
+// The ranks form one valid linearization of the graph rather than the
+// partial order itself: a node ranked before another is not necessarily its
+// ancestor, use IsAncestor for that.
+// A CyclicGraphError is returned if the graph contains a cycle.
+func (g *DirectedGraph) TopologicalRanks() (map[Node]int, error) {
+	nodes, err := g.LexTopoSort(nil)
+	if err != nil {
+		return nil, err
+	}
+	ranks := make(map[Node]int, len(nodes))
+	for i, node := range nodes {
+		ranks[node] = i
+	}
+	return ranks, nil
+}
+
+// CompareTopo compares the ranks of the nodes as returned by
+// TopologicalRanks, returning a negative number if a comes first, a positive
+// one if b does, and 0 if they are the same node. Nodes without a rank come
+// after all others and compare as equal to each other.
+func CompareTopo(ranks map[Node]int, a Node, b Node) int {
+	rankA, okA := ranks[a]
+	rankB, okB := ranks[b]
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return 1
+	case !okB:
+		return -1
+	}
+	return rankA - rankB
+}
+
+// readyState maintains the number of unreleased dependencies of every node,
+// so that nodes become ready once all of their dependencies were released.
+type readyState struct {
+	graph    Directed
+	indegree map[Node]int
+	// releaseOutgoing is releaseEdge bound once, so that releasing a node
+	// does not allocate a closure, and ready is the callback of the current
+	// Release
+	releaseOutgoing func(Node) bool
+	ready           func(Node)
+}
+
+func newReadyState(graph Directed) *readyState {
+	indegree := make(map[Node]int, graph.NodeCount())
+	for _, node := range graph.Nodes() {
+		if count := incomingEdgeCount(graph, node); count > 0 {
+			indegree[node] = count
+		}
+	}
+	r := &readyState{
+		graph:    graph,
+		indegree: indegree,
+	}
+	r.releaseOutgoing = r.releaseEdge
+	return r
+}
+
+// Roots returns the nodes which are ready before anything was released.
+func (r *readyState) Roots() []Node {
+	return rootNodes(r.graph)
+}
+
+// Release marks the node as done and reports each dependant that became
+// ready as a result.
+func (r *readyState) Release(node Node, ready func(Node)) {
+	r.ready = ready
+	eachOutgoing(r.graph, node, r.releaseOutgoing)
+	r.ready = nil
+}
+
+// releaseEdge releases the dependency of the edge's target.
+func (r *readyState) releaseEdge(outgoing Node) bool {
+	r.indegree[outgoing]--
+	if r.indegree[outgoing] == 0 {
+		delete(r.indegree, outgoing)
+		r.ready(outgoing)
+	}
+	return true
+}
+
+// readyQueue is a priority queue of ready nodes ordered by a comparison
+// function, falling back to the graph's insertion order.
+type readyQueue struct {
+	entries []readyEntry
+	less    func(a, b Node) bool
+	order   map[Node]int
+}
+
+type readyEntry struct {
+	node  Node
+	order int
+}
+
+func newReadyQueue(graph Directed, less func(a, b Node) bool) *readyQueue {
+	order := make(map[Node]int, graph.NodeCount())
+	for i, node := range graph.Nodes() {
+		order[node] = i
+	}
+	return &readyQueue{
+		entries: make([]readyEntry, 0),
+		less:    less,
+		order:   order,
+	}
+}
+
+func (q *readyQueue) Push(node Node) {
+	heap.Push((*readyHeap)(q), readyEntry{node: node, order: q.order[node]})
+}
+
+func (q *readyQueue) Pop() Node {
+	return heap.Pop((*readyHeap)(q)).(readyEntry).node
+}
+
+func (q *readyQueue) Len() int {
+	return len(q.entries)
+}
+
+// readyHeap implements heap.Interface for the readyQueue.
+type readyHeap readyQueue
+
+func (h *readyHeap) Len() int {
+	return len(h.entries)
+}
+
+func (h *readyHeap) Less(i, j int) bool {
+	a, b := h.entries[i], h.entries[j]
+	if h.less != nil {
+		if h.less(a.node, b.node) {
+			return true
+		}
+		if h.less(b.node, a.node) {
+			return false
+		}
+	}
+	return a.order < b.order
+}
+
+func (h *readyHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+}
+
+func (h *readyHeap) Push(x interface{}) {
+	h.entries = append(h.entries, x.(readyEntry))
+}
+
+func (h *readyHeap) Pop() interface{} {
+	last := len(h.entries) - 1
+	entry := h.entries[last]
+	h.entries[last] = readyEntry{}
+	h.entries = h.entries[:last]
+	return entry
+}
@@ -0,0 +1,112 @@
+package graff
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLexTopoSort(t *testing.T) {
+	edges := []Edge{{"b", "d"}, {"a", "d"}, {"c", "a"}, {"e", "b"}, {"d", "f"}, {"c", "f"}}
+	less := func(a, b Node) bool { return a.(string) < b.(string) }
+
+	var want []Node
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < 20; run++ {
+		g := NewDirectedGraph()
+		for _, i := range rng.Perm(len(edges)) {
+			g.AddEdge(edges[i].From, edges[i].To)
+		}
+		got, err := g.LexTopoSort(less)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			want = got
+		} else if !reflect.DeepEqual(got, want) {
+			t.Fatalf("the insertion order changed the result from %v to %v", want, got)
+		}
+	}
+
+	// the result is the smallest of all orders when comparing node by node
+	g := NewDirectedGraph()
+	for _, edge := range edges {
+		g.AddEdge(edge.From, edge.To)
+	}
+	all, err := g.AllTopologicalSorts(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, order := range all {
+		for i := range order {
+			if order[i] != want[i] {
+				if less(order[i], want[i]) {
+					t.Errorf("%v is smaller than %v", order, want)
+				}
+				break
+			}
+		}
+	}
+}
+
+func TestTopologicalRanks(t *testing.T) {
+	g := layeredGraph(200, 3)
+	ranks, err := g.TopologicalRanks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, from := range g.Nodes() {
+		for _, to := range g.OutgoingEdges(from) {
+			if CompareTopo(ranks, from, to) >= 0 {
+				t.Errorf("%v is not ranked before %v", from, to)
+			}
+		}
+	}
+	if CompareTopo(ranks, 0, "missing") >= 0 || CompareTopo(ranks, "missing", "other") != 0 {
+		t.Error("nodes without a rank are not ranked last")
+	}
+
+	g.AddEdge(199, 0)
+	if _, err := g.TopologicalRanks(); err == nil {
+		t.Error("a cyclic graph was ranked")
+	}
+}
+
+// BenchmarkTopologicalRanks sorts 1M work items by rank, and answers as many
+// reachability queries as the sort makes comparisons.
+func BenchmarkTopologicalRanks(b *testing.B) {
+	g := layeredGraph(10000, 4)
+	rng := rand.New(rand.NewSource(1))
+	items := make([]Node, 1000000)
+	for i := range items {
+		items[i] = rng.Intn(10000)
+	}
+
+	comparisons := 0
+	b.Run("ranks", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ranks, err := g.TopologicalRanks()
+			if err != nil {
+				b.Fatal(err)
+			}
+			sorted := copyNodes(items)
+			comparisons = 0
+			sort.Slice(sorted, func(i, j int) bool {
+				comparisons++
+				return CompareTopo(ranks, sorted[i], sorted[j]) < 0
+			})
+		}
+	})
+	b.Run("IsAncestor", func(b *testing.B) {
+		if _, err := g.BuildReachabilityIndex(); err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < b.N; i++ {
+			for k := 0; k < comparisons; k++ {
+				g.IsAncestor(items[k%len(items)], items[(k+1)%len(items)])
+			}
+		}
+	})
+}
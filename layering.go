@@ -0,0 +1,106 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to layerings.
+var (
+	ErrInvalidLayering = errors.New("The layering is invalid")
+)
+
+// ValidateLayering verifies that the layers are a valid layering of the
+// graph: every node appears in exactly one layer, no layer holds more than
+// width nodes (unless width is zero or less), and every edge points from an
+// earlier layer to a later one.
+// The returned error wraps ErrInvalidLayering and names the first violation.
+func ValidateLayering(g Directed, layers [][]Node, width int) error {
+	g = directedView(g)
+	levels := make(map[Node]int, g.NodeCount())
+	for level, layer := range layers {
+		if width > 0 && len(layer) > width {
+			return fmt.Errorf("%w: layer %d holds %d nodes, exceeding the width of %d", ErrInvalidLayering, level, len(layer), width)
+		}
+		for _, node := range layer {
+			if !g.NodeExists(node) {
+				return fmt.Errorf("%w: %v in layer %d does not exist within the graph", ErrInvalidLayering, node, level)
+			}
+			if previous, ok := levels[node]; ok {
+				return fmt.Errorf("%w: %v appears in layers %d and %d", ErrInvalidLayering, node, previous, level)
+			}
+			levels[node] = level
+		}
+	}
+
+	for _, node := range g.Nodes() {
+		if _, ok := levels[node]; !ok {
+			return fmt.Errorf("%w: %v is missing", ErrInvalidLayering, node)
+		}
+	}
+	for _, from := range g.Nodes() {
+		for _, to := range g.OutgoingEdges(from) {
+			if levels[from] >= levels[to] {
+				return fmt.Errorf("%w: edge %v -> %v points from layer %d to layer %d", ErrInvalidLayering, from, to, levels[from], levels[to])
+			}
+		}
+	}
+	return graphErr(g)
+}
+
+// BalanceLayers returns a copy of a valid layering of the graph in which
+// nodes were moved to later layers, as far as their edges allow, to even out
+// the sizes of the layers. A node is only moved to a layer holding at least
+// two nodes less than its own, so the layer count never increases, no layer
+// becomes empty, and the width is respected whenever it was before. The
+// nodes keep their relative order within their layers, with moved nodes
+// appended to their new layer.
+func BalanceLayers(g Directed, layers [][]Node, width int) [][]Node {
+	g = directedView(g)
+	balanced := make([][]Node, len(layers))
+	levels := make(map[Node]int, g.NodeCount())
+	for level, layer := range layers {
+		balanced[level] = copyNodes(layer)
+		for _, node := range layer {
+			levels[node] = level
+		}
+	}
+
+	// every move decreases the sum of the squared layer sizes, so this ends
+	for moved := true; moved; {
+		moved = false
+		for level := len(balanced) - 2; level >= 0; level-- {
+			for i := len(balanced[level]) - 1; i >= 0; i-- {
+				node := balanced[level][i]
+
+				// the node must stay before its dependants
+				limit := len(balanced) - 1
+				eachOutgoing(g, node, func(to Node) bool {
+					if l, ok := levels[to]; ok && l-1 < limit {
+						limit = l - 1
+					}
+					return true
+				})
+
+				target := level
+				for l := level + 1; l <= limit; l++ {
+					if len(balanced[l]) < len(balanced[target]) {
+						target = l
+					}
+				}
+				if target == level || len(balanced[target])+1 >= len(balanced[level]) {
+					continue
+				}
+				if width > 0 && len(balanced[target]) >= width {
+					continue
+				}
+
+				balanced[level] = append(balanced[level][:i], balanced[level][i+1:]...)
+				balanced[target] = append(balanced[target], node)
+				levels[node] = target
+				moved = true
+			}
+		}
+	}
+	return balanced
+}
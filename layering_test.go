@@ -0,0 +1,49 @@
+package graff
+
+import (
+	"fmt"
+	"testing"
+)
+
+// sizeVariance returns the variance of the layer sizes.
+func sizeVariance(layers [][]Node) float64 {
+	mean := 0.0
+	for _, layer := range layers {
+		mean += float64(len(layer))
+	}
+	mean /= float64(len(layers))
+	variance := 0.0
+	for _, layer := range layers {
+		d := float64(len(layer)) - mean
+		variance += d * d
+	}
+	return variance / float64(len(layers))
+}
+
+func TestBalanceLayers(t *testing.T) {
+	// the sorter fills the first layers with the ten independent nodes,
+	// leaving a node of the chain alone in each of the last layers
+	g := NewDirectedGraph()
+	for i := 0; i < 4; i++ {
+		g.AddEdge(fmt.Sprint("c", i), fmt.Sprint("c", i+1))
+	}
+	for i := 0; i < 10; i++ {
+		g.AddNode(i)
+	}
+	layers, err := g.CoffmanGrahamSort(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	balanced := BalanceLayers(g, layers, 4)
+	if err := ValidateLayering(g, balanced, 4); err != nil {
+		t.Fatal(err)
+	}
+	if len(balanced) != len(layers) {
+		t.Errorf("got %d layers, want %d", len(balanced), len(layers))
+	}
+	before, after := sizeVariance(layers), sizeVariance(balanced)
+	if after >= before || after > 0.5 {
+		t.Errorf("the size variance went from %g to %g, want it almost even", before, after)
+	}
+}
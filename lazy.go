@@ -0,0 +1,186 @@
+package graff
+
+import (
+	"fmt"
+)
+
+// DefaultLazyPageSize is the number of nodes a LazyGraph loads per batch
+// unless configured otherwise.
+const DefaultLazyPageSize = 256
+
+// LazyAdjacency holds the targets of a node's outgoing edges and the sources
+// of its incoming edges.
+type LazyAdjacency struct {
+	Out []Node
+	In  []Node
+}
+
+// LazyOption configures a LazyGraph.
+type LazyOption func(*LazyGraph)
+
+// WithBatchFetch loads the edges of the nodes the sorters are about to visit
+// in batches of at most pageSize nodes, or DefaultLazyPageSize if it is zero
+// or less, using fetch, which must return the adjacency of every node in the
+// order requested. Nodes which were not prefetched are still loaded one by
+// one.
+func WithBatchFetch(fetch func(nodes []Node) ([]LazyAdjacency, error), pageSize int) LazyOption {
+	return func(g *LazyGraph) {
+		if pageSize <= 0 {
+			pageSize = DefaultLazyPageSize
+		}
+		g.fetchBatch = fetch
+		g.pageSize = pageSize
+	}
+}
+
+// LazyGraph is a Directed graph whose nodes are known up front, but whose
+// edges are loaded on demand, e.g. from a database, and cached, so that the
+// edges of every node are loaded at most once. It serves as a reference for
+// adapting other storage to Directed.
+//
+// The first error returned by a fetch is kept and reported by Err, after
+// which nothing more is loaded and nodes appear to have no edges. The DFS
+// and Coffman-Graham sorters, the ReachabilityIndex and ValidateLayering
+// return it rather than a result computed from missing edges. A LazyGraph
+// must not be used by multiple goroutines at once.
+type LazyGraph struct {
+	nodes []Node
+	set   map[Node]bool
+
+	fetch      func(node Node) (out []Node, in []Node, err error)
+	fetchBatch func(nodes []Node) ([]LazyAdjacency, error)
+	pageSize   int
+
+	cache map[Node]*LazyAdjacency
+	err   error
+}
+
+// NewLazyGraph creates a graph of the nodes whose edges are loaded by fetch,
+// which returns the targets of a node's outgoing edges and the sources of
+// its incoming edges. The edges must only reference the nodes.
+func NewLazyGraph(nodes []Node, fetch func(node Node) (out []Node, in []Node, err error), opts ...LazyOption) *LazyGraph {
+	g := &LazyGraph{
+		nodes: copyNodes(nodes),
+		set:   make(map[Node]bool, len(nodes)),
+		fetch: fetch,
+		cache: make(map[Node]*LazyAdjacency, len(nodes)),
+	}
+	for _, node := range nodes {
+		g.set[node] = true
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Nodes returns the graph's nodes in the order they were passed to
+// NewLazyGraph.
+func (g *LazyGraph) Nodes() []Node {
+	return g.nodes
+}
+
+// NodeCount returns the number of nodes.
+func (g *LazyGraph) NodeCount() int {
+	return len(g.nodes)
+}
+
+// NodeExists determines whether the specified node exists within the graph.
+func (g *LazyGraph) NodeExists(node Node) bool {
+	return g.set[node]
+}
+
+// OutgoingEdges returns the targets of the node's outgoing edges, loading
+// them if necessary.
+func (g *LazyGraph) OutgoingEdges(node Node) []Node {
+	if adjacency := g.load(node); adjacency != nil {
+		return adjacency.Out
+	}
+	return nil
+}
+
+// IncomingEdges returns the sources of the node's incoming edges, loading
+// them if necessary.
+func (g *LazyGraph) IncomingEdges(node Node) []Node {
+	if adjacency := g.load(node); adjacency != nil {
+		return adjacency.In
+	}
+	return nil
+}
+
+// EdgeExists checks whether the edge exists within the graph, loading the
+// edges of its source if necessary.
+func (g *LazyGraph) EdgeExists(from Node, to Node) bool {
+	for _, node := range g.OutgoingEdges(from) {
+		if node == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Prefetch loads the edges of the nodes which were not loaded yet in
+// batches if WithBatchFetch is used, and does nothing otherwise. The sorters
+// call it with the nodes they are about to visit.
+func (g *LazyGraph) Prefetch(nodes []Node) {
+	if g.fetchBatch == nil || g.err != nil {
+		return
+	}
+	pending := make([]Node, 0)
+	seen := make(map[Node]bool)
+	for _, node := range nodes {
+		if _, ok := g.cache[node]; !ok && g.set[node] && !seen[node] {
+			seen[node] = true
+			pending = append(pending, node)
+		}
+	}
+
+	for start := 0; start < len(pending); start += g.pageSize {
+		end := start + g.pageSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		page := pending[start:end]
+		adjacencies, err := g.fetchBatch(page)
+		if err == nil && len(adjacencies) != len(page) {
+			err = fmt.Errorf("%d adjacencies returned for %d nodes", len(adjacencies), len(page))
+		}
+		if err != nil {
+			g.err = fmt.Errorf("fetching %d nodes: %w", len(page), err)
+			return
+		}
+		for i, node := range page {
+			adjacency := adjacencies[i]
+			g.cache[node] = &adjacency
+		}
+	}
+}
+
+// Loaded returns the number of nodes whose edges were loaded.
+func (g *LazyGraph) Loaded() int {
+	return len(g.cache)
+}
+
+// Err returns the first error returned by a fetch, if any.
+func (g *LazyGraph) Err() error {
+	return g.err
+}
+
+// load returns the node's adjacency, loading it if necessary, or nil if the
+// node does not exist or loading failed.
+func (g *LazyGraph) load(node Node) *LazyAdjacency {
+	if adjacency, ok := g.cache[node]; ok {
+		return adjacency
+	}
+	if g.err != nil || !g.set[node] {
+		return nil
+	}
+	out, in, err := g.fetch(node)
+	if err != nil {
+		g.err = fmt.Errorf("fetching %v: %w", node, err)
+		return nil
+	}
+	adjacency := &LazyAdjacency{Out: out, In: in}
+	g.cache[node] = adjacency
+	return adjacency
+}
@@ -0,0 +1,103 @@
+package graff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// pagedStore serves the adjacency of a graph like a database, counting how
+// often every node was fetched.
+type pagedStore struct {
+	graph   *DirectedGraph
+	fetched map[Node]int
+	pages   int
+	fail    Node
+}
+
+var errStoreDown = errors.New("store down")
+
+func (s *pagedStore) fetch(node Node) ([]Node, []Node, error) {
+	if node == s.fail {
+		return nil, nil, errStoreDown
+	}
+	s.fetched[node]++
+	return copyNodes(s.graph.OutgoingEdges(node)), copyNodes(s.graph.IncomingEdges(node)), nil
+}
+
+func (s *pagedStore) fetchPage(nodes []Node) ([]LazyAdjacency, error) {
+	s.pages++
+	page := make([]LazyAdjacency, len(nodes))
+	for i, node := range nodes {
+		out, in, err := s.fetch(node)
+		if err != nil {
+			return nil, err
+		}
+		page[i] = LazyAdjacency{Out: out, In: in}
+	}
+	return page, nil
+}
+
+func TestLazyGraphFetchesOnce(t *testing.T) {
+	g := layeredGraph(500, 3)
+	want, err := g.DFSSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLayers, err := g.CoffmanGrahamSort(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, batched := range []bool{false, true} {
+		store := &pagedStore{graph: g, fetched: make(map[Node]int), fail: "none"}
+		var opts []LazyOption
+		if batched {
+			opts = append(opts, WithBatchFetch(store.fetchPage, 32))
+		}
+		lazy := NewLazyGraph(g.Nodes(), store.fetch, opts...)
+
+		order, err := NewDFSSorter(lazy).Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(order, want) {
+			t.Errorf("batched %v: the lazy graph sorts differently", batched)
+		}
+		layers, err := NewCoffmanGrahamSorter(lazy, 4).Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(layers, wantLayers) {
+			t.Errorf("batched %v: the lazy graph levels differently", batched)
+		}
+
+		for node, count := range store.fetched {
+			if count > 1 {
+				t.Errorf("batched %v: %v was fetched %d times", batched, node, count)
+			}
+		}
+		if len(store.fetched) != g.NodeCount() || lazy.Loaded() != g.NodeCount() {
+			t.Errorf("batched %v: fetched %d nodes of %d", batched, len(store.fetched), g.NodeCount())
+		}
+		if batched && store.pages == 0 {
+			t.Error("nothing was prefetched")
+		}
+	}
+}
+
+func TestLazyGraphFetchError(t *testing.T) {
+	g := layeredGraph(100, 3)
+	store := &pagedStore{graph: g, fetched: make(map[Node]int), fail: 50}
+	lazy := NewLazyGraph(g.Nodes(), store.fetch)
+
+	if _, err := NewDFSSorter(lazy).Sort(); !errors.Is(err, errStoreDown) {
+		t.Errorf("DFS sorting returned %v, want the fetch error", err)
+	}
+	if _, err := NewCoffmanGrahamSorter(lazy, 4).Sort(); !errors.Is(err, errStoreDown) {
+		t.Errorf("Coffman-Graham sorting returned %v, want the fetch error", err)
+	}
+	if !errors.Is(lazy.Err(), errStoreDown) {
+		t.Errorf("Err returned %v", lazy.Err())
+	}
+}
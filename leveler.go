@@ -0,0 +1,625 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Errors relating to the incremental level assignment.
+var (
+	ErrStaleLevel = errors.New("The level of an already sorted node is stale")
+)
+
+// StaleLevelError reports an edge which violates the level ordering because
+// its target was assigned a level before the edge was added, and levels once
+// assigned are never changed.
+// It wraps ErrStaleLevel so it can be tested for using errors.Is.
+type StaleLevelError struct {
+	Edge      Edge
+	FromLevel int
+	ToLevel   int
+}
+
+func (e *StaleLevelError) Error() string {
+	return fmt.Sprintf("%v: edge %v -> %v points from level %d to level %d", ErrStaleLevel, e.Edge.From, e.Edge.To, e.FromLevel, e.ToLevel)
+}
+
+func (e *StaleLevelError) Unwrap() error {
+	return ErrStaleLevel
+}
+
+// LevelChange describes a node whose level changed when recomputing the
+// levels from scratch. Nodes which had no level before have an OldLevel of -1.
+type LevelChange struct {
+	Node     Node
+	OldLevel int
+	NewLevel int
+}
+
+// leveler assigns nodes to layers for the Coffman-Graham sorters. It keeps
+// the assignments between calls so that sorting can continue incrementally:
+// a node which was assigned a level keeps it forever.
+type leveler struct {
+	config *sorterConfig
+
+	layers [][]Node
+	levels map[Node]int
+	// sealed records the layers reported as sealed, i.e. full
+	sealed []bool
+	// arrival orders the nodes within a layer by their insertion index
+	// rather than the order they were assigned
+	arrival bool
+	// deferred records the first level the nodes placed on a later level
+	// were eligible for
+	deferred map[Node]int
+	// floor is the lowest level nodes may be placed on, the base level or
+	// the level below which the layers were pruned
+	floor int
+
+	// reduced is the graph leveled by the last successful sort, and removed
+	// the transitive edges removed from it
+	reduced *DirectedGraph
+	removed []Edge
+	// pending holds the nodes a limited sort has yet to visit
+	pending *pendingSort
+}
+
+// pendingSort holds the progress of a sort which reached its limit, so that
+// the next one can resume with the remaining nodes if the graph is unchanged.
+type pendingSort struct {
+	graph   *DirectedGraph
+	guard   modificationGuard
+	reduced *DirectedGraph
+	nodes   []Node
+	// sizes holds the sizes of the layers before the sort started
+	sizes []int
+}
+
+func newLeveler(config *sorterConfig) *leveler {
+	return &leveler{
+		config:   config,
+		layers:   make([][]Node, 0),
+		levels:   make(map[Node]int, 0),
+		sealed:   make([]bool, 0),
+		deferred: make(map[Node]int),
+		floor:    config.baseLevel,
+	}
+}
+
+// reset discards all assignments, reusing the levels map. The layers are
+// allocated anew since they were returned to callers.
+func (l *leveler) reset() {
+	l.layers = make([][]Node, 0)
+	for node := range l.levels {
+		delete(l.levels, node)
+	}
+	l.sealed = l.sealed[:0]
+	for node := range l.deferred {
+		delete(l.deferred, node)
+	}
+	l.floor = l.config.baseLevel
+	l.reduced = nil
+	l.removed = nil
+	l.pending = nil
+}
+
+// sort levels the graph's nodes which were not assigned a level yet,
+// returning the highest level assigned by this call (-1 if none).
+// The graph's transitive edges are removed before it is topologically sorted
+// unless configured otherwise, and assigned is called with the current
+// layers after every assignment.
+// If assigned returns an error the sort stops, keeping the assignments made
+// so far, which the caller may already have handed out; if any other error
+// occurs all assignments made by the call are undone.
+func (l *leveler) sort(graph *DirectedGraph, assigned func(layers [][]Node) error) (int, error) {
+	maxLevel, _, err := l.sortLimited(graph, assigned, 0)
+	return maxLevel, err
+}
+
+// sortLimited levels the graph's nodes like sort, but assigns at most limit
+// nodes unless it is zero or less, reporting whether all nodes were
+// assigned. The remaining nodes are kept in topological order, and the next
+// call resumes with them unless the graph was modified in between.
+func (l *leveler) sortLimited(graph *DirectedGraph, assigned func(layers [][]Node) error, limit int) (int, bool, error) {
+	guard := graph.guard()
+
+	resumed := l.pending != nil && l.pending.graph == graph && l.pending.guard.check() == nil
+	var reduced *DirectedGraph
+	var nodes []Node
+	if resumed {
+		reduced, nodes = l.pending.reduced, l.pending.nodes
+	} else {
+		l.pending = nil
+		if l.config.strict {
+			if violations := l.checkConsistency(graph); len(violations) > 0 {
+				return -1, false, l.staleLevelError(violations[0])
+			}
+		}
+
+		// create a copy of the graph and remove transitive edges
+		reduced = graph
+		if !l.config.skipReduction {
+			reduced = graph.Copy()
+			if err := reduced.RemoveTransitivesExcept(l.config.keepEdge); err != nil {
+				return -1, false, err
+			}
+		}
+
+		// topologically sort the graph nodes
+		var err error
+		nodes, err = l.config.topologicalSort(reduced)
+		if err != nil {
+			return -1, false, err
+		}
+		if err := guard.check(); err != nil {
+			return -1, false, err
+		}
+	}
+
+	sizes := make([]int, len(l.layers))
+	for i, layer := range l.layers {
+		sizes[i] = len(layer)
+	}
+	added := make([]Node, 0)
+	stopped := false
+
+	maxLevel, remaining, err := l.assign(graph, reduced, nodes, limit, func(node Node) error {
+		added = append(added, node)
+		if err := guard.check(); err != nil {
+			return err
+		}
+		if assigned != nil {
+			if err := assigned(l.layers); err != nil {
+				stopped = true
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && !stopped {
+		l.pending = nil
+		l.rollback(sizes, added)
+		return -1, false, err
+	}
+	started := sizes
+	if resumed {
+		started = l.pending.sizes
+	}
+	for i, layer := range l.layers {
+		if l.arrival {
+			// the nodes assigned by earlier sorts arrived before the others,
+			// while those of a resumed sort are ordered with the nodes the
+			// sort assigned before
+			start := 0
+			if i < len(started) {
+				start = started[i]
+			}
+			l.orderByArrival(graph, layer[start:])
+		}
+		l.config.orderLayer(layer)
+	}
+	if stopped {
+		l.pending = nil
+		return -1, false, err
+	}
+
+	if len(remaining) > 0 {
+		l.pending = &pendingSort{graph: graph, guard: guard, reduced: reduced, nodes: remaining, sizes: started}
+	} else {
+		l.pending = nil
+	}
+	if resumed {
+		return maxLevel, l.pending == nil, nil
+	}
+
+	l.reduced = reduced
+	l.removed = make([]Edge, 0)
+	if reduced != graph {
+		for _, from := range graph.Nodes() {
+			graph.EachOutgoing(from, func(to Node) bool {
+				if !reduced.EdgeExists(from, to) {
+					l.removed = append(l.removed, Edge{from, to})
+				}
+				return true
+			})
+		}
+	}
+	return maxLevel, l.pending == nil, nil
+}
+
+// orderByArrival sorts the nodes by their insertion index in the graph.
+func (l *leveler) orderByArrival(graph *DirectedGraph, nodes []Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		a, _ := graph.InsertionIndex(nodes[i])
+		b, _ := graph.InsertionIndex(nodes[j])
+		return a < b
+	})
+}
+
+// reducedGraph returns the graph leveled by the last successful sort.
+func (l *leveler) reducedGraph() *DirectedGraph {
+	return l.reduced
+}
+
+// removedTransitiveEdges returns a copy of the edges removed from the graph
+// leveled by the last successful sort.
+func (l *leveler) removedTransitiveEdges() []Edge {
+	if l.removed == nil {
+		return nil
+	}
+	removed := make([]Edge, len(l.removed))
+	copy(removed, l.removed)
+	return removed
+}
+
+// assign levels the nodes in order, skipping those already assigned a level,
+// until limit nodes were assigned unless it is zero or less. It returns the
+// highest level assigned (-1 if none) and the nodes left unvisited.
+func (l *leveler) assign(graph *DirectedGraph, reduced *DirectedGraph, nodes []Node, limit int, assigned func(node Node) error) (int, []Node, error) {
+	maxLevel := -1
+	count := 0
+
+	for i, node := range nodes {
+		if _, ok := l.levels[node]; ok {
+			// if already assigned a level, dont need to assign again
+			continue
+		}
+		if limit > 0 && count == limit {
+			return maxLevel, nodes[i:], nil
+		}
+
+		dependantLevel := l.floor - 1
+		var err error
+		reduced.EachIncoming(node, func(dependant Node) bool {
+			level, ok := l.levels[dependant]
+			if !ok {
+				err = &DependencyOrderError{Node: node, Dependant: dependant}
+				return false
+			}
+			if level > dependantLevel {
+				dependantLevel = level
+			}
+			return true
+		})
+		if err != nil {
+			return -1, nil, err
+		}
+
+		demand, err := l.config.demandOf(node)
+		if err != nil {
+			return -1, nil, err
+		}
+		level := l.place(dependantLevel, l.config.sizeOf(node), demand)
+		if level > dependantLevel+1 {
+			// the layers in between were too full to take the node
+			l.deferred[node] = dependantLevel + 1
+		}
+
+		if l.config.strict {
+			// an already leveled dependant of the node must come after it
+			for _, outgoing := range graph.OutgoingEdges(node) {
+				if to, ok := l.levels[outgoing]; ok && to <= level {
+					return -1, nil, &StaleLevelError{Edge: Edge{node, outgoing}, FromLevel: level, ToLevel: to}
+				}
+			}
+		}
+
+		l.layers[level] = append(l.layers[level], node)
+		l.levels[node] = level
+		l.config.trace(node, level)
+		l.notify(level)
+
+		count++
+		if level > maxLevel {
+			maxLevel = level
+		}
+		if err := assigned(node); err != nil {
+			return -1, nil, err
+		}
+	}
+
+	return maxLevel, nil, nil
+}
+
+// place finds the first layer following the dependant layer which has room
+// for a node of the specified size and demand, creating a new layer if none
+// was found. Empty layers are created up to the dependant layer if it does
+// not exist.
+func (l *leveler) place(dependantLevel int, size int, demand []int) int {
+	for len(l.layers) <= dependantLevel {
+		l.layers = append(l.layers, make([]Node, 0))
+		l.sealed = append(l.sealed, false)
+	}
+	for i := dependantLevel + 1; i < len(l.layers); i++ {
+		if l.fits(i, size, demand) {
+			return i
+		}
+	}
+
+	l.layers = append(l.layers, make([]Node, 0, 1))
+	l.sealed = append(l.sealed, false)
+	return len(l.layers) - 1
+}
+
+// fits determines whether adding a node of the specified size and demand
+// keeps the layer within the width and the capacities.
+func (l *leveler) fits(level int, size int, demand []int) bool {
+	// ensure the layer doesn't exceed the desired width
+	if l.occupancy(level)+size > l.config.widthAt(level) {
+		return false
+	}
+	if demand == nil {
+		return true
+	}
+	for d, amount := range l.load(level) {
+		if amount+demand[d] > l.config.capacity[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// full determines whether the layer reached the width, or every capacity.
+func (l *leveler) full(level int) bool {
+	if l.occupancy(level) >= l.config.widthAt(level) {
+		return true
+	}
+	if l.config.demand == nil {
+		return false
+	}
+	for d, amount := range l.load(level) {
+		if amount < l.config.capacity[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// occupancy returns the number of slots taken by the nodes of the layer,
+// which is the number of nodes unless WithNodeSize is used.
+func (l *leveler) occupancy(level int) int {
+	if l.config.nodeSize == nil {
+		return len(l.layers[level])
+	}
+	occupied := 0
+	for _, node := range l.layers[level] {
+		occupied += l.config.sizeOf(node)
+	}
+	return occupied
+}
+
+// load returns the total demand of the nodes of the layer per dimension.
+func (l *leveler) load(level int) []int {
+	load := make([]int, len(l.config.capacity))
+	for _, node := range l.layers[level] {
+		// the demand was validated when the node was placed
+		demand, _ := l.config.demandOf(node)
+		for d, amount := range demand {
+			load[d] += amount
+		}
+	}
+	return load
+}
+
+// overCapacity returns the leveled nodes whose demand exceeds the capacity
+// in any dimension, ordered by their level and their position within it.
+func (l *leveler) overCapacity() []Node {
+	over := make([]Node, 0)
+	if l.config.demand == nil {
+		return over
+	}
+	for _, layer := range l.layers {
+		for _, node := range layer {
+			if demand, err := l.config.demandOf(node); err == nil && l.config.exceeds(demand) {
+				over = append(over, node)
+			}
+		}
+	}
+	return over
+}
+
+// notify reports that the layer gained a node to the layer callbacks, and
+// that it is sealed if it reached the width.
+func (l *leveler) notify(level int) {
+	if l.config.onLayer != nil {
+		l.config.onLayer(level, l.config.orderLayer(copyNodes(l.layers[level])))
+	}
+	if !l.sealed[level] && l.full(level) {
+		l.sealed[level] = true
+		if l.config.onSealed != nil {
+			l.config.onSealed(level, l.config.orderLayer(copyNodes(l.layers[level])))
+		}
+	}
+}
+
+// rollback restores the layers to the specified sizes and forgets the levels
+// of the nodes added since.
+func (l *leveler) rollback(sizes []int, added []Node) {
+	for i, size := range sizes {
+		l.layers[i] = l.layers[i][:size]
+	}
+	l.layers = l.layers[:len(sizes)]
+	l.sealed = l.sealed[:len(sizes)]
+	for i := range l.layers {
+		l.sealed[i] = l.sealed[i] && l.full(i)
+	}
+	for _, node := range added {
+		delete(l.levels, node)
+		delete(l.deferred, node)
+	}
+}
+
+// checkConsistency returns the graph's edges between leveled nodes which
+// violate the level ordering, i.e. whose target has a level lower than or
+// equal to the level of their source.
+func (l *leveler) checkConsistency(graph *DirectedGraph) []Edge {
+	violations := make([]Edge, 0)
+	for _, from := range graph.Nodes() {
+		fromLevel, ok := l.levels[from]
+		if !ok {
+			continue
+		}
+		for _, to := range graph.OutgoingEdges(from) {
+			if toLevel, ok := l.levels[to]; ok && toLevel <= fromLevel {
+				violations = append(violations, Edge{from, to})
+			}
+		}
+	}
+	return violations
+}
+
+func (l *leveler) staleLevelError(edge Edge) error {
+	return &StaleLevelError{Edge: edge, FromLevel: l.levels[edge.From], ToLevel: l.levels[edge.To]}
+}
+
+// deferredAt returns the nodes which were eligible for the level but placed
+// on a later one since the level was full, ordered by their level and their
+// position within it.
+func (l *leveler) deferredAt(level int) []Node {
+	deferred := make([]Node, 0)
+	for i := level + 1; i < len(l.layers); i++ {
+		for _, node := range l.layers[i] {
+			if first, ok := l.deferred[node]; ok && first == level {
+				deferred = append(deferred, node)
+			}
+		}
+	}
+	return deferred
+}
+
+// levelOf returns the node's level and whether it was assigned one.
+func (l *leveler) levelOf(node Node) (int, bool) {
+	level, ok := l.levels[node]
+	return level, ok
+}
+
+// pendingCount returns the number of the graph's nodes which were not
+// assigned a level, assuming every leveled node still exists.
+func (l *leveler) pendingCount(graph Directed) int {
+	if pending := graph.NodeCount() - len(l.levels); pending > 0 {
+		return pending
+	}
+	return 0
+}
+
+// pendingNodes returns the graph's nodes which were not assigned a level, in
+// the order a limited sort in progress assigns them, or in the graph's node
+// order otherwise.
+func (l *leveler) pendingNodes(graph Directed) []Node {
+	nodes := graph.Nodes()
+	if g, ok := graph.(*DirectedGraph); ok && l.pending != nil && l.pending.graph == g && l.pending.guard.check() == nil {
+		nodes = l.pending.nodes
+	}
+	pending := make([]Node, 0)
+	for _, node := range nodes {
+		if _, ok := l.levels[node]; !ok {
+			pending = append(pending, node)
+		}
+	}
+	return pending
+}
+
+// pruneBelow forgets the nodes of the layers below the level and empties
+// those layers, returning the nodes in level order. Nodes are never placed
+// below the level afterwards.
+func (l *leveler) pruneBelow(level int) []Node {
+	pruned := make([]Node, 0)
+	for i := 0; i < level && i < len(l.layers); i++ {
+		for _, node := range l.layers[i] {
+			delete(l.levels, node)
+			delete(l.deferred, node)
+			pruned = append(pruned, node)
+		}
+		l.layers[i] = make([]Node, 0)
+	}
+	for node, first := range l.deferred {
+		if first < level {
+			delete(l.deferred, node)
+		}
+	}
+	if level > l.floor {
+		l.floor = level
+	}
+	return pruned
+}
+
+// recompute discards all assignments and levels the graph from scratch,
+// returning the changes compared to the previous levels. If sorting fails the
+// previous assignments are kept. The floor is kept, so that the levels never
+// fall below the base or pruned levels.
+func (l *leveler) recompute(graph *DirectedGraph) (int, []LevelChange, error) {
+	layers, levels, sealed, deferred := l.layers, l.levels, l.sealed, l.deferred
+	l.pending = nil
+	l.layers = make([][]Node, 0)
+	l.levels = make(map[Node]int, len(levels))
+	l.sealed = make([]bool, 0)
+	l.deferred = make(map[Node]int, len(deferred))
+
+	maxLevel, err := l.sort(graph, nil)
+	if err != nil {
+		l.layers, l.levels, l.sealed, l.deferred = layers, levels, sealed, deferred
+		return -1, nil, err
+	}
+
+	changes := make([]LevelChange, 0)
+	for _, node := range graph.Nodes() {
+		level, ok := l.levels[node]
+		if !ok {
+			continue
+		}
+		old, ok := levels[node]
+		if !ok {
+			old = -1
+		}
+		if old != level {
+			changes = append(changes, LevelChange{Node: node, OldLevel: old, NewLevel: level})
+		}
+	}
+	return maxLevel, changes, nil
+}
+
+// rename transfers the old node's level and position within its layer to
+// the new node. It returns whether the old node had a level.
+func (l *leveler) rename(old Node, new Node) bool {
+	level, ok := l.levels[old]
+	if !ok {
+		return false
+	}
+	delete(l.levels, old)
+	l.levels[new] = level
+	if first, ok := l.deferred[old]; ok {
+		delete(l.deferred, old)
+		l.deferred[new] = first
+	}
+	for i, node := range l.layers[level] {
+		if node == old {
+			l.layers[level][i] = new
+			break
+		}
+	}
+	return true
+}
+
+// remove forgets the node's level and removes it from its layer, keeping
+// the order of the remaining nodes. It returns whether the node had a level.
+func (l *leveler) remove(node Node) bool {
+	level, ok := l.levels[node]
+	if !ok {
+		return false
+	}
+	delete(l.levels, node)
+	delete(l.deferred, node)
+
+	layer := l.layers[level]
+	for i, n := range layer {
+		if n == node {
+			copy(layer[i:], layer[i+1:])
+			layer[len(layer)-1] = nil
+			l.layers[level] = layer[:len(layer)-1]
+			l.sealed[level] = false
+			break
+		}
+	}
+	return true
+}
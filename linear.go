@@ -0,0 +1,94 @@
+package graff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OrderViolation describes why an ordering is not a linear extension of a
+// graph, see IsLinearExtension.
+type OrderViolation struct {
+	// Edge is the first edge, in the graph's node order, whose nodes are
+	// listed in the wrong order, if any.
+	Edge *Edge
+	// Missing holds the graph's nodes absent from the ordering, and Extra the
+	// nodes of the ordering absent from the graph or listed more than once.
+	Missing []Node
+	Extra   []Node
+}
+
+func (v *OrderViolation) Error() string {
+	problems := make([]string, 0, 3)
+	if len(v.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("missing %v", v.Missing))
+	}
+	if len(v.Extra) > 0 {
+		problems = append(problems, fmt.Sprintf("extra %v", v.Extra))
+	}
+	if v.Edge != nil {
+		problems = append(problems, fmt.Sprintf("the edge %v -> %v is violated", v.Edge.From, v.Edge.To))
+	}
+	return "The order is not a linear extension: " + strings.Join(problems, ", ")
+}
+
+// IsLinearExtension determines whether the order lists every node of the
+// graph exactly once, with the source of every edge before its target, as
+// the topological sorters do. Otherwise it returns an OrderViolation naming
+// the missing and extra nodes, and the first violated edge among the nodes
+// the order does list.
+func (g *DirectedGraph) IsLinearExtension(order []Node) (bool, *OrderViolation) {
+	return isLinearExtension(g, order)
+}
+
+// isLinearExtension determines whether the order is a linear extension of
+// any graph, see DirectedGraph.IsLinearExtension.
+func isLinearExtension(g Directed, order []Node) (bool, *OrderViolation) {
+	v := &OrderViolation{Missing: make([]Node, 0), Extra: make([]Node, 0)}
+
+	positions := make(map[Node]int, len(order))
+	for i, node := range order {
+		if _, ok := positions[node]; ok || !g.NodeExists(node) {
+			v.Extra = append(v.Extra, node)
+			continue
+		}
+		positions[node] = i
+	}
+	for _, node := range g.Nodes() {
+		if _, ok := positions[node]; !ok {
+			v.Missing = append(v.Missing, node)
+		}
+	}
+
+	for _, from := range g.Nodes() {
+		i, ok := positions[from]
+		if !ok {
+			continue
+		}
+		eachOutgoing(g, from, func(to Node) bool {
+			if j, ok := positions[to]; ok && j <= i {
+				v.Edge = &Edge{from, to}
+				return false
+			}
+			return true
+		})
+		if v.Edge != nil {
+			break
+		}
+	}
+
+	if v.Edge == nil && len(v.Missing) == 0 && len(v.Extra) == 0 {
+		return true, nil
+	}
+	return false, v
+}
+
+// IsLinearExtension determines whether the order lists every event exactly
+// once, after all events it depends on, see DirectedGraph.IsLinearExtension.
+// The violated edge is reported in the event graph's orientation.
+func (g *EventGraph) IsLinearExtension(order []Node) (bool, *OrderViolation) {
+	ok, v := g.DirectedGraph.IsLinearExtension(order)
+	if v != nil && v.Edge != nil {
+		v.Edge = &Edge{v.Edge.To, v.Edge.From}
+	}
+	return ok, v
+}
@@ -0,0 +1,59 @@
+package graff
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestIsLinearExtension(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddNode("d")
+
+	if ok, v := g.IsLinearExtension([]Node{"d", "a", "b", "c"}); !ok {
+		t.Errorf("a valid order was rejected: %v", v)
+	}
+	ok, v := g.IsLinearExtension([]Node{"c", "a", "b", "b", "x"})
+	if ok {
+		t.Fatal("an invalid order was accepted")
+	}
+	if !reflect.DeepEqual(v.Missing, []Node{"d"}) || !reflect.DeepEqual(v.Extra, []Node{"b", "x"}) || *v.Edge != (Edge{"b", "c"}) {
+		t.Errorf("got the violation %+v", v)
+	}
+	want := "The order is not a linear extension: missing [d], extra [b x], the edge b -> c is violated"
+	if v.Error() != want {
+		t.Errorf("got %q, want %q", v.Error(), want)
+	}
+
+	// b depends on a, so the violated edge is reported as b -> a
+	e := NewEventGraph()
+	e.AddEdge("b", "a")
+	if ok, v := e.IsLinearExtension([]Node{"b", "a"}); ok || *v.Edge != (Edge{"b", "a"}) {
+		t.Errorf("got %v, %+v, want the violated edge b -> a", ok, v)
+	}
+}
+
+func TestSortersProduceLinearExtensions(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for round := 0; round < 100; round++ {
+		data := make([]byte, 2*random.Intn(60))
+		random.Read(data)
+		g := fuzzGraph(data)
+
+		dfs, err := g.DFSSort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		kahn, err := NewKahnSorter(g, nil).Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, order := range map[string][]Node{"dfs": dfs, "kahn": kahn} {
+			if ok, v := g.IsLinearExtension(order); !ok {
+				t.Fatalf("%s: %v", name, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package graff
+
+import (
+	"fmt"
+)
+
+// PriorityListScheduler arranges a graph's nodes into layers of at most width
+// nodes by list scheduling: every layer is filled with the nodes whose
+// dependencies were all placed in earlier layers, picking the smallest
+// according to the priority comparison function first (e.g. the earliest
+// deadline). Ties are broken by insertion order.
+// See https://en.wikipedia.org/wiki/List_scheduling
+type PriorityListScheduler struct {
+	graph Directed
+	width int
+	less  func(a, b Node) bool
+}
+
+// NewPriorityListScheduler returns a new list scheduler.
+// The comparison function may be nil.
+func NewPriorityListScheduler(graph Directed, width int, less func(a, b Node) bool) *PriorityListScheduler {
+	return &PriorityListScheduler{
+		graph: directedView(graph),
+		width: width,
+		less:  less,
+	}
+}
+
+// Sort returns the scheduled layers.
+func (s *PriorityListScheduler) Sort() ([][]Node, error) {
+	if s.width <= 0 {
+		return nil, fmt.Errorf("%w: width must be positive, got %d", ErrInvalidOption, s.width)
+	}
+	return listSchedule(s.graph, s.width, s.less)
+}
+
+// listSchedule fills layers of at most width nodes with the ready nodes in
+// order of priority, releasing the dependants of a layer's nodes only once
+// the layer is complete.
+func listSchedule(graph Directed, width int, less func(a, b Node) bool) ([][]Node, error) {
+	ready := newReadyState(graph)
+	queue := newReadyQueue(graph, less)
+	for _, node := range ready.Roots() {
+		queue.Push(node)
+	}
+
+	push := queue.Push
+	count := 0
+	layers := make([][]Node, 0)
+	for queue.Len() > 0 {
+		layer := make([]Node, 0, width)
+		for queue.Len() > 0 && len(layer) < width {
+			layer = append(layer, queue.Pop())
+		}
+		for _, node := range layer {
+			ready.Release(node, push)
+		}
+		layers = append(layers, layer)
+		count += len(layer)
+	}
+
+	if count != graph.NodeCount() {
+		return nil, cycleError(graph)
+	}
+	if err := graphErr(graph); err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
@@ -0,0 +1,54 @@
+package graff
+
+import (
+	"testing"
+)
+
+func TestPriorityListScheduler(t *testing.T) {
+	g := huTree()
+	heights, err := g.NodeHeights()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the critical path first gives the optimal schedule, insertion order
+	// runs the chain after the leaves
+	critical, err := NewPriorityListScheduler(g, 2, func(a, b Node) bool {
+		return heights[a] > heights[b]
+	}).Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(critical) != 5 || critical[0][0] != "c1" {
+		t.Errorf("the critical path schedule is %v, want 5 steps starting with c1", critical)
+	}
+	fifo, err := NewPriorityListScheduler(g, 2, nil).Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fifo) != 6 {
+		t.Errorf("the insertion order schedule is %v, want 6 steps", fifo)
+	}
+}
+
+func TestPriorityListSchedulerValid(t *testing.T) {
+	g := layeredGraph(100, 3)
+	// prefer the nodes which are depended on least
+	pathological := func(a, b Node) bool {
+		return g.OutgoingEdgeCount(a) < g.OutgoingEdgeCount(b)
+	}
+	for _, width := range []int{1, 3, 7} {
+		layers, err := NewPriorityListScheduler(g, width, pathological).Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateLayering(g, layers, width); err != nil {
+			t.Errorf("width %d: %v", width, err)
+		}
+	}
+
+	g.AddEdge(99, 0)
+	if _, err := NewPriorityListScheduler(g, 3, nil).Sort(); err == nil {
+		t.Error("a cyclic graph was scheduled")
+	}
+}
@@ -0,0 +1,217 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to flows.
+var (
+	ErrSourceIsSink = errors.New("The source and the sink must differ")
+	ErrDirectEdge   = errors.New("No set of nodes separates the nodes of an edge")
+)
+
+// flowArc is an arc of the residual network, paired with its reverse arc.
+type flowArc struct {
+	to       int
+	residual float64
+	reverse  int
+	// edge is the graph's edge the arc represents with its capacity, unless
+	// it is a reverse arc
+	edge     *Edge
+	capacity float64
+}
+
+// MaxFlow computes the maximum flow from the source to the sink using the
+// Edmonds-Karp algorithm, returning its value and the edges of a minimum
+// cut, i.e. the saturated edges leading from the nodes still reachable from
+// the source in the residual network to the others, in node order. Their
+// capacities sum up to the flow.
+// The capacity function is called once for every edge; if it is nil edges
+// carrying a weight attribute have its value as capacity, other edges a
+// capacity of 1, see WeightAttr. ErrNegativeWeight is returned if a capacity
+// is negative, an error wrapping ErrNodeNotFound if either endpoint does not
+// exist, and ErrSourceIsSink if they are the same.
+// See https://en.wikipedia.org/wiki/Edmonds–Karp_algorithm
+func (g *DirectedGraph) MaxFlow(source Node, sink Node, capacity func(from Node, to Node) float64) (float64, []Edge, error) {
+	if err := g.checkNodes([]Node{source, sink}); err != nil {
+		return 0, nil, err
+	}
+	if source == sink {
+		return 0, nil, fmt.Errorf("%w: %v", ErrSourceIsSink, source)
+	}
+	if capacity == nil {
+		capacity = g.edgeWeight
+	}
+
+	nodes := g.Nodes()
+	index := make(map[Node]int, len(nodes))
+	for i, node := range nodes {
+		index[node] = i
+	}
+	arcs := make([]flowArc, 0, 2*g.EdgeCount())
+	adjacency := make([][]int, len(nodes))
+	for i, from := range nodes {
+		var err error
+		g.EachOutgoing(from, func(to Node) bool {
+			c := capacity(from, to)
+			if c < 0 {
+				err = ErrNegativeWeight
+				return false
+			}
+			j := index[to]
+			adjacency[i] = append(adjacency[i], len(arcs))
+			arcs = append(arcs, flowArc{to: j, residual: c, reverse: len(arcs) + 1, edge: &Edge{from, to}, capacity: c})
+			adjacency[j] = append(adjacency[j], len(arcs))
+			arcs = append(arcs, flowArc{to: i, residual: 0, reverse: len(arcs) - 1})
+			return true
+		})
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	s, t := index[source], index[sink]
+	// via holds the arc every node was reached by during a search
+	via := make([]int, len(nodes))
+	search := func() bool {
+		for i := range via {
+			via[i] = -1
+		}
+		via[s] = len(arcs)
+		queue := []int{s}
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			for _, a := range adjacency[node] {
+				arc := arcs[a]
+				if arc.residual > 0 && via[arc.to] == -1 {
+					via[arc.to] = a
+					if arc.to == t {
+						return true
+					}
+					queue = append(queue, arc.to)
+				}
+			}
+		}
+		return false
+	}
+
+	flow := 0.0
+	for search() {
+		// find the bottleneck along the shortest augmenting path, then push it
+		bottleneck := arcs[via[t]].residual
+		for node := t; node != s; node = arcs[arcs[via[node]].reverse].to {
+			if r := arcs[via[node]].residual; r < bottleneck {
+				bottleneck = r
+			}
+		}
+		for node := t; node != s; node = arcs[arcs[via[node]].reverse].to {
+			a := via[node]
+			arcs[a].residual -= bottleneck
+			arcs[arcs[a].reverse].residual += bottleneck
+		}
+		flow += bottleneck
+	}
+
+	// the last search marked the nodes reachable from the source
+	cut := make([]Edge, 0)
+	for i := range nodes {
+		if via[i] == -1 {
+			continue
+		}
+		for _, a := range adjacency[i] {
+			if arc := arcs[a]; arc.edge != nil && arc.capacity > 0 && via[arc.to] == -1 {
+				cut = append(cut, *arc.edge)
+			}
+		}
+	}
+	return flow, cut, nil
+}
+
+// MaxFlow computes the maximum flow from the source to the sink along the
+// edges in the event graph's orientation, see DirectedGraph.MaxFlow. The
+// capacity function is called and the minimum cut is returned in the same
+// orientation.
+func (g *EventGraph) MaxFlow(source Node, sink Node, capacity func(from Node, to Node) float64) (float64, []Edge, error) {
+	// flowing from the sink to the source along the reversed edges is
+	// equivalent
+	reversed := g.DirectedGraph.edgeWeight
+	if capacity != nil {
+		reversed = func(from Node, to Node) float64 {
+			return capacity(to, from)
+		}
+	}
+	flow, cut, err := g.DirectedGraph.MaxFlow(sink, source, reversed)
+	if err != nil {
+		return 0, nil, err
+	}
+	for i, edge := range cut {
+		cut[i] = Edge{edge.To, edge.From}
+	}
+	return flow, cut, nil
+}
+
+// splitNode is the entry or exit half of a node split for MinVertexCut.
+type splitNode struct {
+	node Node
+	exit bool
+}
+
+// MinVertexCut returns a smallest set of nodes other than the endpoints
+// whose removal leaves no path from one endpoint to the other, e.g. the
+// number of events which must fail to disconnect them, in node order. It is
+// computed as the maximum flow through the graph with every node split into
+// an entry and an exit joined by an edge of capacity 1.
+// As no node separates the nodes of an edge, an error wrapping ErrDirectEdge
+// is returned if an edge leads from one endpoint to the other. The cut is
+// empty if no path does. An error wrapping ErrNodeNotFound is returned if
+// either endpoint does not exist, and ErrSourceIsSink if they are the same.
+// See https://en.wikipedia.org/wiki/Menger%27s_theorem
+func (g *DirectedGraph) MinVertexCut(from Node, to Node) ([]Node, error) {
+	if err := g.checkNodes([]Node{from, to}); err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, fmt.Errorf("%w: %v", ErrSourceIsSink, from)
+	}
+	if g.EdgeExists(from, to) {
+		return nil, fmt.Errorf("%w: %v -> %v", ErrDirectEdge, from, to)
+	}
+
+	// no cut exceeds the number of nodes, so edges with a larger capacity
+	// are never cut
+	unbounded := float64(g.NodeCount() + 1)
+	split := NewDirectedGraph()
+	for _, node := range g.Nodes() {
+		split.AddEdge(splitNode{node, false}, splitNode{node, true})
+	}
+	for _, node := range g.Nodes() {
+		g.EachOutgoing(node, func(outgoing Node) bool {
+			split.AddEdge(splitNode{node, true}, splitNode{outgoing, false})
+			return true
+		})
+	}
+	_, edges, err := split.MaxFlow(splitNode{from, true}, splitNode{to, false}, func(a Node, b Node) float64 {
+		if !a.(splitNode).exit {
+			return 1
+		}
+		return unbounded
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cut := make([]Node, 0, len(edges))
+	for _, edge := range edges {
+		cut = append(cut, edge.From.(splitNode).node)
+	}
+	return cut, nil
+}
+
+// MinVertexCut returns a smallest set of nodes whose removal leaves no path
+// from one endpoint to the other along the edges in the event graph's
+// orientation, see DirectedGraph.MinVertexCut.
+func (g *EventGraph) MinVertexCut(from Node, to Node) ([]Node, error) {
+	return g.DirectedGraph.MinVertexCut(to, from)
+}
@@ -0,0 +1,133 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// clrsFlow holds the capacities of the flow network from CLRS figure 26.6,
+// whose maximum flow is 23.
+var clrsFlow = map[Edge]float64{
+	{"s", "v1"}: 16, {"s", "v2"}: 13, {"v1", "v3"}: 12, {"v2", "v1"}: 4,
+	{"v2", "v4"}: 14, {"v3", "v2"}: 9, {"v3", "t"}: 20, {"v4", "v3"}: 7,
+	{"v4", "t"}: 4,
+}
+
+func sortedEdges(edges []Edge) []Edge {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From.(string) < edges[j].From.(string)
+		}
+		return edges[i].To.(string) < edges[j].To.(string)
+	})
+	return edges
+}
+
+func TestMaxFlow(t *testing.T) {
+	g := NewDirectedGraph()
+	for edge, capacity := range clrsFlow {
+		g.AddEdge(edge.From, edge.To)
+		g.SetEdgeAttr(edge.From, edge.To, WeightAttr, capacity)
+	}
+	flow, cut, err := g.MaxFlow("s", "t", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flow != 23 {
+		t.Errorf("got the flow %g, want 23", flow)
+	}
+	want := []Edge{{"v1", "v3"}, {"v4", "t"}, {"v4", "v3"}}
+	if !reflect.DeepEqual(sortedEdges(cut), want) {
+		t.Errorf("got the cut %v, want %v", cut, want)
+	}
+
+	if _, _, err := g.MaxFlow("s", "missing", nil); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("got %v, want ErrNodeNotFound", err)
+	}
+	if _, _, err := g.MaxFlow("s", "s", nil); !errors.Is(err, ErrSourceIsSink) {
+		t.Errorf("got %v, want ErrSourceIsSink", err)
+	}
+}
+
+func TestEventGraphMaxFlow(t *testing.T) {
+	g := NewEventGraph()
+	for edge := range clrsFlow {
+		g.AddEdge(edge.From, edge.To)
+	}
+	flow, cut, err := g.MaxFlow("s", "t", func(from Node, to Node) float64 {
+		return clrsFlow[Edge{from, to}]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flow != 23 {
+		t.Errorf("got the flow %g, want 23", flow)
+	}
+	want := []Edge{{"v1", "v3"}, {"v4", "t"}, {"v4", "v3"}}
+	if !reflect.DeepEqual(sortedEdges(cut), want) {
+		t.Errorf("got the cut %v, want it in the orientation of AddEdge %v", cut, want)
+	}
+}
+
+func TestMinVertexCut(t *testing.T) {
+	random := rand.New(rand.NewSource(5))
+	for i := 0; i < 30; i++ {
+		g := NewDirectedGraph()
+		for j := 0; j < 30; j++ {
+			if from, to := random.Intn(10), random.Intn(10); from != to {
+				g.AddEdge(from, to)
+			}
+		}
+		nodes := g.Nodes()
+		from, to := nodes[0], nodes[len(nodes)-1]
+		if g.EdgeExists(from, to) {
+			if _, err := g.MinVertexCut(from, to); !errors.Is(err, ErrDirectEdge) {
+				t.Errorf("got %v for the edge %v -> %v, want ErrDirectEdge", err, from, to)
+			}
+			continue
+		}
+		cut, err := g.MinVertexCut(from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// removing the cut disconnects the endpoints, and no smaller set of
+		// nodes does
+		if !disconnects(g, from, to, cut) {
+			t.Errorf("removing %v leaves a path from %v to %v in %v", cut, from, to, g)
+		}
+		for mask := 0; mask < 1<<len(nodes); mask++ {
+			subset := make([]Node, 0)
+			for k, node := range nodes {
+				if mask&(1<<k) != 0 && node != from && node != to {
+					subset = append(subset, node)
+				}
+			}
+			if len(subset) < len(cut) && disconnects(g, from, to, subset) {
+				t.Errorf("%v disconnects %v from %v, but the cut is %v", subset, from, to, cut)
+				break
+			}
+		}
+	}
+
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddNode("c")
+	if cut, err := g.MinVertexCut("a", "c"); err != nil || len(cut) != 0 {
+		t.Errorf("got %v, %v between unconnected nodes, want the empty cut", cut, err)
+	}
+	if _, err := g.MinVertexCut("a", "b"); !errors.Is(err, ErrDirectEdge) {
+		t.Errorf("got %v, want ErrDirectEdge", err)
+	}
+}
+
+// disconnects determines whether removing the nodes from a copy of the graph
+// leaves no path from one endpoint to the other.
+func disconnects(g *DirectedGraph, from Node, to Node, nodes []Node) bool {
+	c := g.Copy()
+	c.RemoveNodes(nodes...)
+	return !c.reaches(from, to)
+}
@@ -0,0 +1,100 @@
+package graff
+
+// MergeSorterStates combines the levels assigned by two incremental sorters
+// which sorted overlapping parts of the same history, e.g. two replicas of
+// an event graph after a network partition healed, into a new sorter for the
+// union graph g, which must contain every node either sorter assigned.
+//
+// A node assigned by both sorters starts at the higher of its two levels,
+// and a node assigned by one of them at its level there. Visiting the nodes
+// in topological order, a node is then raised as needed to follow every node
+// it depends on, including those neither sorter assigned yet, and further
+// to the first level with room for it, so levels are never lowered. The
+// nodes neither sorter assigned are then leveled as by EventSort, so that
+// every node of g is assigned and the result has no violations for
+// CheckConsistency. Within a layer the nodes are in arrival order in g, and
+// nodes added to g later follow them as with any sorter.
+//
+// The result is configured like a, which should be configured like b, and
+// never places nodes below the base or pruned levels of either.
+// A CyclicGraphError is returned if g contains a cycle, or an error wrapping
+// ErrNodeNotFound if a node assigned by either sorter is missing from g.
+func MergeSorterStates(a *OptimizedCoffmanGrahamSorter, b *OptimizedCoffmanGrahamSorter, g *EventGraph) (*OptimizedCoffmanGrahamSorter, error) {
+	graph := g.DirectedGraph
+	for _, s := range []*OptimizedCoffmanGrahamSorter{a, b} {
+		for node := range s.levels {
+			if !graph.NodeExists(node) {
+				return nil, &NodeNotFoundError{Node: node}
+			}
+		}
+	}
+	nodes, err := graph.LexTopoSort(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := newOptimizedCoffmanGrahamSorter(graph, a.config)
+	l := merged.leveler
+	l.floor = a.floor
+	if b.floor > l.floor {
+		l.floor = b.floor
+	}
+
+	// required holds the lowest level every node may take, including the
+	// nodes which remain unassigned
+	required := make(map[Node]int, len(nodes))
+	for _, node := range nodes {
+		level := l.floor
+		levelA, inA := a.levels[node]
+		levelB, inB := b.levels[node]
+		if inA && levelA > level {
+			level = levelA
+		}
+		if inB && levelB > level {
+			level = levelB
+		}
+		graph.EachIncoming(node, func(dependant Node) bool {
+			if r := required[dependant] + 1; r > level {
+				level = r
+			}
+			return true
+		})
+
+		if inA || inB {
+			for len(l.layers) < level {
+				l.layers = append(l.layers, make([]Node, 0, 1))
+				l.sealed = append(l.sealed, false)
+			}
+			demand, err := l.config.demandOf(node)
+			if err != nil {
+				return nil, err
+			}
+			level = l.place(level-1, l.config.sizeOf(node), demand)
+			l.layers[level] = append(l.layers[level], node)
+			l.levels[node] = level
+			if level > merged.level {
+				merged.level = level
+			}
+		}
+		required[node] = level
+	}
+
+	for i := range l.layers {
+		l.sealed[i] = l.full(i)
+	}
+	maxLevel, err := l.sort(graph, nil)
+	if err != nil {
+		return nil, err
+	}
+	if maxLevel > merged.level {
+		merged.level = maxLevel
+	}
+
+	// EventSort only orders the nodes it assigned, which here may have
+	// arrived before the merged ones
+	for _, layer := range l.layers {
+		l.orderByArrival(graph, layer)
+		l.config.orderLayer(layer)
+	}
+	return merged, nil
+}
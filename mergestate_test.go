@@ -0,0 +1,97 @@
+package graff
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// replicaOf returns the events of the history the replica received, which
+// include the dependencies of every event, added in the history's order.
+func replicaOf(history *EventGraph, rng *rand.Rand) *EventGraph {
+	replica := NewEventGraph()
+	for _, event := range history.Nodes() {
+		if rng.Intn(3) == 0 {
+			continue
+		}
+		complete := true
+		for _, dependency := range history.IncomingEdges(event) {
+			complete = complete && replica.NodeExists(dependency)
+		}
+		if !complete {
+			continue
+		}
+		replica.AddNode(event)
+		for _, dependency := range history.IncomingEdges(event) {
+			replica.AddHappensBefore(dependency, event)
+		}
+	}
+	return replica
+}
+
+func checkArrivalOrder(t *testing.T, g *EventGraph, layers [][]Node, width int) {
+	t.Helper()
+	for i, layer := range layers {
+		if len(layer) > width {
+			t.Errorf("layer %d holds %d nodes, more than %d", i, len(layer), width)
+		}
+		for j := 1; j < len(layer); j++ {
+			a, _ := g.InsertionIndex(layer[j-1])
+			b, _ := g.InsertionIndex(layer[j])
+			if a > b {
+				t.Errorf("layer %d is not in arrival order: %v", i, layer)
+				break
+			}
+		}
+	}
+}
+
+func TestMergeSorterStatesRandomPartitions(t *testing.T) {
+	const width = 3
+	for seed := int64(0); seed < 50; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		history := NewEventGraph()
+		for i := 0; i < 40; i++ {
+			history.AddNode(i)
+			for j := 0; j < 2 && i > 0; j++ {
+				history.AddHappensBefore(rng.Intn(i), i)
+			}
+		}
+
+		replicas := make([]*OptimizedCoffmanGrahamSorter, 2)
+		for i := range replicas {
+			replicas[i] = NewOptimizedCoffmanGrahamSorter(replicaOf(history, rng), width)
+			if _, err := replicas[i].EventSort(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		merged, err := MergeSorterStates(replicas[0], replicas[1], history)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		for _, node := range history.Nodes() {
+			level, ok := merged.LevelOf(node)
+			if !ok {
+				t.Fatalf("seed %d: %v was not assigned", seed, node)
+			}
+			for _, replica := range replicas {
+				if before, ok := replica.LevelOf(node); ok && level < before {
+					t.Errorf("seed %d: %v was lowered from level %d to %d", seed, node, before, level)
+				}
+			}
+		}
+		if violations := merged.CheckConsistency(); len(violations) > 0 {
+			t.Errorf("seed %d: violations %v", seed, violations)
+		}
+		checkArrivalOrder(t, history, merged.layers, width)
+
+		// events arriving after the merge follow the merged ones
+		history.AddHappensBefore(0, 40)
+		history.AddHappensBefore(39, 41)
+		layers, err := merged.EventSort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkArrivalOrder(t, history, layers, width)
+	}
+}
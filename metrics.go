@@ -0,0 +1,109 @@
+package graff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LayeringMetricsResult describes the quality of a layering.
+type LayeringMetricsResult struct {
+	// TotalSpan is the sum of the number of layers spanned by every edge.
+	TotalSpan int
+	// LongEdges is the number of edges spanning more than one layer.
+	LongEdges int
+	// Crossings holds the number of crossings between the edges connecting
+	// every layer to the next one, so it has one entry less than there are
+	// layers. Long edges are not taken into account.
+	Crossings []int
+	// TotalCrossings is the sum of Crossings.
+	TotalCrossings int
+}
+
+// String summarizes the metrics.
+func (m LayeringMetricsResult) String() string {
+	return fmt.Sprintf("total span %d, %d long edges, %d crossings %v", m.TotalSpan, m.LongEdges, m.TotalCrossings, m.Crossings)
+}
+
+// LayeringMetrics computes metrics of the layering of the graph's nodes, as
+// returned by CoffmanGrahamSort or LongestPathLayering, to compare layering
+// strategies. Edges between nodes missing from the layers are left out.
+func LayeringMetrics(g Directed, layers [][]Node) LayeringMetricsResult {
+	g = directedView(g)
+	positions := make(map[Node][2]int, g.NodeCount())
+	for layer, nodes := range layers {
+		for i, node := range nodes {
+			positions[node] = [2]int{layer, i}
+		}
+	}
+
+	m := LayeringMetricsResult{Crossings: make([]int, 0)}
+	// the edges between every layer and the next as pairs of the indices of
+	// their endpoints within the two layers
+	between := make([][][2]int, 0)
+	for i := 1; i < len(layers); i++ {
+		m.Crossings = append(m.Crossings, 0)
+		between = append(between, make([][2]int, 0))
+	}
+
+	for _, from := range g.Nodes() {
+		p, ok := positions[from]
+		if !ok {
+			continue
+		}
+		eachOutgoing(g, from, func(to Node) bool {
+			q, ok := positions[to]
+			if !ok {
+				return true
+			}
+			span := q[0] - p[0]
+			if span < 0 {
+				span = -span
+			}
+			m.TotalSpan += span
+			if span > 1 {
+				m.LongEdges++
+			}
+			if span == 1 {
+				upper, lower := p, q
+				if q[0] < p[0] {
+					upper, lower = q, p
+				}
+				between[upper[0]] = append(between[upper[0]], [2]int{upper[1], lower[1]})
+			}
+			return true
+		})
+	}
+
+	for i, edges := range between {
+		m.Crossings[i] = countCrossings(edges, len(layers[i+1]))
+		m.TotalCrossings += m.Crossings[i]
+	}
+	return m
+}
+
+// countCrossings counts the pairs of edges between two layers which cross,
+// i.e. whose order in the upper layer is the reverse of their order in the
+// lower one, using a Fenwick tree over the positions in the lower layer.
+func countCrossings(edges [][2]int, width int) int {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+
+	tree := make([]int, width+1)
+	crossings := 0
+	for seen, edge := range edges {
+		// the edges seen so far ending to the right of this one cross it
+		notRight := 0
+		for i := edge[1] + 1; i > 0; i -= i & -i {
+			notRight += tree[i]
+		}
+		crossings += seen - notRight
+		for i := edge[1] + 1; i <= width; i += i & -i {
+			tree[i]++
+		}
+	}
+	return crossings
+}
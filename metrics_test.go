@@ -0,0 +1,32 @@
+package graff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLayeringMetrics(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "d")
+	g.AddEdge("b", "c") // crosses a->d
+	g.AddEdge("a", "c") // shares an endpoint with both, crossing neither
+	g.AddEdge("c", "e")
+	g.AddEdge("d", "e")
+	g.AddEdge("a", "e") // spans two layers
+	layers := [][]Node{{"a", "b"}, {"c", "d"}, {"e"}}
+
+	m := LayeringMetrics(g, layers)
+	want := LayeringMetricsResult{TotalSpan: 7, LongEdges: 1, Crossings: []int{1, 0}, TotalCrossings: 1}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %+v, want %+v", m, want)
+	}
+	if got, want := m.String(), "total span 7, 1 long edges, 1 crossings [1 0]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// swapping c and d removes the crossing
+	m = LayeringMetrics(g, [][]Node{{"a", "b"}, {"d", "c"}, {"e"}})
+	if !reflect.DeepEqual(m.Crossings, []int{0, 0}) || m.TotalSpan != 7 {
+		t.Errorf("got %+v after swapping c and d, want no crossings", m)
+	}
+}
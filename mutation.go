@@ -0,0 +1,120 @@
+package graff
+
+// MutationOp identifies the kind of mutation reported by a MutationEvent.
+type MutationOp int
+
+// The mutations reported to observers, see OnMutate.
+const (
+	MutationAddNode MutationOp = iota
+	MutationAddEdge
+	MutationRemoveEdge
+	MutationRemoveNode
+	MutationRemoveTransitives
+	MutationReplaceNode
+)
+
+var mutationOpNames = []string{"AddNode", "AddEdge", "RemoveEdge", "RemoveNode", "RemoveTransitives", "ReplaceNode"}
+
+func (op MutationOp) String() string {
+	if op < 0 || int(op) >= len(mutationOpNames) {
+		return "Unknown"
+	}
+	return mutationOpNames[op]
+}
+
+// MutationEvent describes a mutation of a graph.
+// Nodes holds the nodes which were added or removed, and Edges the edges
+// which were added or removed, including the edges removed together with
+// removed nodes. For a replacement Nodes holds the old and the new node.
+// Edges are reported in the orientation of the underlying DirectedGraph,
+// which is reversed for an EventGraph.
+type MutationEvent struct {
+	Op    MutationOp
+	Nodes []Node
+	Edges []Edge
+}
+
+// Subscription is the handle of an observer registered by OnMutate.
+type Subscription struct {
+	observers *observerList
+	observer  *observer
+}
+
+// Unsubscribe stops the observer from being notified of further mutations.
+// Calling it more than once has no effect.
+func (s *Subscription) Unsubscribe() {
+	s.observers.remove(s.observer)
+}
+
+// OnMutate registers a function which is called synchronously after every
+// mutation of the graph took effect, in the order the observers were
+// registered. Mutations without effect, e.g. adding an existing node, are not
+// reported. Should an observer panic the graph is left intact, the remaining
+// observers are still notified and the panic is then propagated to the
+// caller of the mutation.
+// Copies of the graph do not inherit its observers.
+func (g *DirectedGraph) OnMutate(fn func(MutationEvent)) *Subscription {
+	if g.observers == nil {
+		g.observers = &observerList{}
+	}
+	return g.observers.add(fn)
+}
+
+// observing determines whether any observer needs to be notified, so that
+// the mutations only collect the affected nodes and edges if so.
+func (g *DirectedGraph) observing() bool {
+	return g.observers != nil && len(g.observers.observers) > 0
+}
+
+// notify reports the event to the observers unless it is empty.
+func (g *DirectedGraph) notify(op MutationOp, nodes []Node, edges []Edge) {
+	if !g.observing() || (len(nodes) == 0 && len(edges) == 0) {
+		return
+	}
+	g.observers.notify(MutationEvent{Op: op, Nodes: nodes, Edges: edges})
+}
+
+type observer struct {
+	fn func(MutationEvent)
+}
+
+// observerList holds the registered observers. The slice is replaced rather
+// than modified, so that observers may unsubscribe while being notified.
+type observerList struct {
+	observers []*observer
+}
+
+func (l *observerList) add(fn func(MutationEvent)) *Subscription {
+	o := &observer{fn: fn}
+	observers := make([]*observer, len(l.observers), len(l.observers)+1)
+	copy(observers, l.observers)
+	l.observers = append(observers, o)
+	return &Subscription{observers: l, observer: o}
+}
+
+func (l *observerList) remove(o *observer) {
+	observers := make([]*observer, 0, len(l.observers))
+	for _, registered := range l.observers {
+		if registered != o {
+			observers = append(observers, registered)
+		}
+	}
+	l.observers = observers
+}
+
+func (l *observerList) notify(event MutationEvent) {
+	var recovered interface{}
+	for _, o := range l.observers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil && recovered == nil {
+					recovered = r
+				}
+			}()
+			o.fn(event)
+		}()
+	}
+	if recovered != nil {
+		panic(recovered)
+	}
+}
@@ -0,0 +1,75 @@
+package graff
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestOnMutate(t *testing.T) {
+	g := NewDirectedGraph()
+	var events []string
+	sub := g.OnMutate(func(event MutationEvent) {
+		events = append(events, fmt.Sprint(event.Op, " ", event.Nodes, " ", event.Edges))
+	})
+
+	g.AddNode("a")
+	g.AddNode("a")
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("a", "c")
+	g.RemoveTransitives()
+	g.RemoveEdge("a", "b")
+	g.RemoveNode("c")
+	want := []string{
+		"AddNode [a] []",
+		"AddNode [b] []",
+		"AddEdge [] [{a b}]",
+		"AddNode [c] []",
+		"AddEdge [] [{b c}]",
+		"AddEdge [] [{a c}]",
+		"RemoveTransitives [] [{a c}]",
+		"RemoveEdge [] [{a b}]",
+		"RemoveNode [c] [{b c}]",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("got the events\n%v\nwant\n%v", events, want)
+	}
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	events = nil
+	g.AddNode("d")
+	if events != nil {
+		t.Errorf("an unsubscribed observer got %v", events)
+	}
+}
+
+func TestOnMutatePanic(t *testing.T) {
+	g := NewDirectedGraph()
+	g.OnMutate(func(MutationEvent) {
+		panic("observer failed")
+	})
+	notified := 0
+	g.OnMutate(func(MutationEvent) {
+		notified++
+	})
+
+	func() {
+		defer func() {
+			if r := recover(); r != "observer failed" {
+				t.Errorf("recovered %v, want the observer's panic", r)
+			}
+		}()
+		g.AddEdge("a", "b")
+	}()
+	if notified != 1 {
+		t.Errorf("the second observer was notified %d times, want once", notified)
+	}
+	if !g.EdgeExists("a", "b") || g.NodeCount() != 2 {
+		t.Error("the edge was not added")
+	}
+	if err := g.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,32 +1,66 @@
 package graff
 
+import (
+	"fmt"
+	"sort"
+)
+
 // Node represents a graph node.
 type Node = interface{}
 
+// nodeList keeps the nodes in insertion order. Membership is held by the
+// nodeIndex shared with the graph's edges, so that every node is hashed in a
+// single map, and seqs holds the insertion sequence number of every ID,
+// which is never reused.
 type nodeList struct {
 	nodes []Node
-	set   map[Node]bool
+	index *nodeIndex
+	seqs  []int
+	next  int
+	// added and removed count the nodes ever added and removed, see Checkpoint
+	added   int
+	removed int
 }
 
-func newNodeList() *nodeList {
+func newNodeList(index *nodeIndex) *nodeList {
 	return &nodeList{
 		nodes: make([]Node, 0),
-		set:   make(map[Node]bool),
+		index: index,
+		seqs:  make([]int, 0),
 	}
 }
 
-func (l *nodeList) Copy() *nodeList {
+// Copy returns a copy of the list using the index, which must be a copy of
+// the list's index.
+func (l *nodeList) Copy(index *nodeIndex) *nodeList {
 	nodes := make([]Node, len(l.nodes))
 	copy(nodes, l.nodes)
 
-	set := make(map[Node]bool, len(nodes))
-	for _, node := range nodes {
-		set[node] = true
-	}
+	seqs := make([]int, len(l.seqs))
+	copy(seqs, l.seqs)
 
 	return &nodeList{
-		nodes: nodes,
-		set:   set,
+		nodes:   nodes,
+		index:   index,
+		seqs:    seqs,
+		next:    l.next,
+		added:   l.added,
+		removed: l.removed,
+	}
+}
+
+// grow ensures there is room for n more nodes without reallocating. The
+// index is grown by the edges sharing it.
+func (l *nodeList) grow(n int) {
+	if cap(l.nodes)-len(l.nodes) < n {
+		nodes := make([]Node, len(l.nodes), len(l.nodes)+n)
+		copy(nodes, l.nodes)
+		l.nodes = nodes
+	}
+	if cap(l.seqs)-len(l.seqs) < n {
+		seqs := make([]int, len(l.seqs), len(l.seqs)+n)
+		copy(seqs, l.seqs)
+		l.seqs = seqs
 	}
 }
 
@@ -39,37 +73,248 @@ func (l *nodeList) Count() int {
 }
 
 func (l *nodeList) Exists(node Node) bool {
-	_, ok := l.set[node]
+	_, ok := l.index.Lookup(node)
 	return ok
 }
 
+// Sequence returns the node's insertion sequence number and whether it is
+// listed.
+func (l *nodeList) Sequence(node Node) (int, bool) {
+	if id, ok := l.index.Lookup(node); ok {
+		return l.seqs[id], true
+	}
+	return 0, false
+}
+
+func (l *nodeList) validate() error {
+	seen := make(map[Node]bool, len(l.nodes))
+	for _, node := range l.nodes {
+		if seen[node] {
+			return fmt.Errorf("%v is listed twice", node)
+		}
+		id, ok := l.index.Lookup(node)
+		if !ok {
+			return fmt.Errorf("%v is missing from the index", node)
+		}
+		if int(id) >= len(l.seqs) || l.seqs[id] < 0 || l.seqs[id] >= l.next {
+			return fmt.Errorf("%v has an invalid sequence number", node)
+		}
+		seen[node] = true
+	}
+	if len(seen) != len(l.index.ids) {
+		return fmt.Errorf("the index holds %d nodes but the list %d", len(l.index.ids), len(seen))
+	}
+	return nil
+}
+
 func (l *nodeList) Add(nodes ...Node) {
 	for _, node := range nodes {
 		if l.Exists(node) {
 			continue
 		}
 
+		id := l.index.Intern(node)
+		for int(id) >= len(l.seqs) {
+			l.seqs = append(l.seqs, 0)
+		}
+		l.seqs[id] = l.next
 		l.nodes = append(l.nodes, node)
-		l.set[node] = true
+		l.next++
+		l.added++
 	}
 }
 
+// Remove removes the nodes in a single pass over the list, keeping the
+// order of the remaining nodes, and releases their IDs, so their edges must
+// have been removed.
 func (l *nodeList) Remove(nodes ...Node) {
-	for i := len(l.nodes) - 1; i >= 0; i-- {
-		for j, node := range nodes {
-			if l.nodes[i] == node {
-				copy(l.nodes[i:], l.nodes[i+1:])
-				l.nodes[len(l.nodes)-1] = nil
-				l.nodes = l.nodes[:len(l.nodes)-1]
+	removed := make(map[Node]bool, len(nodes))
+	for _, node := range nodes {
+		if _, ok := l.index.Release(node); ok {
+			removed[node] = true
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	l.removed += len(removed)
+
+	kept := l.nodes[:0]
+	for _, node := range l.nodes {
+		if !removed[node] {
+			kept = append(kept, node)
+		}
+	}
+	for i := len(kept); i < len(l.nodes); i++ {
+		l.nodes[i] = nil
+	}
+	l.nodes = kept
+}
+
+// Replace substitutes the new node for the old one at its position, keeping
+// its ID and sequence number, returning whether the old node was listed. The
+// caller renames the node within the index beforehand. The list is ordered
+// by sequence number, so the position is found by a binary search.
+func (l *nodeList) Replace(old Node, new Node) bool {
+	id, ok := l.index.Lookup(new)
+	if !ok {
+		return false
+	}
+	seq := l.seqs[id]
+	i := sort.Search(len(l.nodes), func(i int) bool {
+		// the old node is no longer interned
+		if l.nodes[i] == old {
+			return true
+		}
+		other, _ := l.index.Lookup(l.nodes[i])
+		return l.seqs[other] >= seq
+	})
+	if i == len(l.nodes) || l.nodes[i] != old {
+		return false
+	}
+	l.nodes[i] = new
+	return true
+}
+
+// copyNodes returns a copy of the slice which is safe to iterate while
+// mutating the graph the original was taken from.
+func copyNodes(nodes []Node) []Node {
+	result := make([]Node, len(nodes))
+	copy(result, nodes)
+	return result
+}
+
+// nodeIndex interns nodes to dense integer IDs so that adjacency can be
+// stored compactly and hashed cheaply. It is shared by the nodes and edges
+// of a graph and holds every node of it. IDs released by removed nodes are
+// reused by subsequently interned nodes.
+type nodeIndex struct {
+	ids   map[Node]int32
+	nodes []Node
+	free  []int32
+}
+
+func newNodeIndex() *nodeIndex {
+	return &nodeIndex{
+		ids:   make(map[Node]int32),
+		nodes: make([]Node, 0),
+		free:  make([]int32, 0),
+	}
+}
 
-				delete(l.set, node)
+func (x *nodeIndex) Copy() *nodeIndex {
+	ids := make(map[Node]int32, len(x.ids))
+	for node, id := range x.ids {
+		ids[node] = id
+	}
+
+	nodes := make([]Node, len(x.nodes))
+	copy(nodes, x.nodes)
 
-				copy(nodes[j:], nodes[j+1:])
-				nodes[len(nodes)-1] = nil
-				nodes = nodes[:len(nodes)-1]
+	free := make([]int32, len(x.free))
+	copy(free, x.free)
 
-				break
-			}
+	return &nodeIndex{
+		ids:   ids,
+		nodes: nodes,
+		free:  free,
+	}
+}
+
+// grow ensures there is room for n more IDs without reallocating.
+func (x *nodeIndex) grow(n int) {
+	if cap(x.nodes)-len(x.nodes) < n {
+		nodes := make([]Node, len(x.nodes), len(x.nodes)+n)
+		copy(nodes, x.nodes)
+		x.nodes = nodes
+
+		ids := make(map[Node]int32, len(x.ids)+n)
+		for node, id := range x.ids {
+			ids[node] = id
+		}
+		x.ids = ids
+	}
+}
+
+// Lookup returns the node's ID and whether it was interned.
+func (x *nodeIndex) Lookup(node Node) (int32, bool) {
+	id, ok := x.ids[node]
+	return id, ok
+}
+
+// Intern returns the node's ID, assigning it one if it has none.
+func (x *nodeIndex) Intern(node Node) int32 {
+	if id, ok := x.ids[node]; ok {
+		return id
+	}
+
+	var id int32
+	if n := len(x.free); n > 0 {
+		id = x.free[n-1]
+		x.free = x.free[:n-1]
+		x.nodes[id] = node
+	} else {
+		id = int32(len(x.nodes))
+		x.nodes = append(x.nodes, node)
+	}
+	x.ids[node] = id
+	return id
+}
+
+// Rename transfers the old node's ID to the new node, which must not be
+// interned, returning whether the old node was interned.
+func (x *nodeIndex) Rename(old Node, new Node) bool {
+	id, ok := x.ids[old]
+	if !ok {
+		return false
+	}
+	delete(x.ids, old)
+	x.ids[new] = id
+	x.nodes[id] = new
+	return true
+}
+
+// Node returns the node with the ID.
+func (x *nodeIndex) Node(id int32) Node {
+	return x.nodes[id]
+}
+
+// Release forgets the node's ID so that it can be reused, returning the
+// released ID and whether the node was interned.
+func (x *nodeIndex) Release(node Node) (int32, bool) {
+	id, ok := x.ids[node]
+	if !ok {
+		return 0, false
+	}
+	delete(x.ids, node)
+	x.nodes[id] = nil
+	x.free = append(x.free, id)
+	return id, true
+}
+
+// Len returns the number of IDs in use or free.
+func (x *nodeIndex) Len() int {
+	return len(x.nodes)
+}
+
+func (x *nodeIndex) validate() error {
+	free := make(map[int32]bool, len(x.free))
+	for _, id := range x.free {
+		if id < 0 || int(id) >= len(x.nodes) || free[id] {
+			return fmt.Errorf("invalid free ID %d", id)
+		}
+		if x.nodes[id] != nil {
+			return fmt.Errorf("free ID %d is still held by %v", id, x.nodes[id])
+		}
+		free[id] = true
+	}
+	if len(x.ids)+len(free) != len(x.nodes) {
+		return fmt.Errorf("%d IDs are interned and %d free but %d exist", len(x.ids), len(free), len(x.nodes))
+	}
+	for node, id := range x.ids {
+		if id < 0 || int(id) >= len(x.nodes) || x.nodes[id] != node {
+			return fmt.Errorf("%v is interned as %d which maps to another node", node, id)
 		}
 	}
+	return nil
 }
@@ -0,0 +1,428 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Errors relating to sorter options.
+var (
+	ErrInvalidOption = errors.New("The sorter options are invalid")
+	ErrInvalidDemand = errors.New("The demand of a node does not match the capacities")
+)
+
+// SorterOption configures a Coffman-Graham sorter.
+type SorterOption func(*sorterConfig) error
+
+type sorterConfig struct {
+	width     int
+	widthSet  bool
+	widthFunc func(level int) int
+	tracer    func(node Node, level int)
+	ordering  func(graph *DirectedGraph) TopologicalSorter
+	tieBreak  func(a, b Node) bool
+	strict    bool
+	onLayer   func(level int, nodes []Node)
+	onSealed  func(level int, nodes []Node)
+	// skipReduction levels the graph itself rather than a transitively
+	// reduced copy
+	skipReduction bool
+	// keepEdge protects edges from the transitive reduction
+	keepEdge func(from Node, to Node) bool
+	// nodeSize returns the number of slots a node takes within its layer
+	nodeSize func(node Node) int
+	// capacity bounds the total demand of the nodes of a layer per
+	// dimension, as returned by demand
+	capacity []int
+	demand   func(node Node) []int
+	// layerOrder sorts the nodes within every layer
+	layerOrder func(a, b Node) bool
+	// baseLevel is the level of the first layer
+	baseLevel int
+	// formatLimit is the maximum number of nodes per layer rendered by
+	// String, unless it is zero
+	formatLimit int
+	// rootOrder and rootLess order the nodes the depth-first searches start
+	// from
+	rootOrder []Node
+	rootLess  func(a, b Node) bool
+}
+
+// applySorterOptions returns the configuration set by the options, without
+// validating it as a whole.
+func applySorterOptions(opts []SorterOption) (*sorterConfig, error) {
+	config := &sorterConfig{}
+	for _, opt := range opts {
+		if err := opt(config); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+func newSorterConfig(opts []SorterOption) (*sorterConfig, error) {
+	config, err := applySorterOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.widthSet && config.widthFunc != nil {
+		return nil, fmt.Errorf("%w: WithWidth and WithWidthFunc are mutually exclusive", ErrInvalidOption)
+	}
+	if config.ordering != nil && config.tieBreak != nil {
+		return nil, fmt.Errorf("%w: WithTopologicalSorter and WithTieBreak are mutually exclusive", ErrInvalidOption)
+	}
+	if (config.ordering != nil || config.tieBreak != nil) && (config.rootOrder != nil || config.rootLess != nil) {
+		return nil, fmt.Errorf("%w: WithRootOrder and WithRootLess only apply to the default ordering", ErrInvalidOption)
+	}
+	if !config.widthSet && config.widthFunc == nil && config.demand == nil {
+		return nil, fmt.Errorf("%w: a width is required, use WithWidth, WithWidthFunc or WithCapacities", ErrInvalidOption)
+	}
+	return config, nil
+}
+
+// widthAt returns the maximum number of nodes the layer may hold, which is
+// unbounded if only capacities were configured.
+func (c *sorterConfig) widthAt(level int) int {
+	if c.widthFunc != nil {
+		return c.widthFunc(level)
+	}
+	if !c.widthSet {
+		return math.MaxInt32
+	}
+	return c.width
+}
+
+// sizeOf returns the number of slots the node takes within its layer.
+func (c *sorterConfig) sizeOf(node Node) int {
+	if c.nodeSize == nil {
+		return 1
+	}
+	if size := c.nodeSize(node); size > 0 {
+		return size
+	}
+	return 0
+}
+
+// demandOf returns the node's demand per dimension, negative demands taken as
+// zero, or nil if no capacities were configured. An error wrapping
+// ErrInvalidDemand is returned if it has the wrong number of dimensions.
+func (c *sorterConfig) demandOf(node Node) ([]int, error) {
+	if c.demand == nil {
+		return nil, nil
+	}
+	demand := c.demand(node)
+	if len(demand) != len(c.capacity) {
+		return nil, fmt.Errorf("%w: node %v has a demand of %d dimensions, expected %d", ErrInvalidDemand, node, len(demand), len(c.capacity))
+	}
+	clamped := make([]int, len(demand))
+	for d, amount := range demand {
+		if amount > 0 {
+			clamped[d] = amount
+		}
+	}
+	return clamped, nil
+}
+
+// exceeds determines whether the demand exceeds the capacity in any
+// dimension.
+func (c *sorterConfig) exceeds(demand []int) bool {
+	for d, amount := range demand {
+		if amount > c.capacity[d] {
+			return true
+		}
+	}
+	return false
+}
+
+// orderLayer sorts the layer in place by the configured comparison function,
+// if any, returning it.
+func (c *sorterConfig) orderLayer(layer []Node) []Node {
+	if c.layerOrder != nil {
+		sort.SliceStable(layer, func(i, j int) bool {
+			return c.layerOrder(layer[i], layer[j])
+		})
+	}
+	return layer
+}
+
+// trace reports the level assignment of the node to the tracer, if any.
+func (c *sorterConfig) trace(node Node, level int) {
+	if c.tracer != nil {
+		c.tracer(node, level)
+	}
+}
+
+// topologicalSort orders the graph's nodes using the configured sorter, or
+// the lexicographic sorter given a tie-break, defaulting to the depth-first
+// search sorter.
+func (c *sorterConfig) topologicalSort(graph *DirectedGraph) ([]Node, error) {
+	if c.ordering != nil {
+		return c.ordering(graph).Sort()
+	}
+	if c.tieBreak != nil {
+		return graph.LexTopoSort(c.tieBreak)
+	}
+	return newDFSSorter(graph, c).Sort()
+}
+
+// WithWidth limits every layer to at most width nodes.
+func WithWidth(width int) SorterOption {
+	return func(c *sorterConfig) error {
+		if width <= 0 {
+			return fmt.Errorf("%w: width must be positive, got %d", ErrInvalidOption, width)
+		}
+		if c.widthSet {
+			return fmt.Errorf("%w: WithWidth specified more than once", ErrInvalidOption)
+		}
+		c.width = width
+		c.widthSet = true
+		return nil
+	}
+}
+
+// WithWidthFunc limits each layer to the number of nodes returned by fn for
+// the layer's level, allowing the width to vary between layers.
+func WithWidthFunc(fn func(level int) int) SorterOption {
+	return func(c *sorterConfig) error {
+		if fn == nil {
+			return fmt.Errorf("%w: WithWidthFunc requires a non-nil function", ErrInvalidOption)
+		}
+		c.widthFunc = fn
+		return nil
+	}
+}
+
+// WithTracer registers a function which is called every time the sorter
+// assigns a node to a level.
+func WithTracer(fn func(node Node, level int)) SorterOption {
+	return func(c *sorterConfig) error {
+		if fn == nil {
+			return fmt.Errorf("%w: WithTracer requires a non-nil function", ErrInvalidOption)
+		}
+		c.tracer = fn
+		return nil
+	}
+}
+
+// WithTopologicalSorter replaces the depth-first search used to produce the
+// initial node ordering fed to the leveler. The factory is called with the
+// transitively reduced copy of the graph (or the graph itself, see
+// WithoutTransitiveReduction) every time the sorter runs, and the ordering it
+// returns must be a valid topological order of that graph.
+func WithTopologicalSorter(factory func(graph *DirectedGraph) TopologicalSorter) SorterOption {
+	return func(c *sorterConfig) error {
+		if factory == nil {
+			return fmt.Errorf("%w: WithTopologicalSorter requires a non-nil factory", ErrInvalidOption)
+		}
+		c.ordering = factory
+		return nil
+	}
+}
+
+// WithTieBreak orders the nodes fed to the leveler by the lexicographic
+// topological sort under the comparison function, see LexTopoSort, so that
+// among the nodes whose dependencies were all leveled the smallest is
+// leveled first, and so placed in the lowest layer with room. The order of
+// the edges always takes precedence over the comparison function, e.g. a
+// node with an earlier timestamp may still be placed above its dependency.
+// It is mutually exclusive with WithTopologicalSorter.
+func WithTieBreak(less func(a, b Node) bool) SorterOption {
+	return func(c *sorterConfig) error {
+		if less == nil {
+			return fmt.Errorf("%w: WithTieBreak requires a non-nil function", ErrInvalidOption)
+		}
+		c.tieBreak = less
+		return nil
+	}
+}
+
+// WithStrictOrdering makes sorting fail with a StaleLevelError, leaving the
+// levels unchanged, when an edge was added to a node which had already been
+// assigned a level, and the stable level would violate the level ordering.
+// Without it the stale level is kept silently, see CheckConsistency.
+func WithStrictOrdering() SorterOption {
+	return func(c *sorterConfig) error {
+		c.strict = true
+		return nil
+	}
+}
+
+// WithLayerCallback registers a function which is called synchronously, in
+// sort order, every time a layer gains a node, with the layer's level and a
+// copy of its nodes. Should the sort fail, the assignments already reported
+// are undone.
+func WithLayerCallback(fn func(level int, nodes []Node)) SorterOption {
+	return func(c *sorterConfig) error {
+		if fn == nil {
+			return fmt.Errorf("%w: WithLayerCallback requires a non-nil function", ErrInvalidOption)
+		}
+		c.onLayer = fn
+		return nil
+	}
+}
+
+// WithSealedLayerCallback registers a function which is called synchronously,
+// in sort order, once a layer can no longer receive nodes because it reached
+// the width, with the layer's level and a copy of its nodes. As any later
+// node without dependencies may fill a gap in an earlier layer, a layer is
+// never sealed before it is full. Should a node be removed from a sealed
+// layer, the layer is sealed again once it is refilled.
+func WithSealedLayerCallback(fn func(level int, nodes []Node)) SorterOption {
+	return func(c *sorterConfig) error {
+		if fn == nil {
+			return fmt.Errorf("%w: WithSealedLayerCallback requires a non-nil function", ErrInvalidOption)
+		}
+		c.onSealed = fn
+		return nil
+	}
+}
+
+// WithoutTransitiveReduction skips copying the graph and removing its
+// transitive edges before leveling it, which dominates the cost of sorting.
+// The graph is then leveled directly, without being modified. This is only
+// equivalent for graphs without transitive edges: otherwise the redundant
+// edges change the initial node ordering, so levels may differ.
+func WithoutTransitiveReduction() SorterOption {
+	return func(c *sorterConfig) error {
+		c.skipReduction = true
+		return nil
+	}
+}
+
+// WithKeepEdges protects the edges the keep function reports from the
+// transitive reduction performed before leveling, see
+// RemoveTransitivesExcept. As the protected edges remain in the leveled
+// graph, they can influence the initial node ordering and so the levels.
+// The function is called with edges in the orientation of the DirectedGraph
+// being sorted.
+func WithKeepEdges(keep func(from Node, to Node) bool) SorterOption {
+	return func(c *sorterConfig) error {
+		if keep == nil {
+			return fmt.Errorf("%w: WithKeepEdges requires a non-nil function", ErrInvalidOption)
+		}
+		c.keepEdge = keep
+		return nil
+	}
+}
+
+// WithNodeSize makes every node take as many slots of the width of its
+// layer as the size function returns for it, rather than one, e.g. for big
+// nodes or nodes standing in for groups of nodes, so that the sum of the
+// sizes of a layer's nodes never exceeds the width. A node larger than the
+// width is placed in a new layer of its own. Nodes of size zero, and
+// negative sizes are taken as zero, fit into any layer following their
+// dependencies, even one which was reported as sealed.
+func WithNodeSize(size func(node Node) int) SorterOption {
+	return func(c *sorterConfig) error {
+		if size == nil {
+			return fmt.Errorf("%w: WithNodeSize requires a non-nil function", ErrInvalidOption)
+		}
+		c.nodeSize = size
+		return nil
+	}
+}
+
+// WithCapacities bounds every layer by a capacity in each of dims resource
+// dimensions, e.g. CPU slots and memory, so that a node only fits into a
+// layer if adding its demand, as returned by the demand function, keeps the
+// total demand of the layer's nodes within the capacity in every dimension.
+// Negative demands are taken as zero. A width may still be given to also
+// bound the number of nodes, otherwise it is unbounded. A layer is sealed
+// once it reached the width or every capacity.
+// A node whose demand exceeds the capacity in any dimension is placed in a
+// new layer of its own and reported by OverCapacity, and sorting fails with
+// an error wrapping ErrInvalidDemand if the demand function returns the wrong
+// number of dimensions.
+func WithCapacities(dims int, capacity []int, demand func(node Node) []int) SorterOption {
+	return func(c *sorterConfig) error {
+		if dims <= 0 {
+			return fmt.Errorf("%w: dimensions must be positive, got %d", ErrInvalidOption, dims)
+		}
+		if len(capacity) != dims {
+			return fmt.Errorf("%w: %d capacities given for %d dimensions", ErrInvalidOption, len(capacity), dims)
+		}
+		for d, amount := range capacity {
+			if amount <= 0 {
+				return fmt.Errorf("%w: capacity must be positive, got %d in dimension %d", ErrInvalidOption, amount, d)
+			}
+		}
+		if demand == nil {
+			return fmt.Errorf("%w: WithCapacities requires a non-nil demand function", ErrInvalidOption)
+		}
+		c.capacity = make([]int, dims)
+		copy(c.capacity, capacity)
+		c.demand = demand
+		return nil
+	}
+}
+
+// WithLayerOrder sorts the nodes within every layer by the comparison
+// function, rather than listing them in the order they were assigned, before
+// the layers are returned or reported to callbacks. Ties keep their order of
+// assignment.
+func WithLayerOrder(less func(a, b Node) bool) SorterOption {
+	return func(c *sorterConfig) error {
+		if less == nil {
+			return fmt.Errorf("%w: WithLayerOrder requires a non-nil function", ErrInvalidOption)
+		}
+		c.layerOrder = less
+		return nil
+	}
+}
+
+// WithBaseLevel anchors the levels at base rather than 0, so that the first
+// sort places the nodes without dependencies on level base. The layers below
+// it stay empty, so that the index of every layer returned is its level.
+func WithBaseLevel(base int) SorterOption {
+	return func(c *sorterConfig) error {
+		if base < 0 {
+			return fmt.Errorf("%w: base level must not be negative, got %d", ErrInvalidOption, base)
+		}
+		c.baseLevel = base
+		return nil
+	}
+}
+
+// WithFormatLimit truncates every layer rendered by the sorter's String
+// method to at most max nodes, followed by the number of nodes left out.
+func WithFormatLimit(max int) SorterOption {
+	return func(c *sorterConfig) error {
+		if max <= 0 {
+			return fmt.Errorf("%w: format limit must be positive, got %d", ErrInvalidOption, max)
+		}
+		c.formatLimit = max
+		return nil
+	}
+}
+
+// WithRootOrder makes the depth-first searches start from the specified nodes
+// in the given order, followed by the nodes omitted from it. As a node is
+// emitted after all nodes reachable from it, the nodes first reached from a
+// later start come earlier in the result. Sorting fails with a
+// NodeNotFoundError if any of the nodes does not exist within the graph.
+// Besides the DFSSorter it applies to the ordering fed to the Coffman-Graham
+// leveler, and is mutually exclusive with WithTopologicalSorter and
+// WithTieBreak.
+func WithRootOrder(nodes []Node) SorterOption {
+	return func(c *sorterConfig) error {
+		c.rootOrder = copyNodes(nodes)
+		return nil
+	}
+}
+
+// WithRootLess makes the depth-first searches start from the nodes omitted
+// from WithRootOrder, or all nodes without it, in ascending order according
+// to the comparison function rather than in insertion order. Ties are broken
+// by insertion order.
+func WithRootLess(less func(a, b Node) bool) SorterOption {
+	return func(c *sorterConfig) error {
+		if less == nil {
+			return fmt.Errorf("%w: WithRootLess requires a non-nil function", ErrInvalidOption)
+		}
+		c.rootLess = less
+		return nil
+	}
+}
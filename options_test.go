@@ -0,0 +1,269 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSorterOptionsValidation(t *testing.T) {
+	one := func(level int) int { return 1 }
+	cases := map[string][]SorterOption{
+		"no width":            nil,
+		"zero width":          {WithWidth(0)},
+		"width twice":         {WithWidth(2), WithWidth(3)},
+		"width and func":      {WithWidth(2), WithWidthFunc(one)},
+		"nil width func":      {WithWidthFunc(nil)},
+		"nil tracer":          {WithWidth(2), WithTracer(nil)},
+		"sorter and tiebreak": {WithWidth(2), WithTieBreak(func(a, b Node) bool { return false }), WithTopologicalSorter(func(g *DirectedGraph) TopologicalSorter { return NewDFSSorter(g) })},
+	}
+	g := layeredGraph(10, 2)
+	for name, opts := range cases {
+		if _, err := NewCoffmanGrahamSorterWithOptions(g, opts...); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%s: got %v, want ErrInvalidOption", name, err)
+		}
+		if _, err := NewOptimizedCoffmanGrahamSorterWithOptions(g, opts...); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%s: optimized sorter got %v, want ErrInvalidOption", name, err)
+		}
+	}
+}
+
+func TestSorterOptionsMatchShims(t *testing.T) {
+	g := layeredGraph(40, 3)
+	want, err := NewCoffmanGrahamSorter(g, 3).Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var traced int
+	sorter, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(3), WithStrictOrdering(), WithTracer(func(node Node, level int) {
+		traced++
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := sorter.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("the options sorted to %v, the shim to %v", got, want)
+	}
+	if traced != g.NodeCount() {
+		t.Errorf("traced %d assignments, want %d", traced, g.NodeCount())
+	}
+}
+
+func TestWithWidthFunc(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := 0; i < 6; i++ {
+		g.AddNode(i)
+	}
+	sorter, err := NewCoffmanGrahamSorterWithOptions(g, WithWidthFunc(func(level int) int { return level + 1 }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := sorter.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for level, layer := range layers {
+		if len(layer) != level+1 {
+			t.Errorf("layer %d holds %d nodes, want %d", level, len(layer), level+1)
+		}
+	}
+}
+
+func TestWithNodeSize(t *testing.T) {
+	sizes := map[Node]int{"big": 5, "m1": 2, "s1": 1, "f1": 0, "f2": -1}
+	g := NewDirectedGraph()
+	for _, node := range []Node{"f1", "m1", "s1", "f2"} {
+		g.AddEdge("root", node)
+	}
+	g.AddEdge("s1", "big")
+	size := WithNodeSize(func(node Node) int {
+		if size, ok := sizes[node]; ok {
+			return size
+		}
+		return 1
+	})
+
+	sorters := map[string]func() ([][]Node, error){
+		"coffman-graham": func() ([][]Node, error) {
+			sorter, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(3), size)
+			if err != nil {
+				return nil, err
+			}
+			return sorter.Sort()
+		},
+		"optimized": func() ([][]Node, error) {
+			sorter, err := NewOptimizedCoffmanGrahamSorterWithOptions(g, WithWidth(3), size)
+			if err != nil {
+				return nil, err
+			}
+			return sorter.Sort()
+		},
+	}
+	for name, sort := range sorters {
+		layers, err := sort()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		levels := make(map[Node]int)
+		for level, layer := range layers {
+			for _, node := range layer {
+				levels[node] = level
+			}
+		}
+		for level, layer := range layers {
+			occupied := 0
+			for _, node := range layer {
+				if size, ok := sizes[node]; !ok {
+					occupied++
+				} else if size > 0 {
+					occupied += size
+				}
+			}
+			if occupied > 3 && level != levels["big"] {
+				t.Errorf("%s: layer %d takes %d slots: %v", name, level, occupied, layer)
+			}
+		}
+
+		// the oversized node gets a layer of its own, shared at most with
+		// nodes of size zero
+		for _, node := range layers[levels["big"]] {
+			if node != "big" && sizes[node] > 0 {
+				t.Errorf("%s: %v shares the layer of the oversized node: %v", name, node, layers)
+			}
+		}
+		// nodes of size zero ride along in the full layer after their
+		// dependency
+		for _, node := range []Node{"m1", "s1", "f1", "f2"} {
+			if levels[node] != 1 {
+				t.Errorf("%s: %v was placed on level %d, want 1: %v", name, node, levels[node], layers)
+			}
+		}
+	}
+
+	if _, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(3), WithNodeSize(nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("a nil size function got %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestWithCapacities(t *testing.T) {
+	// the layers have 4 CPU slots and 10 units of memory, so that the memory
+	// binds first for the m nodes and the CPU for the c nodes
+	demands := map[Node][]int{
+		"m1": {1, 4}, "m2": {1, 4}, "m3": {1, 4},
+		"c1": {3, 1}, "c2": {2, -5},
+		"huge": {1, 11},
+	}
+	g := NewDirectedGraph()
+	for _, node := range []Node{"m1", "m2", "m3", "huge"} {
+		g.AddNode(node)
+	}
+	for _, node := range []Node{"c1", "c2"} {
+		g.AddEdge("m3", node)
+	}
+	capacities := WithCapacities(2, []int{4, 10}, func(node Node) []int {
+		return demands[node]
+	})
+
+	cg, err := NewCoffmanGrahamSorterWithOptions(g, capacities)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt, err := NewOptimizedCoffmanGrahamSorterWithOptions(g, capacities)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sorters := map[string]struct {
+		sort         func() ([][]Node, error)
+		overCapacity func() []Node
+	}{
+		"coffman-graham": {cg.Sort, cg.OverCapacity},
+		"optimized":      {opt.Sort, opt.OverCapacity},
+	}
+	for name, s := range sorters {
+		layers, err := s.sort()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for level, layer := range layers {
+			used := []int{0, 0}
+			for _, node := range layer {
+				for d, amount := range demands[node] {
+					if amount > 0 {
+						used[d] += amount
+					}
+				}
+			}
+			if (used[0] > 4 || used[1] > 10) && !reflect.DeepEqual(layer, []Node{"huge"}) {
+				t.Errorf("%s: layer %d uses %v: %v", name, level, used, layer)
+			}
+		}
+		if got := s.overCapacity(); !reflect.DeepEqual(got, []Node{"huge"}) {
+			t.Errorf("%s: got %v over capacity, want [huge]", name, got)
+		}
+	}
+
+	if _, err := NewCoffmanGrahamSorterWithOptions(g, WithCapacities(2, []int{4}, nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("mismatched dimensions got %v, want ErrInvalidOption", err)
+	}
+	demands["c2"] = []int{1}
+	cg, err = NewCoffmanGrahamSorterWithOptions(g, capacities)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cg.Sort(); !errors.Is(err, ErrInvalidDemand) {
+		t.Errorf("a demand of one dimension got %v, want ErrInvalidDemand", err)
+	}
+}
+
+func TestLayerCallbacks(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "c")
+	g.AddNode("d")
+
+	var calls []string
+	record := func(kind string) func(level int, nodes []Node) {
+		return func(level int, nodes []Node) {
+			calls = append(calls, fmt.Sprint(kind, " ", level, " ", nodes))
+		}
+	}
+	s, err := NewOptimizedCoffmanGrahamSorterWithOptions(g, WithWidth(2),
+		WithTracer(func(node Node, level int) {
+			calls = append(calls, fmt.Sprint("assign ", level, " ", node))
+		}),
+		WithLayerCallback(record("layer")),
+		WithSealedLayerCallback(record("sealed")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.EventSort(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"assign 0 d", "layer 0 [d]",
+		"assign 0 b", "layer 0 [d b]", "sealed 0 [d b]",
+		"assign 1 a", "layer 1 [a]",
+		"assign 2 c", "layer 2 [c]",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got the calls\n%v\nwant\n%v", calls, want)
+	}
+
+	// a later node fills the gap in layer 1, sealing it
+	calls = nil
+	g.AddNode("e")
+	if _, err := s.EventSort(); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"assign 1 e", "layer 1 [a e]", "sealed 1 [a e]"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got the calls\n%v\nwant\n%v", calls, want)
+	}
+}
@@ -0,0 +1,122 @@
+package graff
+
+import (
+	"errors"
+	"math"
+)
+
+// Errors relating to PageRank.
+var (
+	ErrEmptyGraph        = errors.New("The graph must contain at least one node")
+	ErrInvalidDamping    = errors.New("The damping factor must be within [0, 1]")
+	ErrInvalidIterations = errors.New("The number of iterations must be positive")
+	ErrNegativeWeight    = errors.New("The edge weights cannot be negative")
+)
+
+// PageRank ranks the graph's nodes by structural importance by running the
+// power iteration for the specified number of iterations.
+// Rank held by dangling nodes (those without outgoing edges, or with only
+// zero-weight ones) is distributed uniformly across all nodes, so the
+// returned ranks always sum to ~1.0.
+// Edges carrying a weight attribute pass on rank in proportion to their
+// weight, other edges weigh 1.
+func (g *DirectedGraph) PageRank(damping float64, iterations int) (map[Node]float64, error) {
+	return g.pageRank(damping, 0, iterations)
+}
+
+// PageRankTolerance ranks the graph's nodes like PageRank, but stops as soon as
+// the total (L1) change between two iterations drops below the tolerance,
+// or after maxIterations, whichever comes first.
+func (g *DirectedGraph) PageRankTolerance(damping float64, tolerance float64, maxIterations int) (map[Node]float64, error) {
+	return g.pageRank(damping, tolerance, maxIterations)
+}
+
+func (g *DirectedGraph) pageRank(damping float64, tolerance float64, iterations int) (map[Node]float64, error) {
+	if damping < 0 || damping > 1 || math.IsNaN(damping) {
+		return nil, ErrInvalidDamping
+	}
+	if iterations <= 0 {
+		return nil, ErrInvalidIterations
+	}
+
+	nodes := g.Nodes()
+	n := len(nodes)
+	if n == 0 {
+		return nil, ErrEmptyGraph
+	}
+
+	index := make(map[Node]int, n)
+	for i, node := range nodes {
+		index[node] = i
+	}
+
+	// the share of a node's rank passed along each of its outgoing edges
+	shares := make([][]float64, n)
+	for i, node := range nodes {
+		outgoing := g.OutgoingEdges(node)
+		total := 0.0
+		shares[i] = make([]float64, len(outgoing))
+		for j, to := range outgoing {
+			weight := g.edgeWeight(node, to)
+			if weight < 0 {
+				return nil, ErrNegativeWeight
+			}
+			shares[i][j] = weight
+			total += weight
+		}
+		for j := range shares[i] {
+			if total > 0 {
+				shares[i][j] /= total
+			}
+		}
+		if total == 0 {
+			shares[i] = nil
+		}
+	}
+
+	rank := make([]float64, n)
+	next := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+
+	for iteration := 0; iteration < iterations; iteration++ {
+		// rank held by dangling nodes is spread over the whole graph
+		dangling := 0.0
+		for i := range nodes {
+			if shares[i] == nil {
+				dangling += rank[i]
+			}
+		}
+
+		base := (1-damping)/float64(n) + damping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+
+		for i, node := range nodes {
+			if shares[i] == nil {
+				continue
+			}
+			for j, to := range g.OutgoingEdges(node) {
+				next[index[to]] += damping * rank[i] * shares[i][j]
+			}
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+
+		if tolerance > 0 && delta < tolerance {
+			break
+		}
+	}
+
+	results := make(map[Node]float64, n)
+	for i, node := range nodes {
+		results[node] = rank[i]
+	}
+	return results, nil
+}
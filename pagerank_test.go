@@ -0,0 +1,72 @@
+package graff
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestPageRankFixture(t *testing.T) {
+	// a -> b, a -> c and b -> c with the dangling c spreading its rank over
+	// all nodes, so that a holds only the teleported and spread rank u, b
+	// gains half of a's and c half of a's and all of b's:
+	//   a = u, b = u(1 + d/2), c = u(1 + d/2 + d(1 + d/2))
+	// and u follows from the ranks summing to 1. With a -> b weighing 3 b
+	// gains three quarters of a's rank instead.
+	const d = 0.85
+	tests := []struct {
+		weight float64
+		shareB float64
+	}{
+		{1, 0.5},
+		{3, 0.75},
+	}
+	for _, test := range tests {
+		g := NewDirectedGraph()
+		g.AddEdge("a", "b")
+		g.AddEdge("a", "c")
+		g.AddEdge("b", "c")
+		g.SetEdgeAttr("a", "b", WeightAttr, test.weight)
+
+		a, b, c := 1.0, 1+d*test.shareB, 1+d*(1-test.shareB)+d*(1+d*test.shareB)
+		u := 1 / (a + b + c)
+		want := map[Node]float64{"a": a * u, "b": b * u, "c": c * u}
+
+		ranks, err := g.PageRank(d, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		converged, err := g.PageRankTolerance(d, 1e-12, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := 0.0
+		for node, rank := range ranks {
+			sum += rank
+			if math.Abs(rank-want[node]) > 1e-9 || math.Abs(converged[node]-want[node]) > 1e-9 {
+				t.Errorf("weight %v: %v ranks %v and %v, want %v", test.weight, node, rank, converged[node], want[node])
+			}
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("weight %v: the ranks sum to %v", test.weight, sum)
+		}
+	}
+}
+
+func TestPageRankErrors(t *testing.T) {
+	g := NewDirectedGraph()
+	if _, err := g.PageRank(0.85, 10); !errors.Is(err, ErrEmptyGraph) {
+		t.Errorf("ranking an empty graph returned %v", err)
+	}
+	g.AddEdge("a", "b")
+	if _, err := g.PageRank(1.5, 10); !errors.Is(err, ErrInvalidDamping) {
+		t.Errorf("a damping of 1.5 returned %v", err)
+	}
+	if _, err := g.PageRankTolerance(0.85, 1e-6, 0); !errors.Is(err, ErrInvalidIterations) {
+		t.Errorf("no iterations returned %v", err)
+	}
+	g.SetEdgeAttr("a", "b", WeightAttr, -1)
+	if _, err := g.PageRank(0.85, 10); !errors.Is(err, ErrNegativeWeight) {
+		t.Errorf("a negative weight returned %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package graff
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Errors relating to the Pajek NET format.
+var (
+	ErrInvalidPajek = errors.New("The data is not in the Pajek NET format")
+)
+
+// maxPajekVertices is the maximum number of vertices a Pajek NET file may
+// declare.
+const maxPajekVertices = 1 << 20
+
+// ParsePajek reads a graph in the Pajek NET format. The *Vertices section
+// declares the number of vertices, which have the ids 1 to n, followed by
+// lines holding an id and its optionally quoted label, e.g. 1 "first node".
+// Coordinates and other vertex properties following the label are ignored.
+// Labels become string nodes unless WithLabelMapper is used, and vertices
+// without a line or label are labeled with their id. Nodes are added in the
+// order of their ids.
+//
+// Directed arcs are read from *Arcs sections, with lines holding the ids of
+// the source and target and an optional numeric weight stored as the edge's
+// WeightAttr, and from *Arcslist sections, with lines holding a source
+// followed by its targets. Undirected *Edges and *Edgeslist sections, like
+// any other section such as *Matrix or *Partition, are rejected rather than
+// silently ignored. Lines starting with % are comments. Section keywords
+// are case-insensitive, as in Pajek.
+//
+// At most maxPajekVertices vertices may be declared, since every declared
+// vertex becomes a node even without a line of its own, so that a corrupt
+// count is rejected rather than exhausting the memory.
+//
+// An error wrapping ErrInvalidPajek, including the line number, is returned
+// if the data is malformed, or one wrapping ErrDuplicateNode if two vertices
+// map to the same node.
+// See http://mrvar.fdv.uni-lj.si/pajek/
+func ParsePajek(r io.Reader, opts ...LabelOption) (*DirectedGraph, error) {
+	config := newLabelConfig(opts)
+	lines := newLineReader(r)
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: line %d: %s", ErrInvalidPajek, lines.line, fmt.Sprintf(format, args...))
+	}
+
+	g := NewDirectedGraph()
+	// labels holds the labels read so far by id, count the number of vertices
+	// declared, or -1 before the *Vertices section
+	labels := make(map[int]string)
+	count := -1
+	var nodes []Node
+	section := ""
+
+	// declare adds the vertices once they were all read, in id order
+	declare := func() error {
+		if nodes != nil || count < 0 {
+			return nil
+		}
+		nodes = make([]Node, count)
+		owners := newLabeledNodes(config)
+		for i := range nodes {
+			label := labels[i+1]
+			if label == "" {
+				label = strconv.Itoa(i + 1)
+			}
+			node, err := owners.node(label)
+			if err != nil {
+				return err
+			}
+			nodes[i] = node
+		}
+		g.AddNodes(nodes...)
+		return nil
+	}
+	vertex := func(field string) (Node, error) {
+		id, err := strconv.Atoi(field)
+		if err != nil || id < 1 || id > len(nodes) {
+			return nil, fail("invalid vertex %q", field)
+		}
+		return nodes[id-1], nil
+	}
+
+	for {
+		line, ok := lines.next()
+		if !ok {
+			break
+		}
+		if line == "" || line[0] == '%' {
+			continue
+		}
+
+		if line[0] == '*' {
+			fields := strings.Fields(line)
+			section = strings.ToLower(fields[0])
+			switch section {
+			case "*vertices":
+				if count >= 0 {
+					return nil, fail("duplicate *Vertices section")
+				}
+				if len(fields) < 2 {
+					return nil, fail("missing number of vertices")
+				}
+				n, err := strconv.Atoi(fields[1])
+				if err != nil || n < 0 || n > maxPajekVertices {
+					return nil, fail("invalid number of vertices %q", fields[1])
+				}
+				count = n
+			case "*arcs", "*arcslist":
+				if count < 0 {
+					return nil, fail("%s section before the *Vertices section", fields[0])
+				}
+				if err := declare(); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fail("unsupported section %s", fields[0])
+			}
+			continue
+		}
+
+		switch section {
+		case "*vertices":
+			fields := strings.Fields(line)
+			id, err := strconv.Atoi(fields[0])
+			if err != nil || id < 1 || id > count {
+				return nil, fail("invalid vertex %q", fields[0])
+			}
+			label, err := pajekLabel(strings.TrimSpace(line[len(fields[0]):]))
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			labels[id] = label
+		case "*arcs":
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return nil, fail("missing target of the arc")
+			}
+			from, err := vertex(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			to, err := vertex(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			g.AddEdge(from, to)
+			if len(fields) > 2 {
+				weight, err := strconv.ParseFloat(fields[2], 64)
+				if err != nil {
+					return nil, fail("invalid weight %q", fields[2])
+				}
+				g.SetEdgeAttr(from, to, WeightAttr, weight)
+			}
+		case "*arcslist":
+			fields := strings.Fields(line)
+			from, err := vertex(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			for _, field := range fields[1:] {
+				to, err := vertex(field)
+				if err != nil {
+					return nil, err
+				}
+				g.AddEdge(from, to)
+			}
+		default:
+			return nil, fail("data outside of a section")
+		}
+	}
+	if err := lines.err(); err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("%w: missing *Vertices section", ErrInvalidPajek)
+	}
+	if err := declare(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// pajekLabel returns the label at the start of the text, which is either
+// quoted or runs up to the first whitespace, or an empty label if there is
+// none.
+func pajekLabel(text string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	if text[0] != '"' {
+		return strings.Fields(text)[0], nil
+	}
+	end := strings.IndexByte(text[1:], '"')
+	if end < 0 {
+		return "", errors.New("unterminated label")
+	}
+	return text[1 : end+1], nil
+}
+
+// WritePajek writes the graph in the Pajek NET format read by ParsePajek,
+// with a *Vertices section listing the nodes in insertion order with quoted
+// labels, followed by an *Arcs section listing the edges in the order of
+// their source and then their target, so the output is deterministic.
+// Arcs carrying a numeric WeightAttr are written with their weight.
+// Labels are the nodes' fmt.Sprint representation unless WithNodeLabeler is
+// used. If a label contains a double quote or a line break, which cannot be
+// written, an error wrapping ErrUnsupportedNode is returned.
+func (g *DirectedGraph) WritePajek(w io.Writer, opts ...LabelOption) error {
+	config := newLabelConfig(opts)
+	b := bufio.NewWriter(w)
+
+	nodes := g.Nodes()
+	ids := make(map[Node]int, len(nodes))
+	fmt.Fprintf(b, "*Vertices %d\n", len(nodes))
+	for i, node := range nodes {
+		label, err := writableLabel(config, node, `"`)
+		if err != nil {
+			return err
+		}
+		ids[node] = i + 1
+		fmt.Fprintf(b, "%d \"%s\"\n", i+1, label)
+	}
+
+	b.WriteString("*Arcs\n")
+	for _, from := range nodes {
+		for _, to := range g.OutgoingEdges(from) {
+			fmt.Fprintf(b, "%d %d", ids[from], ids[to])
+			if value, ok := g.EdgeAttr(from, to, WeightAttr); ok {
+				if weight, ok := toFloat(value); ok {
+					fmt.Fprintf(b, " %s", strconv.FormatFloat(weight, 'g', -1, 64))
+				}
+			}
+			b.WriteByte('\n')
+		}
+	}
+	return b.Flush()
+}
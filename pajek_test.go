@@ -0,0 +1,104 @@
+package graff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParsePajek(t *testing.T) {
+	g, err := ParsePajek(strings.NewReader(`*Vertices 3
+1 "first node"
+3 third
+*Arcs
+1 2 0.5
+2 3
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := g.Nodes()
+	if len(nodes) != 3 || nodes[0] != "first node" || nodes[1] != "2" || nodes[2] != "third" {
+		t.Errorf("nodes are %v", nodes)
+	}
+	if weight, _ := g.EdgeAttr("first node", "2", WeightAttr); weight != 0.5 {
+		t.Errorf("weight is %v, want 0.5", weight)
+	}
+	if !g.EdgeExists("2", "third") {
+		t.Error("edge 2 -> third is missing")
+	}
+}
+
+func TestParsePajekHugeVertexCount(t *testing.T) {
+	for _, data := range []string{"*Vertices 2000000000\n", "*Vertices 99999999999999999999\n"} {
+		if _, err := ParsePajek(strings.NewReader(data)); !errors.Is(err, ErrInvalidPajek) {
+			t.Errorf("%q: got %v, want ErrInvalidPajek", data, err)
+		}
+	}
+}
+
+func TestPajekRoundTrip(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("first node", "second")
+	g.AddEdge("second", "third")
+	g.AddEdge("first node", "third")
+	g.AddNode("alone")
+	g.SetEdgeAttr("first node", "second", WeightAttr, 0.25)
+	g.SetEdgeAttr("second", "third", WeightAttr, 3)
+
+	var b bytes.Buffer
+	if err := g.WritePajek(&b); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ParsePajek(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRoundTrip(t, g, read)
+	for _, edge := range []Edge{{"first node", "second"}, {"second", "third"}} {
+		want, _ := g.EdgeAttr(edge.From, edge.To, WeightAttr)
+		if weight, _ := read.EdgeAttr(edge.From, edge.To, WeightAttr); fmt.Sprint(weight) != fmt.Sprint(want) {
+			t.Errorf("read the weight %v of %v, want %v", weight, edge, want)
+		}
+	}
+	if _, ok := read.EdgeAttr("first node", "third", WeightAttr); ok {
+		t.Error("an unweighted arc was read with a weight")
+	}
+}
+
+func TestPajekRoundTripMappedNodes(t *testing.T) {
+	g := layeredGraph(50, 3)
+	var b bytes.Buffer
+	if err := g.WritePajek(&b, WithNodeLabeler(func(node Node) string { return fmt.Sprintf("n%d", node) })); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ParsePajek(&b, WithLabelMapper(func(label string) Node {
+		n, _ := strconv.Atoi(strings.TrimPrefix(label, "n"))
+		return n
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRoundTrip(t, g, read)
+}
+
+func TestParsePajekWindowsLineEndings(t *testing.T) {
+	data := "*Vertices  3 \r\n  1 \"first node\"  0.1 0.2\r\n\t2   second \r\n\r\n*Arcs\r\n 1  2   1.5 \r\n\t2 3\r\n  \r\n"
+	g, err := ParsePajek(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Node{"first node", "second", "3"}; !reflect.DeepEqual(g.Nodes(), want) {
+		t.Errorf("read the nodes %q, want %q", g.Nodes(), want)
+	}
+	if weight, _ := g.EdgeAttr("first node", "second", WeightAttr); weight != 1.5 {
+		t.Errorf("read the weight %v, want 1.5", weight)
+	}
+	if !g.EdgeExists("second", "3") || g.EdgeCount() != 2 {
+		t.Errorf("read the edges of %v", g)
+	}
+}
@@ -0,0 +1,75 @@
+package graff
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// RandomSorter topologically sorts a directed graph's nodes in a random
+// order using Kahn's algorithm, repeatedly emitting a ready node chosen
+// uniformly at random. This does not make every topological order equally
+// likely, but covers all of them, which is adequate for randomized testing.
+// Given a random number generator with the same seed the output is always
+// identical.
+type RandomSorter struct {
+	graph Directed
+	rng   *rand.Rand
+	// result holds the nodes sorted by the last successful sort, see String
+	result []Node
+}
+
+// NewRandomSorter returns a new random sorter drawing from the generator.
+func NewRandomSorter(graph Directed, rng *rand.Rand) *RandomSorter {
+	return &RandomSorter{
+		graph: directedView(graph),
+		rng:   rng,
+	}
+}
+
+// Sort returns the sorted nodes.
+func (s *RandomSorter) Sort() ([]Node, error) {
+	state := newReadyState(s.graph)
+	ready := state.Roots()
+	sorted := make([]Node, 0, s.graph.NodeCount())
+	for len(ready) > 0 {
+		i := s.rng.Intn(len(ready))
+		node := ready[i]
+		ready[i] = ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+
+		sorted = append(sorted, node)
+		state.Release(node, func(dependant Node) {
+			ready = append(ready, dependant)
+		})
+	}
+
+	if len(sorted) != s.graph.NodeCount() {
+		return nil, cycleError(s.graph)
+	}
+	if err := graphErr(s.graph); err != nil {
+		return nil, err
+	}
+	s.result = sorted
+	return sorted, nil
+}
+
+// RandomTopoSort returns the graph's nodes in a random topological order,
+// see RandomSorter.
+func (g *DirectedGraph) RandomTopoSort(rng *rand.Rand) ([]Node, error) {
+	return NewRandomSorter(g, rng).Sort()
+}
+
+// WithRandomOrdering feeds the leveler a random topological order drawn from
+// the generator, see RandomSorter, e.g. to fuzz the level assignment. It is
+// a shorthand for WithTopologicalSorter.
+func WithRandomOrdering(rng *rand.Rand) SorterOption {
+	return func(c *sorterConfig) error {
+		if rng == nil {
+			return fmt.Errorf("%w: WithRandomOrdering requires a non-nil generator", ErrInvalidOption)
+		}
+		c.ordering = func(graph *DirectedGraph) TopologicalSorter {
+			return NewRandomSorter(graph, rng)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,54 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestRandomTopoSort(t *testing.T) {
+	g := layeredGraph(50, 2)
+	random := rand.New(rand.NewSource(1))
+	distinct := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		order, err := g.RandomTopoSort(random)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, v := g.IsLinearExtension(order); !ok {
+			t.Fatal(v)
+		}
+		distinct[formatOrder(order, 0)] = true
+	}
+	if len(distinct) < 150 {
+		t.Errorf("got only %d distinct orders out of 200", len(distinct))
+	}
+
+	first, _ := g.RandomTopoSort(rand.New(rand.NewSource(7)))
+	second, _ := g.RandomTopoSort(rand.New(rand.NewSource(7)))
+	if !reflect.DeepEqual(first, second) {
+		t.Error("the same seed gave different orders")
+	}
+}
+
+func TestWithRandomOrdering(t *testing.T) {
+	g := layeredGraph(50, 2)
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		s, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(3), WithRandomOrdering(random))
+		if err != nil {
+			t.Fatal(err)
+		}
+		layers, err := s.Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateLayering(g, layers, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(3), WithRandomOrdering(nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v for a nil generator, want ErrInvalidOption", err)
+	}
+}
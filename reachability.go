@@ -0,0 +1,206 @@
+package graff
+
+import (
+	"errors"
+)
+
+// Errors relating to the ReachabilityIndex.
+var (
+	ErrStaleIndex = errors.New("The graph was modified since the index was built")
+)
+
+// ReachabilityIndex answers whether a path leads from one node to another in
+// constant time by storing the transitive closure of a graph as one bit set
+// per node, taking O(n²) bits of memory.
+// The index reflects the graph at the time it was built, and becomes stale
+// as soon as the graph is modified.
+type ReachabilityIndex struct {
+	// graph is the indexed graph if it is a DirectedGraph, whose generation
+	// tells whether the index is stale
+	graph      *DirectedGraph
+	generation uint64
+	ids        map[Node]int
+	// nodes holds the nodes by id, in topological order
+	nodes []Node
+	reach [][]uint64
+}
+
+// BuildReachabilityIndex computes the transitive closure of the graph.
+// The graph remembers the index, so that IsAncestor, IsDescendant and
+// HappensBefore consult it for as long as it is not stale.
+// A CyclicGraphError is returned if the graph contains a cycle.
+func (g *DirectedGraph) BuildReachabilityIndex() (*ReachabilityIndex, error) {
+	x, err := NewReachabilityIndex(g)
+	if err != nil {
+		return nil, err
+	}
+	g.reachability = x
+	return x, nil
+}
+
+// NewReachabilityIndex computes the transitive closure of any implementation
+// of Directed. Only an index of a DirectedGraph can tell whether it became
+// stale, the index of other graphs must be rebuilt whenever they change.
+// A CyclicGraphError is returned if the graph contains a cycle.
+func NewReachabilityIndex(graph Directed) (*ReachabilityIndex, error) {
+	graph = directedView(graph)
+	nodes, err := NewDFSSorter(graph).Sort()
+	if err != nil {
+		return nil, err
+	}
+
+	x := &ReachabilityIndex{
+		ids:   make(map[Node]int, len(nodes)),
+		nodes: nodes,
+		reach: make([][]uint64, len(nodes)),
+	}
+	if g, ok := graph.(*DirectedGraph); ok {
+		x.graph = g
+		x.generation = g.generation
+	}
+	for i, node := range nodes {
+		x.ids[node] = i
+	}
+
+	// every node reaches what its successors reach, so walk them backwards
+	words := (len(nodes) + 63) / 64
+	for i := len(nodes) - 1; i >= 0; i-- {
+		reach := make([]uint64, words)
+		eachOutgoing(graph, nodes[i], func(outgoing Node) bool {
+			j := x.ids[outgoing]
+			reach[j/64] |= 1 << uint(j%64)
+			for w, bits := range x.reach[j] {
+				reach[w] |= bits
+			}
+			return true
+		})
+		x.reach[i] = reach
+	}
+	if err := graphErr(graph); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Stale determines whether the graph was modified since the index was built.
+// It is always false for graphs other than a DirectedGraph.
+func (x *ReachabilityIndex) Stale() bool {
+	return x.graph != nil && x.graph.generation != x.generation
+}
+
+// Reaches determines whether a directed path of at least one edge leads from
+// one node to the other. An error wrapping ErrStaleIndex is returned if the
+// index is stale, or ErrNodeNotFound if either node did not exist when the
+// index was built.
+func (x *ReachabilityIndex) Reaches(from Node, to Node) (bool, error) {
+	if x.Stale() {
+		return false, ErrStaleIndex
+	}
+	i, ok := x.ids[from]
+	if !ok {
+		return false, &NodeNotFoundError{Node: from}
+	}
+	j, ok := x.ids[to]
+	if !ok {
+		return false, &NodeNotFoundError{Node: to}
+	}
+	return x.reach[i][j/64]&(1<<uint(j%64)) != 0, nil
+}
+
+// freshReachability returns the graph's reachability index if one was built
+// and is not stale.
+func (g *DirectedGraph) freshReachability() *ReachabilityIndex {
+	if g.reachability == nil || g.reachability.Stale() {
+		return nil
+	}
+	return g.reachability
+}
+
+// IsAncestor determines whether a is an ancestor of b, i.e. whether a directed
+// path leads from a to b. A node is not its own ancestor unless it lies on a
+// cycle. The graph's ReachabilityIndex is consulted if it is not stale,
+// otherwise the graph is searched from both ends like AddEdgeChecked, so the
+// search is bounded by the smaller of the descendants of a and the ancestors
+// of b, and stops once either is exhausted.
+// An error wrapping ErrNodeNotFound is returned if either node does not exist.
+func (g *DirectedGraph) IsAncestor(a Node, b Node) (bool, error) {
+	if err := g.checkNodes([]Node{a, b}); err != nil {
+		return false, err
+	}
+	if x := g.freshReachability(); x != nil {
+		return x.Reaches(a, b)
+	}
+	if a == b {
+		return g.pathExists(a, b), nil
+	}
+	// adding an edge from b to a would close a cycle through a path from a
+	// to b
+	return g.closesCycle(b, a) != nil, nil
+}
+
+// IsDescendant determines whether a is a descendant of b, i.e. whether a
+// directed path leads from b to a. See IsAncestor.
+func (g *DirectedGraph) IsDescendant(a Node, b Node) (bool, error) {
+	return g.IsAncestor(b, a)
+}
+
+// Ancestors returns the ancestors of the node, i.e. the nodes from which a
+// directed path leads to it, nearest first in breadth-first order. A node is
+// not its own ancestor unless it lies on a cycle. See AncestorCache for
+// answering repeated queries.
+// An error wrapping ErrNodeNotFound is returned if the node does not exist.
+func (g *DirectedGraph) Ancestors(node Node) ([]Node, error) {
+	return ancestorsOf(g, node)
+}
+
+// ancestorsOf returns the node's ancestors like DirectedGraph.Ancestors for
+// any graph.
+func ancestorsOf(d Directed, node Node) ([]Node, error) {
+	if err := checkDirectedNodes(d, []Node{node}); err != nil {
+		return nil, err
+	}
+	ancestors := make([]Node, 0)
+	visited := make(map[Node]bool)
+	for i := -1; i < len(ancestors); i++ {
+		to := node
+		if i >= 0 {
+			to = ancestors[i]
+		}
+		eachIncoming(d, to, func(from Node) bool {
+			if !visited[from] {
+				visited[from] = true
+				ancestors = append(ancestors, from)
+			}
+			return true
+		})
+	}
+	return ancestors, graphErr(d)
+}
+
+// pathExists determines whether a directed path of at least one edge leads
+// from one node to the other, using a breadth-first search which visits every
+// node at most once.
+func (g *DirectedGraph) pathExists(from Node, to Node) bool {
+	visited := make(map[Node]bool)
+	queue := []Node{from}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		found := false
+		g.EachOutgoing(node, func(outgoing Node) bool {
+			if outgoing == to {
+				found = true
+				return false
+			}
+			if !visited[outgoing] {
+				visited[outgoing] = true
+				queue = append(queue, outgoing)
+			}
+			return true
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package graff
+
+import (
+	"testing"
+)
+
+// checkAncestors compares IsAncestor and IsDescendant with a plain search
+// for every pair of nodes.
+func checkAncestors(t *testing.T, g *DirectedGraph, when string) {
+	t.Helper()
+	for _, a := range g.Nodes() {
+		for _, b := range g.Nodes() {
+			want := g.pathExists(a, b)
+			if got, err := g.IsAncestor(a, b); err != nil || got != want {
+				t.Fatalf("%s: IsAncestor(%v, %v) returned %v, %v, want %v", when, a, b, got, err, want)
+			}
+			if got, err := g.IsDescendant(b, a); err != nil || got != want {
+				t.Fatalf("%s: IsDescendant(%v, %v) returned %v, %v, want %v", when, b, a, got, err, want)
+			}
+		}
+	}
+}
+
+func TestIsAncestorAfterMutation(t *testing.T) {
+	g := layeredGraph(40, 2)
+	if _, err := g.BuildReachabilityIndex(); err != nil {
+		t.Fatal(err)
+	}
+	checkAncestors(t, g, "indexed")
+
+	g.AddEdge(39, "tail")
+	if g.freshReachability() != nil {
+		t.Fatal("adding an edge left the index fresh")
+	}
+	checkAncestors(t, g, "after adding an edge")
+
+	g.RemoveNode(20)
+	g.RemoveEdge(0, g.OutgoingEdges(0)[0])
+	checkAncestors(t, g, "after removals")
+
+	g.AddEdge("tail", 5)
+	checkAncestors(t, g, "after closing a cycle")
+
+	g.RemoveEdge("tail", 5)
+	if _, err := g.BuildReachabilityIndex(); err != nil {
+		t.Fatal(err)
+	}
+	checkAncestors(t, g, "reindexed")
+}
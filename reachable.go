@@ -0,0 +1,80 @@
+package graff
+
+// DFSSortFrom returns the nodes reachable from the sources, including the
+// sources themselves, in topological order using the Depth-first search
+// algorithm. Nodes which are not reachable from any source are left out.
+// An error wrapping ErrNodeNotFound is returned for an unknown source, and a
+// CyclicGraphError if a cycle is reachable from the sources.
+func (g *DirectedGraph) DFSSortFrom(sources ...Node) ([]Node, error) {
+	if err := g.checkNodes(sources); err != nil {
+		return nil, err
+	}
+	return NewDFSSorter(g).sortFrom(sources)
+}
+
+// CoffmanGrahamSortFrom sorts the nodes reachable from the sources, including
+// the sources themselves, into a sequence of levels like CoffmanGrahamSort.
+// Nodes which are not reachable from any source are left out.
+// An error wrapping ErrNodeNotFound is returned for an unknown source, and a
+// CyclicGraphError if a cycle is reachable from the sources.
+func (g *DirectedGraph) CoffmanGrahamSortFrom(width int, sources ...Node) ([][]Node, error) {
+	if err := g.checkNodes(sources); err != nil {
+		return nil, err
+	}
+	return g.induced(g.descendants(sources)).CoffmanGrahamSort(width)
+}
+
+// checkNodes returns an error wrapping ErrNodeNotFound for the first node
+// which does not exist within the graph.
+func (g *DirectedGraph) checkNodes(nodes []Node) error {
+	if missing := g.HasNodes(nodes...); len(missing) > 0 {
+		return &NodeNotFoundError{Node: missing[0]}
+	}
+	return nil
+}
+
+// descendants returns the set of nodes reachable from the sources, including
+// the sources themselves.
+func (g *DirectedGraph) descendants(sources []Node) map[Node]bool {
+	reached := make(map[Node]bool, len(sources))
+	queue := make([]Node, 0, len(sources))
+	for _, source := range sources {
+		if !reached[source] {
+			reached[source] = true
+			queue = append(queue, source)
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		g.EachOutgoing(node, func(outgoing Node) bool {
+			if !reached[outgoing] {
+				reached[outgoing] = true
+				queue = append(queue, outgoing)
+			}
+			return true
+		})
+	}
+	return reached
+}
+
+// induced returns the subgraph of the nodes in the set and the edges between
+// them, keeping the graph's node order, multiplicities and attributes.
+func (g *DirectedGraph) induced(nodes map[Node]bool) *DirectedGraph {
+	sub := NewDirectedGraph()
+	for _, node := range g.Nodes() {
+		if nodes[node] {
+			sub.AddNode(node)
+			sub.copyNodeAttrs(g, node, node)
+		}
+	}
+	for _, from := range sub.Nodes() {
+		g.EachOutgoing(from, func(to Node) bool {
+			if nodes[to] {
+				sub.copyEdge(g, from, to, from, to)
+			}
+			return true
+		})
+	}
+	return sub
+}
@@ -0,0 +1,79 @@
+package graff
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSortFrom(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("d", "c")
+	g.AddEdge("e", "f")
+	g.AddEdge("c", "g")
+
+	reachable := map[Node]bool{"b": true, "c": true, "g": true, "d": true}
+	order, err := g.DFSSortFrom("b", "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != len(reachable) {
+		t.Errorf("got %v, want the %d nodes reachable from b and d", order, len(reachable))
+	}
+	position := make(map[Node]int, len(order))
+	for i, node := range order {
+		if !reachable[node] {
+			t.Errorf("%v is not reachable from b or d", node)
+		}
+		position[node] = i
+	}
+	for _, edge := range [][2]Node{{"b", "c"}, {"d", "c"}, {"c", "g"}} {
+		if position[edge[0]] > position[edge[1]] {
+			t.Errorf("%v is sorted after %v in %v", edge[0], edge[1], order)
+		}
+	}
+
+	layers, err := g.CoffmanGrahamSortFrom(1, "b", "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, layer := range layers {
+		for _, node := range layer {
+			if !reachable[node] {
+				t.Errorf("%v is not reachable from b or d", node)
+			}
+			count++
+		}
+	}
+	if count != len(reachable) {
+		t.Errorf("got the layers %v, want the %d nodes reachable from b and d", layers, len(reachable))
+	}
+}
+
+func TestSortFromErrors(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "c")
+
+	if _, err := g.DFSSortFrom("a", "typo"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("DFSSortFrom got %v, want ErrNodeNotFound", err)
+	}
+	if _, err := g.CoffmanGrahamSortFrom(2, "typo"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("CoffmanGrahamSortFrom got %v, want ErrNodeNotFound", err)
+	}
+
+	// the cycle is only reachable from c
+	if _, err := g.DFSSortFrom("a"); err != nil {
+		t.Errorf("DFSSortFrom got %v for an unreachable cycle", err)
+	}
+	var cyclic *CyclicGraphError
+	if _, err := g.DFSSortFrom("c"); !errors.As(err, &cyclic) {
+		t.Errorf("DFSSortFrom got %v, want a CyclicGraphError", err)
+	}
+	if _, err := g.CoffmanGrahamSortFrom(2, "c"); !errors.As(err, &cyclic) {
+		t.Errorf("CoffmanGrahamSortFrom got %v, want a CyclicGraphError", err)
+	}
+}
@@ -0,0 +1,186 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to the ReadyTracker.
+var (
+	ErrDeadlock = errors.New("No node is ready or in flight but not all are done")
+)
+
+// DeadlockError reports the nodes which can never become ready, since none
+// is ready or in flight although not all nodes are done, because the graph
+// contains a cycle or the tracker was used inconsistently.
+// It wraps ErrDeadlock so it can be tested for using errors.Is.
+type DeadlockError struct {
+	Waiting []Node
+}
+
+func (e *DeadlockError) Error() string {
+	return fmt.Sprintf("%v: %d nodes are waiting", ErrDeadlock, len(e.Waiting))
+}
+
+func (e *DeadlockError) Unwrap() error {
+	return ErrDeadlock
+}
+
+// ReadyNodes returns the nodes which are not done but all of whose
+// dependencies are, i.e. which can be executed next, in insertion order.
+func (g *DirectedGraph) ReadyNodes(done map[Node]bool) []Node {
+	ready := make([]Node, 0)
+	for _, node := range g.Nodes() {
+		if done[node] {
+			continue
+		}
+		waiting := false
+		g.EachIncoming(node, func(dependency Node) bool {
+			waiting = !done[dependency]
+			return !waiting
+		})
+		if !waiting {
+			ready = append(ready, node)
+		}
+	}
+	return ready
+}
+
+// ReadyTracker incrementally tracks which nodes of a graph are ready for
+// execution as their dependencies are done, maintaining the number of
+// dependencies every node is still waiting for. It is the execution-side
+// counterpart of the Coffman-Graham sorters.
+//
+// To drive a worker pool, Next hands out the ready nodes in order of priority
+// and marks them as in flight until they are marked as done.
+// The graph must not be modified while it is being tracked.
+type ReadyTracker struct {
+	graph Directed
+	state *readyState
+	done  map[Node]bool
+
+	queue    *readyQueue
+	queued   map[Node]bool
+	inFlight map[Node]bool
+}
+
+// ReadyStats holds the number of nodes in every state of a ReadyTracker.
+type ReadyStats struct {
+	// Pending is the number of nodes still waiting for dependencies.
+	Pending  int
+	Ready    int
+	InFlight int
+	Done     int
+}
+
+// NewReadyTracker returns a tracker for the graph with no node done, which
+// hands out the nodes with the greatest height first, i.e. those on the
+// critical path, see NodeHeights.
+func NewReadyTracker(graph Directed) *ReadyTracker {
+	heights, err := asDirectedGraph(graph).NodeHeights()
+	if err != nil {
+		// a cyclic graph deadlocks anyway, see Next
+		return NewReadyTrackerWithPriority(graph, nil)
+	}
+	return NewReadyTrackerWithPriority(graph, func(a, b Node) bool {
+		return heights[a] > heights[b]
+	})
+}
+
+// NewReadyTrackerWithPriority returns a tracker for the graph with no node
+// done, which hands out the smallest ready nodes according to the comparison
+// function first. Ties, and all nodes when the function is nil, are broken by
+// insertion order.
+func NewReadyTrackerWithPriority(graph Directed, less func(a, b Node) bool) *ReadyTracker {
+	graph = directedView(graph)
+	t := &ReadyTracker{
+		graph:    graph,
+		state:    newReadyState(graph),
+		done:     make(map[Node]bool, graph.NodeCount()),
+		queue:    newReadyQueue(graph, less),
+		queued:   make(map[Node]bool),
+		inFlight: make(map[Node]bool),
+	}
+	for _, node := range t.state.Roots() {
+		t.push(node)
+	}
+	return t
+}
+
+func (t *ReadyTracker) push(node Node) {
+	t.queue.Push(node)
+	t.queued[node] = true
+}
+
+// Next hands out up to max ready nodes in order of priority, marking them as
+// in flight. It returns no nodes once all nodes are done, and a
+// DeadlockError if no node is ready or in flight before that.
+func (t *ReadyTracker) Next(max int) ([]Node, error) {
+	nodes := make([]Node, 0)
+	for len(nodes) < max && t.queue.Len() > 0 {
+		node := t.queue.Pop()
+		if !t.queued[node] {
+			// marked as done while queued
+			continue
+		}
+		delete(t.queued, node)
+		t.inFlight[node] = true
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 0 && len(t.inFlight) == 0 && len(t.queued) == 0 && len(t.done) < t.graph.NodeCount() {
+		waiting := make([]Node, 0)
+		for _, node := range t.graph.Nodes() {
+			if !t.done[node] {
+				waiting = append(waiting, node)
+			}
+		}
+		return nil, &DeadlockError{Waiting: waiting}
+	}
+	return nodes, nil
+}
+
+// Stats returns the number of nodes in every state.
+func (t *ReadyTracker) Stats() ReadyStats {
+	stats := ReadyStats{
+		Ready:    len(t.queued),
+		InFlight: len(t.inFlight),
+		Done:     len(t.done),
+	}
+	stats.Pending = t.graph.NodeCount() - stats.Ready - stats.InFlight - stats.Done
+	return stats
+}
+
+// Roots returns the nodes which are ready before any node is done, i.e.
+// those without dependencies, in insertion order.
+func (t *ReadyTracker) Roots() []Node {
+	return t.state.Roots()
+}
+
+// MarkDone marks the node as done, returning its dependants which became
+// ready as a result in the order of its outgoing edges. They are handed out
+// by Next as well. It takes time proportional to the node's outdegree.
+// Marking a node which is already done, or does not exist within the graph,
+// has no effect.
+func (t *ReadyTracker) MarkDone(node Node) []Node {
+	if t.done[node] || !t.graph.NodeExists(node) {
+		return nil
+	}
+	t.done[node] = true
+	delete(t.inFlight, node)
+	delete(t.queued, node)
+
+	ready := make([]Node, 0)
+	t.state.Release(node, func(dependant Node) {
+		if !t.done[dependant] {
+			ready = append(ready, dependant)
+			t.push(dependant)
+		}
+	})
+	return ready
+}
+
+// IsDone determines whether the node was marked as done.
+func (t *ReadyTracker) IsDone(node Node) bool {
+	return t.done[node]
+}
@@ -0,0 +1,150 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func diamondGraph() *DirectedGraph {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "d")
+	g.AddEdge("c", "d")
+	return g
+}
+
+func TestReadyNodes(t *testing.T) {
+	g := diamondGraph()
+	cases := []struct {
+		done []Node
+		want []Node
+	}{
+		{nil, []Node{"a"}},
+		{[]Node{"a"}, []Node{"b", "c"}},
+		{[]Node{"a", "c"}, []Node{"b"}},
+		{[]Node{"a", "b", "c"}, []Node{"d"}},
+		{[]Node{"a", "b", "c", "d"}, []Node{}},
+	}
+	for _, c := range cases {
+		done := make(map[Node]bool)
+		for _, node := range c.done {
+			done[node] = true
+		}
+		if got := g.ReadyNodes(done); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("with %v done got %v, want %v", c.done, got, c.want)
+		}
+	}
+}
+
+func TestReadyTrackerMarkDone(t *testing.T) {
+	tracker := NewReadyTracker(diamondGraph())
+	if roots := tracker.Roots(); !reflect.DeepEqual(roots, []Node{"a"}) {
+		t.Errorf("got the roots %v, want [a]", roots)
+	}
+	steps := []struct {
+		node Node
+		want []Node
+	}{
+		{"a", []Node{"b", "c"}},
+		{"b", []Node{}},
+		{"b", nil},
+		{"c", []Node{"d"}},
+		{"d", []Node{}},
+		{"missing", nil},
+	}
+	for _, step := range steps {
+		if got := tracker.MarkDone(step.node); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("marking %v as done released %v, want %v", step.node, got, step.want)
+		}
+	}
+	if !tracker.IsDone("d") || tracker.IsDone("missing") {
+		t.Error("IsDone got the wrong answer")
+	}
+}
+
+func TestReadyTrackerSimulation(t *testing.T) {
+	g := layeredGraph(200, 3)
+	tracker := NewReadyTracker(g)
+	random := rand.New(rand.NewSource(1))
+
+	// every worker holds a node until its random duration has passed
+	const workers = 4
+	running := make(map[Node]int)
+	done := make(map[Node]int)
+	for clock := 0; ; clock++ {
+		for node, finish := range running {
+			if finish == clock {
+				delete(running, node)
+				tracker.MarkDone(node)
+			}
+		}
+		nodes, err := tracker.Next(workers - len(running))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, node := range nodes {
+			for _, dependency := range g.IncomingEdges(node) {
+				if !tracker.IsDone(dependency) {
+					t.Fatalf("%v was handed out before its dependency %v", node, dependency)
+				}
+			}
+			done[node]++
+			running[node] = clock + 1 + random.Intn(5)
+		}
+		stats := tracker.Stats()
+		if stats.InFlight != len(running) || stats.Pending+stats.Ready+stats.InFlight+stats.Done != g.NodeCount() {
+			t.Fatalf("got the stats %+v with %d running", stats, len(running))
+		}
+		if len(running) == 0 {
+			break
+		}
+	}
+
+	if stats := tracker.Stats(); stats.Done != g.NodeCount() {
+		t.Errorf("got the stats %+v, want all nodes done", stats)
+	}
+	for _, node := range g.Nodes() {
+		if done[node] != 1 {
+			t.Errorf("%v was handed out %d times", node, done[node])
+		}
+	}
+}
+
+func TestReadyTrackerPriority(t *testing.T) {
+	// b heads the longer path, so it is handed out first
+	g := NewDirectedGraph()
+	g.AddEdge("a", "x")
+	g.AddEdge("b", "y")
+	g.AddEdge("y", "z")
+	tracker := NewReadyTracker(g)
+	if nodes, err := tracker.Next(1); err != nil || !reflect.DeepEqual(nodes, []Node{"b"}) {
+		t.Errorf("got %v, %v, want [b]", nodes, err)
+	}
+}
+
+func TestReadyTrackerDeadlock(t *testing.T) {
+	g := diamondGraph()
+	g.AddEdge("d", "b")
+	tracker := NewReadyTracker(g)
+	nodes, err := tracker.Next(4)
+	if err != nil || !reflect.DeepEqual(nodes, []Node{"a"}) {
+		t.Fatalf("got %v, %v, want [a]", nodes, err)
+	}
+	tracker.MarkDone("a")
+	if nodes, err := tracker.Next(4); err != nil || !reflect.DeepEqual(nodes, []Node{"c"}) {
+		t.Fatalf("got %v, %v, want [c]", nodes, err)
+	}
+	tracker.MarkDone("c")
+
+	_, err = tracker.Next(4)
+	var deadlock *DeadlockError
+	if !errors.As(err, &deadlock) || !errors.Is(err, ErrDeadlock) {
+		t.Fatalf("got %v, want a DeadlockError", err)
+	}
+	if !reflect.DeepEqual(deadlock.Waiting, []Node{"b", "d"}) {
+		t.Errorf("got the waiting nodes %v, want [b d]", deadlock.Waiting)
+	}
+}
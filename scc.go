@@ -0,0 +1,152 @@
+package graff
+
+import (
+	"sort"
+)
+
+// StronglyConnectedComponents partitions the graph's nodes into strongly
+// connected components, i.e. maximal sets of nodes which all reach each
+// other, using Tarjan's algorithm. The components are in topological order,
+// so that edges only lead from a component to a later one, and the nodes of
+// a component are in insertion order.
+// See https://en.wikipedia.org/wiki/Tarjan's_strongly_connected_components_algorithm
+func (g *DirectedGraph) StronglyConnectedComponents() [][]Node {
+	t := &tarjan{
+		graph:   g,
+		index:   make(map[Node]int, g.NodeCount()),
+		lowlink: make(map[Node]int, g.NodeCount()),
+		onStack: make(map[Node]bool),
+		stack:   make([]Node, 0),
+		found:   make([][]Node, 0),
+	}
+	for _, node := range g.Nodes() {
+		if _, ok := t.index[node]; !ok {
+			t.visit(node)
+		}
+	}
+
+	// Tarjan's algorithm finds the components in reverse topological order
+	components := make([][]Node, len(t.found))
+	position := make(map[Node]int, g.NodeCount())
+	for i, node := range g.Nodes() {
+		position[node] = i
+	}
+	for i, found := range t.found {
+		// restore the insertion order of the members
+		sort.Slice(found, func(a, b int) bool {
+			return position[found[a]] < position[found[b]]
+		})
+		components[len(t.found)-1-i] = found
+	}
+	return components
+}
+
+type tarjan struct {
+	graph   *DirectedGraph
+	counter int
+	index   map[Node]int
+	lowlink map[Node]int
+	onStack map[Node]bool
+	stack   []Node
+	found   [][]Node
+}
+
+func (t *tarjan) visit(node Node) {
+	t.index[node] = t.counter
+	t.lowlink[node] = t.counter
+	t.counter++
+	t.stack = append(t.stack, node)
+	t.onStack[node] = true
+
+	t.graph.EachOutgoing(node, func(outgoing Node) bool {
+		if _, ok := t.index[outgoing]; !ok {
+			t.visit(outgoing)
+			if t.lowlink[outgoing] < t.lowlink[node] {
+				t.lowlink[node] = t.lowlink[outgoing]
+			}
+		} else if t.onStack[outgoing] && t.index[outgoing] < t.lowlink[node] {
+			t.lowlink[node] = t.index[outgoing]
+		}
+		return true
+	})
+
+	// the node is the root of a component consisting of the nodes above it
+	if t.lowlink[node] == t.index[node] {
+		i := len(t.stack) - 1
+		for t.stack[i] != node {
+			i--
+		}
+		component := copyNodes(t.stack[i:])
+		for _, member := range component {
+			delete(t.onStack, member)
+		}
+		t.stack = t.stack[:i]
+		t.found = append(t.found, component)
+	}
+}
+
+// Condense returns the condensation of the graph, in which every strongly
+// connected component is contracted into a single node, together with the
+// components as returned by StronglyConnectedComponents. The nodes of the
+// condensation are the indices of the components, of type int, and an edge
+// leads from one component to another if any edge leads between their
+// members. The condensation is always acyclic.
+func (g *DirectedGraph) Condense() (*DirectedGraph, [][]Node) {
+	components := g.StronglyConnectedComponents()
+	componentOf := make(map[Node]int, g.NodeCount())
+	condensed := NewDirectedGraph()
+	for i, component := range components {
+		condensed.AddNode(i)
+		for _, node := range component {
+			componentOf[node] = i
+		}
+	}
+	for _, from := range g.Nodes() {
+		g.EachOutgoing(from, func(to Node) bool {
+			if a, b := componentOf[from], componentOf[to]; a != b {
+				condensed.AddEdge(a, b)
+			}
+			return true
+		})
+	}
+	return condensed, components
+}
+
+// CoffmanGrahamSortCondensed levels a graph which may contain cycles by
+// leveling its condensation (see Condense) with the Coffman-Graham sorter,
+// with every component taking as many slots of the width as it has members,
+// and then listing the members of every component together within the
+// component's layer. A component larger than the width is placed in a layer
+// of its own, which then exceeds the width. The order of the members of a
+// component is arbitrary, as no order between them respects all edges.
+// Besides the layers it returns the components which contain a cycle, i.e.
+// those with multiple members or a self-loop.
+func (g *DirectedGraph) CoffmanGrahamSortCondensed(width int) ([][]Node, [][]Node, error) {
+	condensed, components := g.Condense()
+	sorter, err := NewCoffmanGrahamSorterWithOptions(condensed, WithWidth(width), WithNodeSize(func(node Node) int {
+		return len(components[node.(int)])
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+	sorted, err := sorter.Sort()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	layers := make([][]Node, len(sorted))
+	for i, layer := range sorted {
+		layers[i] = make([]Node, 0, len(layer))
+		for _, node := range layer {
+			layers[i] = append(layers[i], components[node.(int)]...)
+		}
+	}
+
+	cyclic := make([][]Node, 0)
+	for _, component := range components {
+		if len(component) > 1 || g.EdgeExists(component[0], component[0]) {
+			cyclic = append(cyclic, component)
+		}
+	}
+	return layers, cyclic, nil
+}
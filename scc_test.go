@@ -0,0 +1,111 @@
+package graff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCoffmanGrahamSortCondensed(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "c")
+	g.AddEdge("d", "e")
+	g.AddEdge("s", "s")
+	g.AddEdge("s", "e")
+	for _, edge := range [][2]string{{"w", "x"}, {"x", "y"}, {"y", "z"}, {"z", "w"}} {
+		g.AddEdge(edge[0], edge[1])
+	}
+
+	layers, cyclic, err := g.CoffmanGrahamSortCondensed(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the members of every component share a layer and are listed together
+	levels := make(map[Node]int)
+	for level, layer := range layers {
+		for _, node := range layer {
+			levels[node] = level
+		}
+	}
+	if len(levels) != g.NodeCount() {
+		t.Fatalf("leveled %d nodes, want %d: %v", len(levels), g.NodeCount(), layers)
+	}
+	componentOf := make(map[Node]int)
+	for i, component := range g.StronglyConnectedComponents() {
+		level := levels[component[0]]
+		for _, node := range component {
+			componentOf[node] = i
+			if levels[node] != level {
+				t.Errorf("component %v is split across layers %v", component, layers)
+			}
+		}
+		if !containsRun(layers[level], component) {
+			t.Errorf("component %v is not listed together in layer %v", component, layers[level])
+		}
+	}
+
+	// edges between components point to later layers, and only a component
+	// larger than the width exceeds it
+	for _, from := range g.Nodes() {
+		for _, to := range g.OutgoingEdges(from) {
+			if componentOf[from] != componentOf[to] && levels[from] >= levels[to] {
+				t.Errorf("edge %v -> %v points from level %d to level %d", from, to, levels[from], levels[to])
+			}
+		}
+	}
+	for level, layer := range layers {
+		if len(layer) > 3 && level != levels["w"] {
+			t.Errorf("layer %d exceeds the width: %v", level, layer)
+		}
+	}
+	if len(layers[levels["w"]]) != 4 {
+		t.Errorf("the component of 4 members shares its layer: %v", layers)
+	}
+
+	want := [][]Node{{"w", "x", "y", "z"}, {"s"}, {"a", "b"}, {"c", "d"}}
+	if !reflect.DeepEqual(sortedComponents(cyclic), sortedComponents(want)) {
+		t.Errorf("got cyclic components %v, want %v", cyclic, want)
+	}
+
+	if _, _, err := g.CoffmanGrahamSortCondensed(0); err == nil {
+		t.Error("a width of 0 was accepted")
+	}
+}
+
+// containsRun reports whether the members occur next to each other in the
+// layer, in any order.
+func containsRun(layer []Node, members []Node) bool {
+	set := make(map[Node]bool, len(members))
+	for _, member := range members {
+		set[member] = true
+	}
+	for i := range layer {
+		if i+len(members) > len(layer) {
+			break
+		}
+		run := true
+		for _, node := range layer[i : i+len(members)] {
+			run = run && set[node]
+		}
+		if run {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedComponents renders the components in a canonical order.
+func sortedComponents(components [][]Node) []string {
+	rendered := make([]string, len(components))
+	for i, component := range components {
+		rendered[i] = fmt.Sprint(component)
+	}
+	sort.Strings(rendered)
+	return rendered
+}
@@ -0,0 +1,57 @@
+package graff
+
+// SliceByLevels returns the subgraph induced by the nodes whose level lies
+// between lo and hi inclusive, e.g. the layers of a Coffman-Graham sort
+// making up a scheduling window, along with the edges crossing its
+// boundaries: boundaryIn holds the edges entering the window from nodes on a
+// level below lo, and boundaryOut those leaving it for nodes on a level above
+// hi. The subgraph keeps the graph's node order, and the attributes and edge
+// multiplicities of a DirectedGraph, and the boundary edges are in the
+// graph's node order.
+//
+// Nodes without a level are left out, as are the edges touching them, and so
+// are edges pointing from a higher level to a lower one, which a valid
+// layering does not have.
+func SliceByLevels(g Directed, levels map[Node]int, lo int, hi int) (*DirectedGraph, []Edge, []Edge) {
+	g = directedView(g)
+	src, _ := g.(*DirectedGraph)
+	inside := func(node Node) bool {
+		level, ok := levels[node]
+		return ok && level >= lo && level <= hi
+	}
+
+	slice := NewDirectedGraph()
+	boundaryIn := make([]Edge, 0)
+	boundaryOut := make([]Edge, 0)
+	for _, node := range g.Nodes() {
+		if inside(node) {
+			slice.AddNode(node)
+			if src != nil {
+				slice.copyNodeAttrs(src, node, node)
+			}
+		}
+	}
+	for _, from := range g.Nodes() {
+		fromLevel, ok := levels[from]
+		if !ok {
+			continue
+		}
+		for _, to := range g.OutgoingEdges(from) {
+			toLevel, ok := levels[to]
+			if !ok {
+				continue
+			}
+			switch {
+			case inside(from) && inside(to) && src != nil:
+				slice.copyEdge(src, from, to, from, to)
+			case inside(from) && inside(to):
+				slice.AddEdge(from, to)
+			case fromLevel < lo && inside(to):
+				boundaryIn = append(boundaryIn, Edge{from, to})
+			case inside(from) && toLevel > hi:
+				boundaryOut = append(boundaryOut, Edge{from, to})
+			}
+		}
+	}
+	return slice, boundaryIn, boundaryOut
+}
@@ -0,0 +1,44 @@
+package graff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSliceByLevelsThreeBands(t *testing.T) {
+	// the bands are levels 0-1, 2-3 and 4-5, and the middle one is sliced
+	g := NewDirectedGraph()
+	levels := map[Node]int{"a": 0, "b": 1, "c": 2, "d": 3, "e": 3, "f": 4, "g": 5, "h": 2}
+	for _, node := range []Node{"a", "b", "c", "d", "e", "f", "g", "h", "unleveled"} {
+		g.AddNode(node)
+	}
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "c")
+	g.AddEdge("b", "h")
+	g.AddEdgeCounted("c", "d")
+	g.AddEdgeCounted("c", "d")
+	g.AddEdge("h", "e")
+	g.AddEdge("d", "f")
+	g.AddEdge("e", "g")
+	g.AddEdge("a", "g")
+	g.AddEdge("c", "unleveled")
+	g.SetEdgeAttr("c", "d", WeightAttr, 3)
+
+	slice, in, out := SliceByLevels(g, levels, 2, 3)
+	if want := []Node{"c", "d", "e", "h"}; !reflect.DeepEqual(slice.Nodes(), want) {
+		t.Errorf("the slice holds %v, want %v", slice.Nodes(), want)
+	}
+	if slice.EdgeCount() != 2 || slice.EdgeMultiplicity("c", "d") != 2 || !slice.EdgeExists("h", "e") {
+		t.Error("the slice lacks the edges within the band")
+	}
+	if weight, _ := slice.EdgeAttr("c", "d", WeightAttr); weight != 3 {
+		t.Errorf("the edge c -> d weighs %v in the slice", weight)
+	}
+	if want := []Edge{{"a", "c"}, {"b", "c"}, {"b", "h"}}; !reflect.DeepEqual(in, want) {
+		t.Errorf("the edges entering the band are %v, want %v", in, want)
+	}
+	if want := []Edge{{"d", "f"}, {"e", "g"}}; !reflect.DeepEqual(out, want) {
+		t.Errorf("the edges leaving the band are %v, want %v", out, want)
+	}
+}
@@ -1,8 +1,10 @@
 package graff
 
 import (
-	"fmt"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 )
 
 // Errors relating to the DFSSorter.
@@ -10,37 +12,171 @@ var (
 	ErrCyclicGraph = errors.New("The graph cannot be cyclic")
 )
 
+// CyclicGraphError reports the cycle which prevented the graph from being
+// sorted. A self-loop is reported as a cycle of a single node.
+// It wraps ErrCyclicGraph so it can be tested for using errors.Is.
+type CyclicGraphError struct {
+	Cycle []Node
+}
+
+func (e *CyclicGraphError) Error() string {
+	var b strings.Builder
+	b.WriteString(ErrCyclicGraph.Error())
+	b.WriteString(": ")
+	for _, node := range e.Cycle {
+		fmt.Fprintf(&b, "%v -> ", node)
+	}
+	if len(e.Cycle) > 0 {
+		fmt.Fprintf(&b, "%v", e.Cycle[0])
+	}
+	return b.String()
+}
+
+func (e *CyclicGraphError) Unwrap() error {
+	return ErrCyclicGraph
+}
+
+// cycleError returns a CyclicGraphError for the first cycle within the graph.
+func (g *DirectedGraph) cycleError() error {
+	return &CyclicGraphError{Cycle: g.findCycle()}
+}
+
 // DFSSorter topologically sorts a directed graph's nodes based on the
 // directed edges between them using the Depth-first search algorithm.
 type DFSSorter struct {
-	graph      *DirectedGraph
+	graph      Directed
 	sorted     []Node
 	visiting   map[Node]bool
 	discovered map[Node]bool
+	path       []Node
+	// visitOutgoing is visitEdge bound once, so that iterating the edges of
+	// every node does not allocate a closure, and err is the error it met
+	visitOutgoing func(Node) bool
+	err           error
+	// result holds the nodes sorted by the last successful sort, see String
+	result []Node
+
+	config *sorterConfig
+}
+
+// NewDFSSorter returns a new DFS sorter, whose searches start from the
+// graph's nodes in insertion order. The graph may be any implementation of
+// Directed.
+func NewDFSSorter(graph Directed) *DFSSorter {
+	return newDFSSorter(graph, &sorterConfig{})
 }
 
-// NewDFSSorter returns a new DFS sorter.
-func NewDFSSorter(graph *DirectedGraph) *DFSSorter {
+// NewDFSSorterWithOptions returns a new DFS sorter configured by the
+// specified options, e.g.
+//
+//	NewDFSSorterWithOptions(g, WithRootOrder(roots), WithFormatLimit(20))
+//
+// Only WithRootOrder, WithRootLess and WithFormatLimit affect the sorter.
+func NewDFSSorterWithOptions(graph Directed, opts ...SorterOption) (*DFSSorter, error) {
+	config, err := applySorterOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return newDFSSorter(graph, config), nil
+}
+
+func newDFSSorter(graph Directed, config *sorterConfig) *DFSSorter {
 	return &DFSSorter{
-		graph: graph,
+		graph:  directedView(graph),
+		config: config,
 	}
 }
 
+// Reset rebinds the sorter to the graph, which may differ from the one it was
+// created for, so that it can be reused without reallocating its internal
+// state. A sorter must not be used by multiple goroutines at once.
+func (s *DFSSorter) Reset(graph Directed) {
+	s.graph = directedView(graph)
+}
+
+// init prepares the sorter's state for sorting, reusing the maps allocated
+// by a previous sort. The sorted slice is always allocated anew since it is
+// returned to the caller.
 func (s *DFSSorter) init() {
 	s.sorted = make([]Node, 0, s.graph.NodeCount())
-	s.visiting = make(map[Node]bool)
-	s.discovered = make(map[Node]bool, s.graph.NodeCount())
+	s.err = nil
+	if s.visiting == nil {
+		s.visitOutgoing = s.visitEdge
+		s.visiting = make(map[Node]bool)
+		s.discovered = make(map[Node]bool, s.graph.NodeCount())
+		s.path = make([]Node, 0)
+		return
+	}
+	for node := range s.visiting {
+		delete(s.visiting, node)
+	}
+	for node := range s.discovered {
+		delete(s.discovered, node)
+	}
+	s.path = s.path[:0]
 }
 
 // Sort returns the sorted nodes.
 func (s *DFSSorter) Sort() ([]Node, error) {
+	roots, err := s.roots()
+	if err != nil {
+		return nil, err
+	}
+	sorted, err := s.sortFrom(roots)
+	if err != nil {
+		return nil, err
+	}
+	s.result = sorted
+	return sorted, nil
+}
+
+// roots returns the nodes to start the searches from in order, see
+// WithRootOrder and WithRootLess.
+func (s *DFSSorter) roots() ([]Node, error) {
+	rootOrder, rootLess := s.config.rootOrder, s.config.rootLess
+	if rootOrder == nil && rootLess == nil {
+		return s.graph.Nodes(), nil
+	}
+	if err := checkDirectedNodes(s.graph, rootOrder); err != nil {
+		return nil, err
+	}
+
+	ordered := make(map[Node]bool, len(rootOrder))
+	roots := make([]Node, 0, s.graph.NodeCount())
+	for _, node := range rootOrder {
+		if !ordered[node] {
+			ordered[node] = true
+			roots = append(roots, node)
+		}
+	}
+	rest := make([]Node, 0, s.graph.NodeCount()-len(roots))
+	for _, node := range s.graph.Nodes() {
+		if !ordered[node] {
+			rest = append(rest, node)
+		}
+	}
+	if rootLess != nil {
+		sort.SliceStable(rest, func(i, j int) bool {
+			return rootLess(rest[i], rest[j])
+		})
+	}
+	return append(roots, rest...), nil
+}
+
+// sortFrom returns the nodes reachable from the sources in topological order.
+func (s *DFSSorter) sortFrom(sources []Node) ([]Node, error) {
 	s.init()
+	guard := guardOf(s.graph)
 
 	// > while there are unmarked nodes do
-	for _, node := range s.graph.Nodes() {
+	prefetch(s.graph, sources)
+	for _, node := range sources {
 		if err := s.visit(node); err != nil {
 			return nil, err
 		}
+		if err := guard.check(); err != nil {
+			return nil, err
+		}
 	}
 
 	// as the nodes were appended to the slice for performance reasons,
@@ -61,26 +197,40 @@ func (s *DFSSorter) visit(node Node) error {
 	}
 	// > if n has a temporary mark then stop (not a DAG)
 	if visiting, ok := s.visiting[node]; ok && visiting {
+		for i, visited := range s.path {
+			if visited == node {
+				return &CyclicGraphError{Cycle: copyNodes(s.path[i:])}
+			}
+		}
 		return ErrCyclicGraph
 	}
 
 	// > mark n temporarily
 	s.visiting[node] = true
+	s.path = append(s.path, node)
 
 	// > for each node m with an edge from n to m do
-	for _, outgoing := range s.graph.OutgoingEdges(node) {
-		if err := s.visit(outgoing); err != nil {
-			return err
-		}
+	prefetchOutgoing(s.graph, node)
+	eachOutgoing(s.graph, node, s.visitOutgoing)
+	if s.err != nil {
+		return s.err
 	}
 
 	s.discovered[node] = true
 	delete(s.visiting, node)
+	s.path = s.path[:len(s.path)-1]
 
 	s.sorted = append(s.sorted, node)
 	return nil
 }
 
+// visitEdge visits the target of an edge, stopping the iteration over the
+// edges once a visit fails.
+func (s *DFSSorter) visitEdge(outgoing Node) bool {
+	s.err = s.visit(outgoing)
+	return s.err == nil
+}
+
 // DFSSort returns the graph's nodes in topological order based on the
 // directed edges between them using the Depth-first search algorithm.
 func (g *DirectedGraph) DFSSort() ([]Node, error) {
@@ -93,135 +243,202 @@ var (
 	ErrDependencyOrder = errors.New("The topological dependency order is incorrect")
 )
 
+// DependencyOrderError reports a node whose dependant had not been assigned
+// a level by the time the node itself was being leveled.
+// It wraps ErrDependencyOrder so it can be tested for using errors.Is.
+type DependencyOrderError struct {
+	Node      Node
+	Dependant Node
+}
+
+func (e *DependencyOrderError) Error() string {
+	return fmt.Sprintf("%v: %v depends on %v which has no level", ErrDependencyOrder, e.Node, e.Dependant)
+}
+
+func (e *DependencyOrderError) Unwrap() error {
+	return ErrDependencyOrder
+}
+
 // CoffmanGrahamSorter sorts a graph's nodes into a sequence of levels,
 // arranging so that a node which comes after another in the order is
 // assigned to a lower level, and that a level never exceeds the width.
+// Within a level the nodes are listed in the order they were assigned, which
+// only depends on the graph's node and edge insertion order (see also
+// WithRootOrder and WithTopologicalSorter), unless WithLayerOrder is used.
+// The graph may be any implementation of Directed; one which is not a
+// DirectedGraph is copied by every sort.
 // See https://en.wikipedia.org/wiki/Coffman–Graham_algorithm
 type CoffmanGrahamSorter struct {
-	graph *DirectedGraph
-	width int
-
-	layers [][]Node
-	levels map[Node]int
-	level int
+	graph Directed
+	*leveler
 }
 
 // NewCoffmanGrahamSorter returns a new Coffman-Graham sorter.
-func NewCoffmanGrahamSorter(graph *DirectedGraph, width int) *CoffmanGrahamSorter {
-	layers := make([][]Node, 0)
-	levels := make(map[Node]int, 0)
-	level := 0
-
-	return &CoffmanGrahamSorter{
-		graph: graph,
-		width: width,
-		layers : layers,
-		levels : levels,
-		level : level,
-	}
+//
+// Deprecated: Use NewCoffmanGrahamSorterWithOptions with WithWidth instead.
+func NewCoffmanGrahamSorter(graph Directed, width int) *CoffmanGrahamSorter {
+	return newCoffmanGrahamSorter(graph, &sorterConfig{width: width, widthSet: true})
 }
 
-
-// Sort returns the sorted nodes.
-// This version tries to optimize for directed graph (not reverse graph)
-func (s *CoffmanGrahamSorter) Sort() ([][]Node, error) {
-	// create a copy of the graph and remove transitive edges
-	//fmt.Println("-start-- layers", s.layers)
-
-	reduced := s.graph.Copy()
-	reduced.RemoveTransitives()
-
-	// topologically sort the graph nodes
-	nodes, err := reduced.DFSSort()
+// NewCoffmanGrahamSorterWithOptions returns a new Coffman-Graham sorter
+// configured by the specified options, e.g.
+//
+//	NewCoffmanGrahamSorterWithOptions(g, WithWidth(4), WithTracer(fn))
+//
+// The options are validated eagerly and a descriptive error is returned if
+// they are missing or conflicting.
+func NewCoffmanGrahamSorterWithOptions(graph Directed, opts ...SorterOption) (*CoffmanGrahamSorter, error) {
+	config, err := newSorterConfig(opts)
 	if err != nil {
 		return nil, err
 	}
+	return newCoffmanGrahamSorter(graph, config), nil
+}
 
-	//fmt.Println("--- DFS nodes", nodes);
-	layers := s.layers
-	levels := s.levels
-	level := s.level
-
-	//fmt.Println("--- level", level)
-	//fmt.Println("--- levels", levels, "layers", layers)
-
-	for _, node := range nodes {
-		//fmt.Println("--- node", node)
-		_, ok := levels[node]
-		if ok {
-			// if already assigned a level, dont need to assign again
-			continue
-		}
-
-		fmt.Println("--- node  -- not assigned", node)
+func newCoffmanGrahamSorter(graph Directed, config *sorterConfig) *CoffmanGrahamSorter {
+	return &CoffmanGrahamSorter{
+		graph:   directedView(graph),
+		leveler: newLeveler(config),
+	}
+}
 
-		dependantLevel := -1
-		for _, dependant := range reduced.IncomingEdges(node) {
+// Reset discards all levels and rebinds the sorter to the graph, which may
+// differ from the one it was created for, keeping its configuration and
+// reusing its internal state. A sorter must not be used by multiple
+// goroutines at once.
+func (s *CoffmanGrahamSorter) Reset(graph Directed) {
+	s.graph = directedView(graph)
+	s.reset()
+}
 
-			level, ok := levels[dependant]
-			//fmt.Println("--- dependant", node, "level", level)
+// ReducedGraph returns the transitively reduced copy of the graph leveled by
+// the last successful sort, or nil before the first one. It is the sorter's
+// internal copy and must not be modified. With WithoutTransitiveReduction it
+// is the graph itself.
+func (s *CoffmanGrahamSorter) ReducedGraph() *DirectedGraph {
+	return s.reducedGraph()
+}
 
-			if !ok {
-				return nil, ErrDependencyOrder
-			}
-			if level > dependantLevel {
-				dependantLevel = level
-			}
+// RemovedTransitiveEdges returns the transitive edges which were removed from
+// the graph before it was leveled by the last successful sort, in the graph's
+// node order, or nil before the first one. Together with the edges of
+// ReducedGraph they make up the graph's edges at the time.
+func (s *CoffmanGrahamSorter) RemovedTransitiveEdges() []Edge {
+	return s.removedTransitiveEdges()
+}
 
-			//fmt.Println("--- dependantLevel", dependantLevel)
-		}
+// DeferredAtLayer returns the nodes whose dependencies allowed placing them
+// on the level, but which were placed on a later level because the level had
+// no room left for them, ordered by their level. A node is only reported for
+// the first level it was eligible for, so the count tells how many more
+// nodes the level would have taken given more width. The result is empty for
+// levels which never filled up or do not exist.
+func (s *CoffmanGrahamSorter) DeferredAtLayer(level int) []Node {
+	return s.deferredAt(level)
+}
 
-		level := -1
+// OverCapacity returns the nodes whose demand exceeds the capacity in some
+// dimension, see WithCapacities, each of which was placed in a new layer of
+// its own, ordered by their level. It is empty without capacities.
+func (s *CoffmanGrahamSorter) OverCapacity() []Node {
+	return s.overCapacity()
+}
 
-		// find the first ununfilled layer outgoing the dependent layer
-		// skip this if the dependent layer is the last
+// PendingCount returns the number of the graph's nodes which were not
+// assigned a level yet. It takes constant time as it subtracts the number of
+// leveled nodes from the graph's, so it is only accurate if the nodes removed
+// from the graph were also removed from the sorter by RemoveNode.
+func (s *CoffmanGrahamSorter) PendingCount() int {
+	return s.pendingCount(s.graph)
+}
 
-		//fmt.Println("--- dependantLevel=", dependantLevel, ", len(layers) - 1 = ", len(layers) - 1)
+// Pending returns the graph's nodes which were not assigned a level yet, in
+// the graph's node order.
+func (s *CoffmanGrahamSorter) Pending() []Node {
+	return s.pendingNodes(s.graph)
+}
 
-		if dependantLevel < len(layers)-1 {
-			//fmt.Println("--- len(layers)-1=", len(layers)-1)
+// Sort returns the sorted nodes.
+// This version tries to optimize for directed graph (not reverse graph)
+func (s *CoffmanGrahamSorter) Sort() ([][]Node, error) {
+	return s.sort(nil)
+}
 
-			for i := dependantLevel + 1; i < len(layers); i++ {
-				// ensure the layer doesn't exceed the desired width
-				if len(layers[i]) < s.width {
-					level = i
-					break
-				}
-			}
-		}
+// sort levels the graph's nodes, calling assigned with the current layers
+// after every level assignment. If assigned returns an error the sort stops,
+// keeping the assignments made so far.
+func (s *CoffmanGrahamSorter) sort(assigned func(layers [][]Node) error) ([][]Node, error) {
+	graph, err := snapshot(s.graph)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.leveler.sort(graph, assigned); err != nil {
+		return nil, err
+	}
+	return s.layers, nil
+}
 
-		//fmt.Println("HEEERR level ", level);
-		// create a new layer new none was found
-		if level == -1 {
-			//fmt.Println("HEEERR level ", level, "layers", layers);
+// RemoveNode forgets the level of a node removed from the graph and removes
+// it from its layer, compacting the layer. Layers are never removed, so
+// the levels of the other nodes are unaffected, although a layer may become
+// empty. It returns whether the node had been assigned a level.
+func (s *CoffmanGrahamSorter) RemoveNode(node Node) bool {
+	return s.remove(node)
+}
 
-			layers = append(layers, make([]Node, 0, 1))
-			level = len(layers) - 1
-		}
+// RenameNode transfers the level of a node replaced by ReplaceNode to its
+// replacement, so that it keeps its place in the layers. It returns whether
+// the old node had been assigned a level.
+func (s *CoffmanGrahamSorter) RenameNode(old Node, new Node) bool {
+	return s.rename(old, new)
+}
 
-		//fmt.Println("--== level ", level);
+// Recompute discards all levels assigned by previous calls to Sort and
+// sorts the current graph from scratch, so that subsequent calls behave like
+// those of a fresh sorter. If sorting fails the previous levels are kept.
+func (s *CoffmanGrahamSorter) Recompute() ([][]Node, error) {
+	layers, _, err := s.RecomputeWithChanges()
+	return layers, err
+}
 
-		layers[level] = append(layers[level], node)
-		levels[node] = level
+// RecomputeWithChanges recomputes the levels like Recompute, additionally
+// reporting the nodes whose level changed compared to the previous levels
+// in the graph's node order.
+func (s *CoffmanGrahamSorter) RecomputeWithChanges() ([][]Node, []LevelChange, error) {
+	graph, err := snapshot(s.graph)
+	if err != nil {
+		return nil, nil, err
 	}
+	_, changes, err := s.recompute(graph)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.layers, changes, nil
+}
 
-	s.level = level
-	//fmt.Println("--== level ", level);
-	s.layers = layers;
-	//fmt.Println("ending--- levels", levels, "layers", layers)
-
-	return layers, nil
+// CheckConsistency returns the edges which violate the level ordering since
+// their target was assigned a level by a previous call to Sort before the
+// edge was added. The result is empty when the current levels are consistent
+// with the graph.
+func (s *CoffmanGrahamSorter) CheckConsistency() []Edge {
+	return s.checkConsistency(asDirectedGraph(s.graph))
 }
 
 // Sort returns the sorted nodes.
 // This version is orginal impl for directed graph (not reverse graph)
 func (s *CoffmanGrahamSorter) OrigSort() ([][]Node, error) {
 	// create a copy of the graph and remove transitive edges
-	reduced := s.graph.Copy()
-	reduced.RemoveTransitives()
+	graph, err := snapshot(s.graph)
+	if err != nil {
+		return nil, err
+	}
+	reduced := graph.Copy()
+	if err := reduced.RemoveTransitives(); err != nil {
+		return nil, err
+	}
 
 	// topologically sort the graph nodes
-	nodes, err := reduced.DFSSort()
+	nodes, err := s.config.topologicalSort(reduced)
 	if err != nil {
 		return nil, err
 	}
@@ -231,14 +448,20 @@ func (s *CoffmanGrahamSorter) OrigSort() ([][]Node, error) {
 
 	for _, node := range nodes {
 		dependantLevel := -1
-		for _, dependant := range reduced.IncomingEdges(node) {
+		var err error
+		reduced.EachIncoming(node, func(dependant Node) bool {
 			level, ok := levels[dependant]
 			if !ok {
-				return nil, ErrDependencyOrder
+				err = &DependencyOrderError{Node: node, Dependant: dependant}
+				return false
 			}
 			if level > dependantLevel {
 				dependantLevel = level
 			}
+			return true
+		})
+		if err != nil {
+			return nil, err
 		}
 
 		level := -1
@@ -247,7 +470,7 @@ func (s *CoffmanGrahamSorter) OrigSort() ([][]Node, error) {
 		if dependantLevel < len(layers)-1 {
 			for i := dependantLevel + 1; i < len(layers); i++ {
 				// ensure the layer doesn't exceed the desired width
-				if len(layers[i]) < s.width {
+				if len(layers[i]) < s.config.widthAt(i) {
 					level = i
 					break
 				}
@@ -261,6 +484,7 @@ func (s *CoffmanGrahamSorter) OrigSort() ([][]Node, error) {
 
 		layers[level] = append(layers[level], node)
 		levels[node] = level
+		s.config.trace(node, level)
 	}
 
 	return layers, nil
@@ -268,7 +492,7 @@ func (s *CoffmanGrahamSorter) OrigSort() ([][]Node, error) {
 
 
 func (g *DirectedGraph) CoffmanGrahamSorter(width int) (*CoffmanGrahamSorter) {
-	sorter := NewCoffmanGrahamSorter(g, width)
+	sorter := newCoffmanGrahamSorter(g, &sorterConfig{width: width, widthSet: true})
 	return sorter
 }
 
@@ -276,7 +500,7 @@ func (g *DirectedGraph) CoffmanGrahamSorter(width int) (*CoffmanGrahamSorter) {
 // arranging so that a node which comes after another in the order is
 // assigned to a lower level, and that a level never exceeds the specified width.
 func (g *DirectedGraph) CoffmanGrahamSort(width int) ([][]Node, error) {
-	sorter := NewCoffmanGrahamSorter(g, width)
+	sorter := newCoffmanGrahamSorter(g, &sorterConfig{width: width, widthSet: true})
 	return sorter.Sort()
 }
 
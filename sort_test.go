@@ -0,0 +1,671 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCoffmanGrahamSorterRemoveNode(t *testing.T) {
+	g := layeredGraph(30, 2)
+	s := NewCoffmanGrahamSorter(g, 3)
+	if _, err := s.Sort(); err != nil {
+		t.Fatal(err)
+	}
+	levels := make(map[Node]int, len(s.levels))
+	for node, level := range s.levels {
+		levels[node] = level
+	}
+
+	for _, node := range []Node{0, 7, 29} {
+		g.RemoveNode(node)
+		if !s.RemoveNode(node) {
+			t.Errorf("%v had no level", node)
+		}
+	}
+	if s.RemoveNode(7) {
+		t.Error("7 was removed twice")
+	}
+	g.AddEdge(1, "new")
+
+	layers, err := s.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for level, layer := range layers {
+		for _, node := range layer {
+			if before, ok := levels[node]; ok && before != level {
+				t.Errorf("%v moved from level %d to %d", node, before, level)
+			}
+		}
+	}
+	if level, ok := s.levels["new"]; !ok || level <= levels[1] {
+		t.Errorf("the new node has level %d, not above its dependency's %d", level, levels[1])
+	}
+	if violations := s.CheckConsistency(); len(violations) > 0 {
+		t.Errorf("violations %v", violations)
+	}
+}
+
+// rootsGraph has two independent subtrees and an isolated node.
+func rootsGraph() *DirectedGraph {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "a1")
+	g.AddEdge("a1", "a2")
+	g.AddEdge("b", "b1")
+	g.AddNode("c")
+	return g
+}
+
+func TestDFSSorterRootOrder(t *testing.T) {
+	g := rootsGraph()
+	sort := func(opts ...SorterOption) []Node {
+		t.Helper()
+		sorter, err := NewDFSSorterWithOptions(g, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		order, err := sorter.Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, violation := g.IsLinearExtension(order); !ok {
+			t.Fatalf("%v is not a topological order: %v", order, violation)
+		}
+		return order
+	}
+
+	aFirst := sort(WithRootOrder([]Node{"a", "b"}))
+	bFirst := sort(WithRootOrder([]Node{"b", "a"}))
+	if reflect.DeepEqual(aFirst, bFirst) {
+		t.Errorf("both root orders sorted to %v", aFirst)
+	}
+	// the omitted node is searched last, and so comes first
+	if want := []Node{"c", "b", "b1", "a", "a1", "a2"}; !reflect.DeepEqual(aFirst, want) {
+		t.Errorf("got %v, want %v", aFirst, want)
+	}
+
+	desc := func(a, b Node) bool { return a.(string) > b.(string) }
+	if got, want := sort(WithRootLess(desc)), sort(WithRootOrder([]Node{"c", "b1", "b", "a2", "a1", "a"})); !reflect.DeepEqual(got, want) {
+		t.Errorf("WithRootLess sorted to %v, want %v", got, want)
+	}
+}
+
+func TestDFSSorterRootOrderErrors(t *testing.T) {
+	g := rootsGraph()
+	sorter, err := NewDFSSorterWithOptions(g, WithRootOrder([]Node{"a", "missing"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notFound *NodeNotFoundError
+	if _, err := sorter.Sort(); !errors.As(err, &notFound) || notFound.Node != "missing" {
+		t.Errorf("got %v, want a NodeNotFoundError for missing", err)
+	}
+
+	if _, err := NewDFSSorterWithOptions(g, WithRootLess(nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v, want ErrInvalidOption", err)
+	}
+	_, err = NewCoffmanGrahamSorterWithOptions(g, WithWidth(2), WithRootOrder([]Node{"a"}), WithTieBreak(func(a, b Node) bool { return false }))
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestCoffmanGrahamSorterRootOrder(t *testing.T) {
+	g := NewDirectedGraph()
+	for _, node := range []Node{"a", "b", "c"} {
+		g.AddNode(node)
+	}
+	level := func(roots []Node) [][]Node {
+		t.Helper()
+		sorter, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(2), WithRootOrder(roots))
+		if err != nil {
+			t.Fatal(err)
+		}
+		layers, err := sorter.Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return layers
+	}
+
+	if got, want := level([]Node{"a", "b", "c"}), [][]Node{{"c", "b"}, {"a"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := level([]Node{"c", "b", "a"}), [][]Node{{"a", "b"}, {"c"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSelfLoop(t *testing.T) {
+	checkCycle := func(name string, err error) {
+		t.Helper()
+		var cyclic *CyclicGraphError
+		if !errors.As(err, &cyclic) || !reflect.DeepEqual(cyclic.Cycle, []Node{"a"}) {
+			t.Errorf("%s: got %v, want the one-node cycle a", name, err)
+		}
+	}
+
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "a")
+	if !g.EdgeExists("a", "a") || g.EdgeCount() != 2 {
+		t.Fatal("the self-loop was not added")
+	}
+	_, err := g.DFSSort()
+	checkCycle("DFSSort", err)
+	_, err = g.LexTopoSort(nil)
+	checkCycle("LexTopoSort", err)
+	_, err = g.CoffmanGrahamSort(2)
+	checkCycle("CoffmanGrahamSort", err)
+
+	e := NewEventGraph()
+	e.AddEdge("b", "a")
+	e.AddEdge("a", "a")
+	if !e.EdgeExists("a", "a") {
+		t.Fatal("the self-loop was not added to the event graph")
+	}
+	_, err = e.CausalOrder()
+	checkCycle("CausalOrder", err)
+
+	g.RemoveEdge("a", "a")
+	if g.EdgeExists("a", "a") {
+		t.Fatal("the self-loop was not removed")
+	}
+	if _, err := g.DFSSort(); err != nil {
+		t.Errorf("the graph is still cyclic: %v", err)
+	}
+}
+
+// fixedSorter returns the same order for every graph.
+type fixedSorter []Node
+
+func (s fixedSorter) Sort() ([]Node, error) {
+	return s, nil
+}
+
+func TestDependencyOrderError(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	backwards := WithTopologicalSorter(func(*DirectedGraph) TopologicalSorter {
+		return fixedSorter{"b", "a"}
+	})
+
+	sorter, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(2), backwards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sorter.Sort()
+	var order *DependencyOrderError
+	if !errors.As(err, &order) || !errors.Is(err, ErrDependencyOrder) {
+		t.Fatalf("got %v, want a DependencyOrderError", err)
+	}
+	if order.Node != "b" || order.Dependant != "a" {
+		t.Errorf("got node %v and dependant %v, want b and a", order.Node, order.Dependant)
+	}
+
+	optimized, err := NewOptimizedCoffmanGrahamSorterWithOptions(g, WithWidth(2), backwards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = optimized.EventSort()
+	if !errors.As(err, &order) {
+		t.Fatalf("EventSort returned %v, want a DependencyOrderError", err)
+	}
+	if !g.EdgeExists(order.Dependant, order.Node) {
+		t.Errorf("EventSort reported node %v and dependant %v, which share no edge", order.Node, order.Dependant)
+	}
+}
+
+func TestRecomputeWithChanges(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	s := NewCoffmanGrahamSorter(g, 2)
+	if _, err := s.Sort(); err != nil {
+		t.Fatal(err)
+	}
+
+	// c is already leveled above b, so the new edge to b is violated
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "b")
+	g.RemoveEdge("b", "c")
+	if _, err := s.Sort(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.CheckConsistency()) == 0 {
+		t.Fatal("the stale levels went unnoticed")
+	}
+
+	want, err := NewCoffmanGrahamSorter(g, 2).Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := make(map[Node]int, len(s.levels))
+	for node, level := range s.levels {
+		before[node] = level
+	}
+	layers, changes, err := s.RecomputeWithChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(layers, want) {
+		t.Errorf("recomputed %v, a fresh sorter %v", layers, want)
+	}
+	if violations := s.CheckConsistency(); len(violations) > 0 {
+		t.Errorf("violations %v remain", violations)
+	}
+	for _, change := range changes {
+		if before[change.Node] != change.OldLevel || s.levels[change.Node] != change.NewLevel || change.OldLevel == change.NewLevel {
+			t.Errorf("the change %+v does not match the levels", change)
+		}
+	}
+	moved := 0
+	for node, level := range s.levels {
+		if before[node] != level {
+			moved++
+		}
+	}
+	if moved == 0 || moved != len(changes) {
+		t.Errorf("%d nodes moved but %d changes were reported", moved, len(changes))
+	}
+
+	g.AddEdge("b", "a")
+	if _, err := s.Recompute(); err == nil {
+		t.Fatal("a cyclic graph was recomputed")
+	}
+	if !reflect.DeepEqual(s.layers, layers) {
+		t.Errorf("the failed recompute changed the layers to %v", s.layers)
+	}
+}
+
+func TestOptimizedRecompute(t *testing.T) {
+	g := layeredGraph(40, 2)
+	s := NewOptimizedCoffmanGrahamSorter(g, 3)
+	if _, err := s.EventSort(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 30; i += 3 {
+		g.AddEdge(i, i+10)
+	}
+	if _, err := s.EventSort(); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := NewOptimizedCoffmanGrahamSorter(g, 3)
+	want, err := fresh.EventSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := s.Recompute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(layers, want) {
+		t.Errorf("recomputed %v, a fresh sorter %v", layers, want)
+	}
+
+	// later calls continue like those of the fresh sorter
+	g.AddEdge("new", 5)
+	g.AddEdge(20, "other")
+	want, err = fresh.EventSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.EventSort(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("continued with %v (%v), the fresh sorter with %v", got, err, want)
+	}
+}
+
+func TestSortConcurrentModification(t *testing.T) {
+	g := layeredGraph(20, 2)
+	mutate := WithTracer(func(node Node, level int) {
+		g.AddEdge(node, "late")
+	})
+
+	s, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(3), mutate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Sort(); !errors.Is(err, ErrConcurrentModification) {
+		t.Errorf("Sort got %v, want ErrConcurrentModification", err)
+	}
+
+	o, err := NewOptimizedCoffmanGrahamSorterWithOptions(g, WithWidth(3), mutate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.EventSort(); !errors.Is(err, ErrConcurrentModification) {
+		t.Errorf("EventSort got %v, want ErrConcurrentModification", err)
+	}
+}
+
+func TestSortIgnoresExistingEdges(t *testing.T) {
+	g := layeredGraph(20, 2)
+	g.AddEdge(0, "late")
+	generation := g.generation
+	g.AddEdge(0, "late")
+	if g.generation != generation {
+		t.Error("re-adding an existing edge recorded a modification")
+	}
+
+	// re-adding an edge while sorting is not a concurrent modification
+	s, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(3), WithTracer(func(node Node, level int) {
+		g.AddEdge(0, "late")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Sort(); err != nil {
+		t.Errorf("Sort got %v", err)
+	}
+}
+
+func TestSorterReset(t *testing.T) {
+	first, second := layeredGraph(30, 2), rootsGraph()
+
+	dfs := NewDFSSorter(first)
+	if _, err := dfs.Sort(); err != nil {
+		t.Fatal(err)
+	}
+	dfs.Reset(second)
+	got, err := dfs.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := NewDFSSorter(second).Sort(); !reflect.DeepEqual(got, want) {
+		t.Errorf("the reset DFS sorter got %v, want %v", got, want)
+	}
+
+	cg := NewCoffmanGrahamSorter(first, 2)
+	if _, err := cg.Sort(); err != nil {
+		t.Fatal(err)
+	}
+	cg.Reset(second)
+	layers, err := cg.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := NewCoffmanGrahamSorter(second, 2).Sort(); !reflect.DeepEqual(layers, want) {
+		t.Errorf("the reset Coffman-Graham sorter got %v, want %v", layers, want)
+	}
+
+	opt := NewOptimizedCoffmanGrahamSorter(first, 2)
+	if _, err := opt.EventSort(); err != nil {
+		t.Fatal(err)
+	}
+	opt.Reset(second)
+	layers, err = opt.EventSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := NewOptimizedCoffmanGrahamSorter(second, 2).EventSort(); !reflect.DeepEqual(layers, want) {
+		t.Errorf("the reset optimized sorter got %v, want %v", layers, want)
+	}
+}
+
+func BenchmarkDFSSorterReuse(b *testing.B) {
+	graphs := make([]*DirectedGraph, 100)
+	for i := range graphs {
+		graphs[i] = layeredGraph(20+i%10, 2)
+	}
+	b.Run("fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := NewDFSSorter(graphs[i%len(graphs)]).Sort(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("reset", func(b *testing.B) {
+		sorter := NewDFSSorter(graphs[0])
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sorter.Reset(graphs[i%len(graphs)])
+			if _, err := sorter.Sort(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// gridGraph returns a grid whose nodes point to their right and lower
+// neighbours, which has no transitive edges.
+func gridGraph(rows int, columns int) *DirectedGraph {
+	g := NewDirectedGraph()
+	g.Grow(rows*columns, 2*rows*columns)
+	for i := 0; i < rows*columns; i++ {
+		g.AddNode(i)
+	}
+	for i := 0; i < rows*columns; i++ {
+		if (i+1)%columns != 0 {
+			g.AddEdge(i, i+1)
+		}
+		if i+columns < rows*columns {
+			g.AddEdge(i, i+columns)
+		}
+	}
+	return g
+}
+
+func TestWithoutTransitiveReduction(t *testing.T) {
+	reduced := layeredGraph(200, 3).Copy()
+	reduced.RemoveTransitives()
+	for name, g := range map[string]*DirectedGraph{"grid": gridGraph(20, 30), "stacked": stackedGraph(6, 50, 3), "reduced": reduced} {
+		want, err := NewCoffmanGrahamSorter(g, 4).Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		edges := g.EdgeCount()
+		s, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(4), WithoutTransitiveReduction())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: skipping the reduction changed the layers", name)
+		}
+		if g.EdgeCount() != edges {
+			t.Errorf("%s: sorting modified the graph", name)
+		}
+	}
+}
+
+// stackedGraph returns layers of nodes pointing to random nodes of the next
+// layer only, which has no transitive edges.
+func stackedGraph(layers int, width int, degree int) *DirectedGraph {
+	rng := rand.New(rand.NewSource(int64(width)))
+	g := NewDirectedGraph()
+	g.Grow(layers*width, (layers-1)*width*degree)
+	for i := 0; i < layers*width; i++ {
+		g.AddNode(i)
+	}
+	for i := 0; i < (layers-1)*width; i++ {
+		next := (i/width + 1) * width
+		for j := 0; j < degree; j++ {
+			g.AddEdge(i, next+rng.Intn(width))
+		}
+	}
+	return g
+}
+
+func BenchmarkWithoutTransitiveReduction(b *testing.B) {
+	// 500k edges
+	g := stackedGraph(5, 62500, 2)
+	for _, bench := range []struct {
+		name string
+		opts []SorterOption
+	}{
+		{"default", []SorterOption{WithWidth(8)}},
+		{"skipped", []SorterOption{WithWidth(8), WithoutTransitiveReduction()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				s, err := NewCoffmanGrahamSorterWithOptions(g, bench.opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := s.Sort(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestReducedGraph(t *testing.T) {
+	g := layeredGraph(60, 3)
+	type reducer interface {
+		ReducedGraph() *DirectedGraph
+		RemovedTransitiveEdges() []Edge
+	}
+	cg := NewCoffmanGrahamSorter(g, 3)
+	opt := NewOptimizedCoffmanGrahamSorter(g, 3)
+	sorters := map[string]struct {
+		reducer
+		sort func() ([][]Node, error)
+	}{
+		"coffman-graham": {cg, cg.Sort},
+		"optimized":      {opt, opt.EventSort},
+	}
+	for name, s := range sorters {
+		if s.ReducedGraph() != nil || s.RemovedTransitiveEdges() != nil {
+			t.Errorf("%s: got a reduced graph before sorting", name)
+		}
+		if _, err := s.sort(); err != nil {
+			t.Fatal(err)
+		}
+		reduced, removed := s.ReducedGraph(), s.RemovedTransitiveEdges()
+		if len(removed) == 0 {
+			t.Fatalf("%s: no transitive edges were removed", name)
+		}
+
+		edges := make(map[Edge]bool, g.EdgeCount())
+		for _, from := range reduced.Nodes() {
+			for _, to := range reduced.OutgoingEdges(from) {
+				edges[Edge{from, to}] = true
+			}
+		}
+		for _, edge := range removed {
+			if edges[edge] {
+				t.Errorf("%s: the removed edge %v is still in the reduced graph", name, edge)
+			}
+			edges[edge] = true
+		}
+		for _, from := range g.Nodes() {
+			for _, to := range g.OutgoingEdges(from) {
+				if !edges[Edge{from, to}] {
+					t.Errorf("%s: the edge %v->%v is neither reduced nor removed", name, from, to)
+				}
+				delete(edges, Edge{from, to})
+			}
+		}
+		for edge := range edges {
+			t.Errorf("%s: the edge %v is not in the graph", name, edge)
+		}
+	}
+}
+
+func TestLayerOrderIsStable(t *testing.T) {
+	build := func() *DirectedGraph {
+		random := rand.New(rand.NewSource(7))
+		g := NewDirectedGraph()
+		for i := 0; i < 200; i++ {
+			from, to := random.Intn(80), random.Intn(80)
+			if from < to {
+				g.AddEdge(from, to)
+			}
+		}
+		return g
+	}
+	want, err := NewCoffmanGrahamSorter(build(), 4).Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for run := 0; run < 20; run++ {
+		got, err := NewCoffmanGrahamSorter(build(), 4).Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d sorted to %v, the first run to %v", run, got, want)
+		}
+	}
+}
+
+func TestWithLayerOrder(t *testing.T) {
+	g := layeredGraph(60, 3)
+	descending := func(a, b Node) bool { return a.(int) > b.(int) }
+	sorter, err := NewCoffmanGrahamSorterWithOptions(g, WithWidth(4), WithLayerOrder(descending))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := sorter.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := NewCoffmanGrahamSorter(g, 4).Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d layers, want %d", len(got), len(want))
+	}
+	for level, layer := range want {
+		sorted := copyNodes(layer)
+		sort.SliceStable(sorted, func(i, j int) bool { return descending(sorted[i], sorted[j]) })
+		if !reflect.DeepEqual(got[level], sorted) {
+			t.Errorf("layer %d is %v, want %v", level, got[level], sorted)
+		}
+	}
+}
+
+func TestDeferredAtLayer(t *testing.T) {
+	// two ranks of three nodes, every node of the second rank depending on
+	// every node of the first one
+	g := NewDirectedGraph()
+	for _, from := range []Node{"a0", "a1", "a2"} {
+		for _, to := range []Node{"b0", "b1", "b2"} {
+			g.AddEdge(from, to)
+		}
+	}
+	cg := NewCoffmanGrahamSorter(g, 1)
+	opt := NewOptimizedCoffmanGrahamSorter(g, 1)
+	sorters := map[string]struct {
+		sort     func() ([][]Node, error)
+		deferred func(level int) []Node
+	}{
+		"coffman-graham": {cg.Sort, cg.DeferredAtLayer},
+		"optimized":      {opt.EventSort, opt.DeferredAtLayer},
+	}
+	for name, s := range sorters {
+		layers, err := s.sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the first node of each rank takes the layer its dependencies allow,
+		// and the other two are deferred from it
+		for level, rank := range map[int]string{0: "a", 3: "b"} {
+			deferred := s.deferred(level)
+			if len(deferred) != 2 {
+				t.Errorf("%s: deferred %v at level %d, want two nodes of rank %s", name, deferred, level, rank)
+			}
+			for _, node := range deferred {
+				if node.(string)[:1] != rank || reflect.DeepEqual(layers[level], []Node{node}) {
+					t.Errorf("%s: %v was deferred at level %d holding %v", name, node, level, layers[level])
+				}
+			}
+		}
+		for _, level := range []int{1, 2, 4, 5, 6} {
+			if deferred := s.deferred(level); len(deferred) != 0 {
+				t.Errorf("%s: deferred %v at level %d, want none", name, deferred, level)
+			}
+		}
+	}
+}
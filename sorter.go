@@ -0,0 +1,22 @@
+package graff
+
+// TopologicalSorter orders a graph's nodes so that every node comes after
+// the nodes it depends on.
+type TopologicalSorter interface {
+	Sort() ([]Node, error)
+}
+
+// LayeredSorter arranges a graph's nodes into a sequence of layers so that
+// every node is placed in a later layer than the nodes it depends on.
+type LayeredSorter interface {
+	Sort() ([][]Node, error)
+}
+
+var (
+	_ TopologicalSorter = (*DFSSorter)(nil)
+	_ TopologicalSorter = (*KahnSorter)(nil)
+	_ TopologicalSorter = (*RandomSorter)(nil)
+	_ LayeredSorter     = (*CoffmanGrahamSorter)(nil)
+	_ LayeredSorter     = (*OptimizedCoffmanGrahamSorter)(nil)
+	_ LayeredSorter     = (*PriorityListScheduler)(nil)
+)
@@ -0,0 +1,109 @@
+package graff
+
+import (
+	"reflect"
+	"testing"
+)
+
+// reverseTieSorter orders the graph's nodes with Kahn's algorithm, emitting
+// the ready node which comes last in the default depth-first order first.
+type reverseTieSorter struct {
+	graph *DirectedGraph
+}
+
+func (s reverseTieSorter) Sort() ([]Node, error) {
+	dfs, err := s.graph.DFSSort()
+	if err != nil {
+		return nil, err
+	}
+	rank := make(map[Node]int, len(dfs))
+	for i, node := range dfs {
+		rank[node] = i
+	}
+	return NewKahnSorter(s.graph, func(a, b Node) bool {
+		return rank[a] > rank[b]
+	}).Sort()
+}
+
+func TestWithTopologicalSorter(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := 0; i < 4; i++ {
+		g.AddEdge(i, 4)
+	}
+	g.AddEdge(4, 5)
+	g.AddEdge(6, 5)
+
+	level := func(opts ...SorterOption) [][]Node {
+		t.Helper()
+		sorter, err := NewCoffmanGrahamSorterWithOptions(g, append(opts, WithWidth(2))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		layers, err := sorter.Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Invariants(g, WithLayering(layers, 2)); err != nil {
+			t.Fatal(err)
+		}
+		return layers
+	}
+
+	var injected int
+	reversed := level(WithTopologicalSorter(func(graph *DirectedGraph) TopologicalSorter {
+		injected++
+		return reverseTieSorter{graph: graph}
+	}))
+	if injected != 1 {
+		t.Errorf("the factory was called %d times, want once", injected)
+	}
+	if layers := level(); reflect.DeepEqual(layers, reversed) {
+		t.Errorf("both orderings gave the layering %v", layers)
+	}
+}
+
+func TestLayeredSorters(t *testing.T) {
+	g := layeredGraph(30, 2)
+	sorters := map[string]LayeredSorter{
+		"coffman-graham": NewCoffmanGrahamSorter(g, 3),
+		"optimized":      NewOptimizedCoffmanGrahamSorter(g, 3),
+	}
+	for name, sorter := range sorters {
+		layers, err := sorter.Sort()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Invariants(g, WithLayering(layers, 3)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestWithTieBreakRespectsCausality(t *testing.T) {
+	// b happens after a, although its timestamp is the earliest; without the
+	// tie-break c rather than e would share the first layer with d
+	timestamps := map[Node]int{"a": 5, "b": 0, "c": 3, "d": 2, "e": 1}
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	for _, node := range []Node{"e", "d", "c"} {
+		g.AddNode(node)
+	}
+	s, err := NewOptimizedCoffmanGrahamSorterWithOptions(g, WithWidth(2), WithTieBreak(func(a, b Node) bool {
+		return timestamps[a] < timestamps[b]
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.EventSort(); err != nil {
+		t.Fatal(err)
+	}
+	// the optimized sorter keeps the layers in insertion order, so compare
+	// the levels
+	levels := make(map[Node]int)
+	for node := range timestamps {
+		levels[node], _ = s.LevelOf(node)
+	}
+	if want := map[Node]int{"e": 0, "d": 0, "c": 1, "a": 1, "b": 2}; !reflect.DeepEqual(levels, want) {
+		t.Errorf("got the levels %v, want %v", levels, want)
+	}
+}
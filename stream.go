@@ -0,0 +1,106 @@
+package graff
+
+import (
+	"context"
+)
+
+// SortStream topologically sorts the graph's nodes like Sort, but delivers
+// them over a channel as soon as each one is known to be in its final place.
+// Depth-first search only finalizes nodes in reverse order, so the streamed
+// order is the one produced by Kahn's algorithm with ties broken by
+// insertion order (see KahnSorter) rather than the order returned by Sort.
+// Cycles are detected before anything is streamed.
+//
+// Both channels are closed when the sort completes, fails, or the context is
+// cancelled; at most one error is delivered. The graph must not be mutated
+// while the stream is being consumed.
+func (s *DFSSorter) SortStream(ctx context.Context) (<-chan Node, <-chan error) {
+	nodes := make(chan Node)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(nodes)
+		defer close(errs)
+
+		graph, err := snapshot(s.graph)
+		if err != nil {
+			errs <- err
+			return
+		}
+		if cycle := graph.findCycle(); cycle != nil {
+			errs <- &CyclicGraphError{Cycle: cycle}
+			return
+		}
+
+		sorter := NewKahnSorter(graph, nil)
+		err = sorter.walk(func(node Node) error {
+			select {
+			case nodes <- node:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return nodes, errs
+}
+
+// SortStream levels the graph's nodes like Sort, but delivers each layer over
+// a channel as soon as it is complete. As any later node may still fill a
+// gap in an earlier layer, a layer is only complete once it has reached the
+// width, or every capacity (see WithCapacities), and all layers before it
+// were delivered; the remaining layers are
+// delivered when the sort finishes. The empty layers below the base level are
+// delivered right away.
+//
+// Both channels are closed when the sort completes, fails, or the context is
+// cancelled; at most one error is delivered. The levels of the nodes assigned
+// before the context was cancelled are kept, so the layers already delivered
+// remain valid and a later Sort continues from them. The graph must not be
+// mutated while the stream is being consumed.
+func (s *CoffmanGrahamSorter) SortStream(ctx context.Context) (<-chan []Node, <-chan error) {
+	layers := make(chan []Node)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(layers)
+		defer close(errs)
+
+		sent := 0
+		send := func(layer []Node) error {
+			select {
+			case layers <- s.config.orderLayer(copyNodes(layer)):
+				sent++
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		sorted, err := s.sort(func(current [][]Node) error {
+			for sent < len(current) && (sent < s.floor || s.full(sent)) {
+				if err := send(current[sent]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		for sent < len(sorted) {
+			if err := send(sorted[sent]); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	return layers, errs
+}
@@ -0,0 +1,121 @@
+package graff
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDFSSortStream(t *testing.T) {
+	g := layeredGraph(100, 3)
+	want, err := g.LexTopoSort(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, errs := NewDFSSorter(g).SortStream(context.Background())
+	got := make([]Node, 0, len(want))
+	for node := range nodes {
+		got = append(got, node)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("streamed %v, want the Kahn order %v", got, want)
+	}
+
+	g.AddEdge(99, 0)
+	nodes, errs = NewDFSSorter(g).SortStream(context.Background())
+	if _, ok := <-nodes; ok {
+		t.Error("a node of a cyclic graph was streamed")
+	}
+	var cyclic *CyclicGraphError
+	if err := <-errs; !errors.As(err, &cyclic) {
+		t.Errorf("got %v, want a CyclicGraphError", err)
+	}
+}
+
+func TestCoffmanGrahamSortStream(t *testing.T) {
+	g := layeredGraph(100, 3)
+	want, err := NewCoffmanGrahamSorter(g, 4).Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, errs := NewCoffmanGrahamSorter(g, 4).SortStream(context.Background())
+	got := make([][]Node, 0, len(want))
+	for layer := range layers {
+		got = append(got, layer)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("streamed %v, want %v", got, want)
+	}
+}
+
+// TestSortStreamCancel abandons both streams after their first item and
+// checks that cancelling releases their goroutines.
+func TestSortStreamCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+	g := layeredGraph(500, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	nodes, nodeErrs := NewDFSSorter(g).SortStream(ctx)
+	layers, layerErrs := NewCoffmanGrahamSorter(g, 2).SortStream(ctx)
+	<-nodes
+	<-layers
+	cancel()
+	if err := <-nodeErrs; !errors.Is(err, context.Canceled) {
+		t.Errorf("the node stream ended with %v, want context.Canceled", err)
+	}
+	if err := <-layerErrs; !errors.Is(err, context.Canceled) {
+		t.Errorf("the layer stream ended with %v, want context.Canceled", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines are left running", runtime.NumGoroutine()-before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoffmanGrahamSortStreamKeepsDeliveredLayers(t *testing.T) {
+	g := layeredGraph(60, 2)
+	s := NewCoffmanGrahamSorter(g, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	layers, errs := s.SortStream(ctx)
+	first := <-layers
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("the stream ended with %v, want context.Canceled", err)
+	}
+	for range layers {
+	}
+
+	// a new root placed above the delivered layer must not move it
+	for _, node := range first {
+		g.AddEdge("late", node)
+	}
+	sorted, err := s.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sorted[0]) != len(first) {
+		t.Fatalf("the first layer became %v, delivered %v", sorted[0], first)
+	}
+	for i, node := range first {
+		if sorted[0][i] != node {
+			t.Fatalf("the first layer became %v, delivered %v", sorted[0], first)
+		}
+	}
+	if violations := s.CheckConsistency(); len(violations) != len(first) {
+		t.Errorf("%d edges violate the levels, want the %d from the late root", len(violations), len(first))
+	}
+}
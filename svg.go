@@ -0,0 +1,129 @@
+package graff
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+)
+
+// RenderOption configures the output of RenderLayeredSVG.
+type RenderOption func(*renderConfig)
+
+type renderConfig struct {
+	cellWidth, cellHeight float64
+	colorAttr             string
+	orthogonal            bool
+	points                map[Node]Point
+}
+
+// WithCellSize sets the size of the grid cell every node is drawn in.
+// The default is 120 by 80.
+func WithCellSize(width float64, height float64) RenderOption {
+	return func(c *renderConfig) {
+		c.cellWidth = width
+		c.cellHeight = height
+	}
+}
+
+// WithColorAttr fills the node boxes with the color held by the node
+// attribute, e.g. "red" or "#ff0000". Nodes without the attribute are white.
+func WithColorAttr(key string) RenderOption {
+	return func(c *renderConfig) {
+		c.colorAttr = key
+	}
+}
+
+// WithOrthogonalEdges draws the edges as horizontal and vertical segments
+// rather than straight lines.
+func WithOrthogonalEdges() RenderOption {
+	return func(c *renderConfig) {
+		c.orthogonal = true
+	}
+}
+
+// WithCoordinates positions the nodes horizontally at the x-coordinates
+// assigned by AssignCoordinates, in units of cells, rather than by their
+// index within the layer.
+func WithCoordinates(points map[Node]Point) RenderOption {
+	return func(c *renderConfig) {
+		c.points = points
+	}
+}
+
+// RenderLayeredSVG draws the layers, as returned by CoffmanGrahamSort, as an
+// SVG image for debugging: every layer is a row, and every node a box in the
+// column of its index within the layer labelled by its fmt.Sprint
+// representation. Edges between the nodes of the layers are drawn as arrows,
+// edges to nodes missing from the layers are left out.
+func (g *DirectedGraph) RenderLayeredSVG(w io.Writer, layers [][]Node, opts ...RenderOption) error {
+	config := &renderConfig{cellWidth: 120, cellHeight: 80}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	positions := make(map[Node]Point, g.NodeCount())
+	columns := 0.0
+	for layer, nodes := range layers {
+		for column, node := range nodes {
+			x := float64(column)
+			if point, ok := config.points[node]; ok {
+				x = point.X
+			}
+			positions[node] = Point{X: x, Layer: layer}
+			if x+1 > columns {
+				columns = x + 1
+			}
+		}
+	}
+	centre := func(node Node) (float64, float64) {
+		p := positions[node]
+		return (p.X + 0.5) * config.cellWidth, (float64(p.Layer) + 0.5) * config.cellHeight
+	}
+	boxWidth, boxHeight := config.cellWidth*0.8, config.cellHeight*0.5
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%g\" height=\"%g\">\n", columns*config.cellWidth, float64(len(layers))*config.cellHeight)
+	b.WriteString("\t<defs><marker id=\"arrow\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"6\" markerHeight=\"6\" orient=\"auto\"><path d=\"M 0 0 L 10 5 L 0 10 z\"/></marker></defs>\n")
+
+	for _, nodes := range layers {
+		for _, from := range nodes {
+			g.EachOutgoing(from, func(to Node) bool {
+				if _, ok := positions[to]; !ok {
+					return true
+				}
+				x1, y1 := centre(from)
+				x2, y2 := centre(to)
+				// leave the box on the side facing the target
+				if y2 >= y1 {
+					y1, y2 = y1+boxHeight/2, y2-boxHeight/2
+				} else {
+					y1, y2 = y1-boxHeight/2, y2+boxHeight/2
+				}
+				if config.orthogonal {
+					mid := (y1 + y2) / 2
+					fmt.Fprintf(b, "\t<path d=\"M %g %g V %g H %g V %g\" fill=\"none\" stroke=\"black\" marker-end=\"url(#arrow)\"/>\n", x1, y1, mid, x2, y2)
+				} else {
+					fmt.Fprintf(b, "\t<line x1=\"%g\" y1=\"%g\" x2=\"%g\" y2=\"%g\" stroke=\"black\" marker-end=\"url(#arrow)\"/>\n", x1, y1, x2, y2)
+				}
+				return true
+			})
+		}
+	}
+
+	for _, nodes := range layers {
+		for _, node := range nodes {
+			x, y := centre(node)
+			fill := "white"
+			if config.colorAttr != "" {
+				if value, ok := g.NodeAttr(node, config.colorAttr); ok {
+					fill = fmt.Sprint(value)
+				}
+			}
+			fmt.Fprintf(b, "\t<rect x=\"%g\" y=\"%g\" width=\"%g\" height=\"%g\" fill=\"%s\" stroke=\"black\"/>\n", x-boxWidth/2, y-boxHeight/2, boxWidth, boxHeight, html.EscapeString(fill))
+			fmt.Fprintf(b, "\t<text x=\"%g\" y=\"%g\" text-anchor=\"middle\" dominant-baseline=\"middle\">%s</text>\n", x, y, html.EscapeString(fmt.Sprint(node)))
+		}
+	}
+	b.WriteString("</svg>\n")
+	return b.Flush()
+}
@@ -0,0 +1,33 @@
+package graff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderLayeredSVG(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("fetch", "build")
+	g.AddEdge("fetch", "lint")
+	g.AddEdge("build", "a&b")
+	g.AddEdge("lint", "a&b")
+	g.SetNodeAttr("build", "color", "#ffcc00")
+	layers, err := g.CoffmanGrahamSort(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := g.RenderLayeredSVG(&b, layers, WithCellSize(100, 60), WithColorAttr("color"), WithOrthogonalEdges()); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "layered.svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("wrote\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,106 @@
+package graff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LabelOption configures how the Pajek and TGF readers and writers map
+// between nodes and their labels.
+type LabelOption func(*labelConfig)
+
+type labelConfig struct {
+	node  func(label string) Node
+	label func(node Node) string
+}
+
+// WithLabelMapper converts the labels read to nodes using the function,
+// rather than using the labels themselves as string nodes. Labels mapping
+// to the same node are rejected like duplicate labels.
+func WithLabelMapper(node func(label string) Node) LabelOption {
+	return func(c *labelConfig) {
+		c.node = node
+	}
+}
+
+// WithNodeLabeler derives the labels written from the nodes using the
+// function, rather than using their fmt.Sprint representation.
+func WithNodeLabeler(label func(node Node) string) LabelOption {
+	return func(c *labelConfig) {
+		c.label = label
+	}
+}
+
+func newLabelConfig(opts []LabelOption) *labelConfig {
+	config := &labelConfig{
+		node:  func(label string) Node { return label },
+		label: func(node Node) string { return fmt.Sprint(node) },
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// labeledNodes assigns nodes to the labels read, rejecting duplicates.
+type labeledNodes struct {
+	config *labelConfig
+	owners map[Node]string
+}
+
+func newLabeledNodes(config *labelConfig) *labeledNodes {
+	return &labeledNodes{
+		config: config,
+		owners: make(map[Node]string),
+	}
+}
+
+// node returns the label's node, or an error wrapping ErrDuplicateNode if
+// another label was mapped to the same node.
+func (n *labeledNodes) node(label string) (Node, error) {
+	node := n.config.node(label)
+	if owner, ok := n.owners[node]; ok {
+		return nil, fmt.Errorf("%w: %q and %q both map to %v", ErrDuplicateNode, owner, label, node)
+	}
+	n.owners[node] = label
+	return node, nil
+}
+
+// lineReader reads a text format line by line, numbering the lines from 1
+// and trimming surrounding whitespace including the carriage returns of
+// Windows line endings.
+type lineReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{scanner: bufio.NewScanner(r)}
+}
+
+// next returns the next line and whether there was one.
+func (r *lineReader) next() (string, bool) {
+	if !r.scanner.Scan() {
+		return "", false
+	}
+	r.line++
+	return strings.TrimSpace(r.scanner.Text()), true
+}
+
+// err returns the error which stopped the reader, if any.
+func (r *lineReader) err() error {
+	return r.scanner.Err()
+}
+
+// writableLabel returns the node's label, or an error wrapping
+// ErrUnsupportedNode if it contains a line break or any of the forbidden
+// characters, which the format has no way of escaping.
+func writableLabel(config *labelConfig, node Node, forbidden string) (string, error) {
+	label := config.label(node)
+	if strings.ContainsAny(label, "\r\n"+forbidden) {
+		return "", fmt.Errorf("%w: the label %q of %v cannot be written", ErrUnsupportedNode, label, node)
+	}
+	return label, nil
+}
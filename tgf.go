@@ -0,0 +1,138 @@
+package graff
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Errors relating to the Trivial Graph Format.
+var (
+	ErrInvalidTGF = errors.New("The data is not in the Trivial Graph Format")
+)
+
+// ParseTGF reads a graph in the Trivial Graph Format used by yEd, which
+// lists one node per line as an id followed by an optional label, e.g.
+// 1 first node, then a line holding a single #, then one edge per line as
+// the ids of its source and target followed by an optional label, which is
+// stored as the edge's label attribute. Labels become string nodes unless
+// WithLabelMapper is used, and nodes without a label are labeled with their
+// id. Nodes are added in the order they are listed.
+//
+// An error wrapping ErrInvalidTGF, including the line number, is returned
+// if the data is malformed, e.g. an edge references an id which was not
+// listed, or one wrapping ErrDuplicateNode if two nodes map to the same
+// node.
+func ParseTGF(r io.Reader, opts ...LabelOption) (*DirectedGraph, error) {
+	config := newLabelConfig(opts)
+	lines := newLineReader(r)
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: line %d: %s", ErrInvalidTGF, lines.line, fmt.Sprintf(format, args...))
+	}
+
+	g := NewDirectedGraph()
+	owners := newLabeledNodes(config)
+	nodes := make(map[string]Node)
+	edges := false
+
+	for {
+		line, ok := lines.next()
+		if !ok {
+			break
+		}
+		if line == "" {
+			continue
+		}
+		if line == "#" {
+			if edges {
+				return nil, fail("duplicate # separator")
+			}
+			edges = true
+			continue
+		}
+
+		if !edges {
+			id, label := tgfSplit(line)
+			if _, ok := nodes[id]; ok {
+				return nil, fail("duplicate id %q", id)
+			}
+			if label == "" {
+				label = id
+			}
+			node, err := owners.node(label)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lines.line, err)
+			}
+			nodes[id] = node
+			g.AddNode(node)
+			continue
+		}
+
+		id, rest := tgfSplit(line)
+		from, ok := nodes[id]
+		if !ok {
+			return nil, fail("unknown id %q", id)
+		}
+		id, label := tgfSplit(rest)
+		to, ok := nodes[id]
+		if !ok {
+			return nil, fail("unknown id %q", id)
+		}
+		g.AddEdge(from, to)
+		if label != "" {
+			g.SetEdgeAttr(from, to, "label", label)
+		}
+	}
+	if err := lines.err(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// tgfSplit splits the line into its first field and the trimmed rest.
+func tgfSplit(line string) (string, string) {
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, ""
+	}
+	return line[:i], strings.TrimSpace(line[i:])
+}
+
+// WriteTGF writes the graph in the Trivial Graph Format read by ParseTGF,
+// listing the nodes in insertion order with the ids 1 to n and their
+// labels, followed by the edges in the order of their source and then their
+// target, so the output is deterministic. Edges carrying a label attribute
+// are written with its fmt.Sprint representation.
+// Labels are the nodes' fmt.Sprint representation unless WithNodeLabeler is
+// used. If a label contains a line break, which cannot be written, an error
+// wrapping ErrUnsupportedNode is returned.
+func (g *DirectedGraph) WriteTGF(w io.Writer, opts ...LabelOption) error {
+	config := newLabelConfig(opts)
+	b := bufio.NewWriter(w)
+
+	nodes := g.Nodes()
+	ids := make(map[Node]int, len(nodes))
+	for i, node := range nodes {
+		label, err := writableLabel(config, node, "")
+		if err != nil {
+			return err
+		}
+		ids[node] = i + 1
+		fmt.Fprintf(b, "%d %s\n", i+1, label)
+	}
+
+	b.WriteString("#\n")
+	for _, from := range nodes {
+		for _, to := range g.OutgoingEdges(from) {
+			fmt.Fprintf(b, "%d %d", ids[from], ids[to])
+			if label, ok := g.EdgeAttr(from, to, "label"); ok {
+				text := strings.NewReplacer("\r", " ", "\n", " ").Replace(fmt.Sprint(label))
+				fmt.Fprintf(b, " %s", text)
+			}
+			b.WriteByte('\n')
+		}
+	}
+	return b.Flush()
+}
@@ -0,0 +1,111 @@
+package graff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTGFRoundTrip(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("fetch sources", "build")
+	g.AddEdge("build", "test")
+	g.AddEdge("fetch sources", "lint")
+	g.AddNode("release")
+	g.SetEdgeAttr("build", "test", "label", "on success")
+
+	var b bytes.Buffer
+	if err := g.WriteTGF(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "1 fetch sources\n2 build\n3 test\n4 lint\n5 release\n#\n1 2\n1 4\n2 3 on success\n"
+	if b.String() != want {
+		t.Errorf("wrote\n%s\nwant\n%s", b.String(), want)
+	}
+
+	read, err := ParseTGF(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRoundTrip(t, g, read)
+	if label, _ := read.EdgeAttr("build", "test", "label"); label != "on success" {
+		t.Errorf("read the edge label %v, want on success", label)
+	}
+}
+
+func TestTGFRoundTripMappedNodes(t *testing.T) {
+	g := layeredGraph(50, 3)
+	var b bytes.Buffer
+	if err := g.WriteTGF(&b, WithNodeLabeler(func(node Node) string { return fmt.Sprintf("n%d", node) })); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ParseTGF(&b, WithLabelMapper(func(label string) Node {
+		n, _ := strconv.Atoi(strings.TrimPrefix(label, "n"))
+		return n
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRoundTrip(t, g, read)
+}
+
+func TestParseTGFWindowsLineEndings(t *testing.T) {
+	data := "1   first node  \r\n\t2 second\r\n3\r\n \r\n#\r\n1  2\t depends on \r\n 2 3\r\n\r\n"
+	g, err := ParseTGF(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Node{"first node", "second", "3"}; !reflect.DeepEqual(g.Nodes(), want) {
+		t.Errorf("read the nodes %q, want %q", g.Nodes(), want)
+	}
+	if !g.EdgeExists("first node", "second") || !g.EdgeExists("second", "3") || g.EdgeCount() != 2 {
+		t.Errorf("read the edges of %v", g)
+	}
+	if label, _ := g.EdgeAttr("first node", "second", "label"); label != "depends on" {
+		t.Errorf("read the edge label %q, want %q", label, "depends on")
+	}
+}
+
+func TestParseTGFErrors(t *testing.T) {
+	cases := map[string]string{
+		"unknown id":   "1 a\n#\n1 2\n",
+		"duplicate id": "1 a\n1 b\n",
+		"two #":        "1 a\n#\n#\n",
+	}
+	for name, data := range cases {
+		if _, err := ParseTGF(strings.NewReader(data)); !errors.Is(err, ErrInvalidTGF) {
+			t.Errorf("%s: got %v, want ErrInvalidTGF", name, err)
+		}
+	}
+	if _, err := ParseTGF(strings.NewReader("1 a\n2 a\n")); !errors.Is(err, ErrDuplicateNode) {
+		t.Errorf("got %v for a duplicate label, want ErrDuplicateNode", err)
+	}
+	g := NewDirectedGraph()
+	g.AddNode("two\nlines")
+	if err := g.WriteTGF(&bytes.Buffer{}); !errors.Is(err, ErrUnsupportedNode) {
+		t.Errorf("got %v for a label with a line break, want ErrUnsupportedNode", err)
+	}
+}
+
+// checkRoundTrip reports the differences between the nodes and edges of the
+// graph read back and the graph written.
+func checkRoundTrip(t *testing.T, written *DirectedGraph, read *DirectedGraph) {
+	t.Helper()
+	if !reflect.DeepEqual(read.Nodes(), written.Nodes()) {
+		t.Errorf("read the nodes %v, want %v", read.Nodes(), written.Nodes())
+	}
+	if read.EdgeCount() != written.EdgeCount() {
+		t.Errorf("read %d edges, want %d", read.EdgeCount(), written.EdgeCount())
+	}
+	for _, from := range written.Nodes() {
+		for _, to := range written.OutgoingEdges(from) {
+			if !read.EdgeExists(from, to) {
+				t.Errorf("the edge %v -> %v was lost", from, to)
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to graph transformations.
+var (
+	ErrDuplicateNode = errors.New("The node already exists within the graph")
+)
+
+// MapNodes returns a new graph with the same structure in which every node
+// was replaced by the value returned by f. Node and edge attributes and
+// edge multiplicities carry over to the mapped nodes. If f returns the same
+// value for two distinct nodes, which would silently merge them, an error
+// wrapping ErrDuplicateNode is returned.
+func (g *DirectedGraph) MapNodes(f func(Node) Node) (*DirectedGraph, error) {
+	mapped := make(map[Node]Node, g.NodeCount())
+	origins := make(map[Node]Node, g.NodeCount())
+	for _, node := range g.Nodes() {
+		to := f(node)
+		if origin, ok := origins[to]; ok {
+			return nil, fmt.Errorf("%w: %v and %v both map to %v", ErrDuplicateNode, origin, node, to)
+		}
+		origins[to] = node
+		mapped[node] = to
+	}
+
+	result := NewDirectedGraph()
+	for _, node := range g.Nodes() {
+		result.AddNode(mapped[node])
+		result.copyNodeAttrs(g, node, mapped[node])
+	}
+	for _, from := range g.Nodes() {
+		for _, to := range g.OutgoingEdges(from) {
+			result.copyEdge(g, from, to, mapped[from], mapped[to])
+		}
+	}
+	return result, nil
+}
+
+// MapNodes returns a new event graph in which every node was replaced by
+// the value returned by f, see DirectedGraph.MapNodes.
+func (g *EventGraph) MapNodes(f func(Node) Node) (*EventGraph, error) {
+	mapped, err := g.DirectedGraph.MapNodes(f)
+	if err != nil {
+		return nil, err
+	}
+	return &EventGraph{mapped}, nil
+}
+
+// copyNodeAttrs sets the attributes of the source graph's node on the node
+// within this graph, without overwriting attributes already set.
+func (g *DirectedGraph) copyNodeAttrs(src *DirectedGraph, node Node, as Node) {
+	for key, value := range src.NodeAttrs(node) {
+		if _, ok := g.NodeAttr(as, key); !ok {
+			g.nodeAttrs.Set(as, key, value)
+		}
+	}
+}
+
+// copyEdge adds the source graph's edge as an edge between the specified
+// nodes, carrying over its multiplicity and attributes without overwriting
+// attributes already set.
+func (g *DirectedGraph) copyEdge(src *DirectedGraph, from Node, to Node, asFrom Node, asTo Node) {
+	g.AddEdge(asFrom, asTo)
+	for i := 1; i < src.EdgeMultiplicity(from, to); i++ {
+		g.AddEdgeCounted(asFrom, asTo)
+	}
+	g.copyEdgeAttrs(src, from, to, asFrom, asTo)
+}
+
+// copyEdgeAttrs sets the attributes of the source graph's edge on the edge
+// within this graph, without overwriting attributes already set.
+func (g *DirectedGraph) copyEdgeAttrs(src *DirectedGraph, from Node, to Node, asFrom Node, asTo Node) {
+	for key, value := range src.EdgeAttrs(from, to) {
+		if _, ok := g.EdgeAttr(asFrom, asTo, key); !ok {
+			g.edgeAttrs.Set(Edge{asFrom, asTo}, key, value)
+		}
+	}
+}
+
+// ReplaceNode substitutes the new node for the old one in place, keeping its
+// position in the node order, its edges with their multiplicities, and all
+// attributes. If the old node does not exist within the graph a
+// NodeNotFoundError is returned, and if the new one already does an error
+// wrapping ErrDuplicateNode. Replacing a node by itself has no effect.
+// The levels assigned by the incremental sorters are not updated, see
+// RenameNode on the sorters.
+func (g *DirectedGraph) ReplaceNode(old Node, new Node) error {
+	if err := g.checkNodes([]Node{old}); err != nil {
+		return err
+	}
+	if old == new {
+		return nil
+	}
+	if g.NodeExists(new) {
+		return fmt.Errorf("%w: %v", ErrDuplicateNode, new)
+	}
+
+	// the edges are stored by ID, so only their attributes need rekeying
+	rename := func(node Node) Node {
+		if node == old {
+			return new
+		}
+		return node
+	}
+	for _, to := range g.OutgoingEdges(old) {
+		g.edgeAttrs.Rename(Edge{old, to}, Edge{new, rename(to)})
+	}
+	for _, from := range g.IncomingEdges(old) {
+		if from != old {
+			g.edgeAttrs.Rename(Edge{from, old}, Edge{from, new})
+		}
+	}
+	g.edges.index.Rename(old, new)
+	g.nodes.Replace(old, new)
+	g.nodeAttrs.Rename(old, new)
+
+	g.modified()
+	g.notify(MutationReplaceNode, []Node{old, new}, nil)
+	return nil
+}
+
+// MergeOptions configures MergeNodesWithOptions.
+type MergeOptions struct {
+	// KeepSelfLoops keeps the self-loops resulting from edges between the
+	// merged nodes rather than dropping them.
+	KeepSelfLoops bool
+}
+
+// MergeNodes merges the from nodes into the into node, redirecting all of
+// their edges to it and removing them from the graph. Edges between the
+// merged nodes are dropped rather than turned into self-loops, and parallel
+// edges resulting from the merge collapse into one.
+// Attributes of the merged nodes and edges are combined, with those already
+// present on into winning conflicts. Merging a node into itself has no effect.
+// If any of the nodes does not exist within the graph ErrNodeNotFound is
+// returned and the graph is left unchanged.
+func (g *DirectedGraph) MergeNodes(into Node, from ...Node) error {
+	return g.MergeNodesWithOptions(MergeOptions{}, into, from...)
+}
+
+// MergeNodesWithOptions merges the from nodes into the into node like
+// MergeNodes, configured by the specified options.
+func (g *DirectedGraph) MergeNodesWithOptions(opts MergeOptions, into Node, from ...Node) error {
+	if err := g.checkNodes(append([]Node{into}, from...)); err != nil {
+		return err
+	}
+	merged := make(map[Node]bool, len(from))
+	for _, node := range from {
+		if node != into {
+			merged[node] = true
+		}
+	}
+
+	target := func(node Node) Node {
+		if merged[node] {
+			return into
+		}
+		return node
+	}
+
+	for _, node := range from {
+		if !merged[node] || !g.NodeExists(node) {
+			continue
+		}
+
+		for _, to := range copyNodes(g.OutgoingEdges(node)) {
+			if t := target(to); t != into || opts.KeepSelfLoops {
+				g.AddEdge(into, t)
+				g.copyEdgeAttrs(g, node, to, into, t)
+			}
+		}
+		for _, in := range copyNodes(g.IncomingEdges(node)) {
+			if t := target(in); t != into || opts.KeepSelfLoops {
+				g.AddEdge(t, into)
+				g.copyEdgeAttrs(g, in, node, t, into)
+			}
+		}
+
+		g.copyNodeAttrs(g, node, into)
+		g.RemoveNode(node)
+	}
+	return nil
+}
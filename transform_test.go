@@ -0,0 +1,192 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type service struct {
+	name   string
+	region string
+}
+
+func TestMapNodes(t *testing.T) {
+	api, db, cache := service{"api", "eu"}, service{"db", "eu"}, service{"cache", "us"}
+	g := NewDirectedGraph()
+	g.AddEdge(api, db)
+	g.AddEdge(api, cache)
+	g.AddEdgeCounted(cache, db)
+	g.AddEdgeCounted(cache, db)
+	g.SetNodeAttr(db, "color", "red")
+	g.SetEdgeAttr(api, db, WeightAttr, 3)
+
+	ids := make(map[Node]int)
+	anonymized, err := g.MapNodes(func(node Node) Node {
+		if _, ok := ids[node]; !ok {
+			ids[node] = len(ids)
+		}
+		return ids[node]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, mapping, err := AreIsomorphic(g, anonymized); err != nil || !ok {
+		t.Fatalf("the mapped graph is not isomorphic: %v", err)
+	} else if mapping[api] != ids[api] {
+		t.Errorf("api maps to %v, want %v", mapping[api], ids[api])
+	}
+	if color, _ := anonymized.NodeAttr(ids[db], "color"); color != "red" {
+		t.Errorf("the node's color is %v, want red", color)
+	}
+	if weight, _ := anonymized.EdgeAttr(ids[api], ids[db], WeightAttr); weight != 3 {
+		t.Errorf("the edge weighs %v, want 3", weight)
+	}
+	if got := anonymized.EdgeMultiplicity(ids[cache], ids[db]); got != 2 {
+		t.Errorf("the multiplicity is %d, want 2", got)
+	}
+
+	_, err = g.MapNodes(func(node Node) Node { return node.(service).region })
+	if !errors.Is(err, ErrDuplicateNode) {
+		t.Errorf("got %v when merging two nodes, want ErrDuplicateNode", err)
+	}
+
+	e := NewEventGraph()
+	e.AddEdge("later", "earlier")
+	mapped, err := e.MapNodes(func(node Node) Node { return node.(string) + "'" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mapped.EdgeExists("later'", "earlier'") {
+		t.Error("the event graph's edge was not mapped in its direction")
+	}
+}
+
+func TestReplaceNodeKeepsPlace(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "b")
+	g.AddEdgeCounted("b", "c")
+	g.AddEdgeCounted("b", "c")
+	g.SetEdgeAttr("a", "b", WeightAttr, 2)
+	g.SetNodeAttr("b", "color", "red")
+	s := NewCoffmanGrahamSorter(g, 1)
+	if _, err := s.Sort(); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []MutationEvent
+	g.OnMutate(func(event MutationEvent) {
+		events = append(events, event)
+	})
+	if err := g.ReplaceNode("b", "B"); err != nil {
+		t.Fatal(err)
+	}
+	if !s.RenameNode("b", "B") {
+		t.Error("b had no level")
+	}
+
+	if nodes := g.Nodes(); nodes[1] != "B" || g.NodeExists("b") {
+		t.Errorf("the nodes are %v", nodes)
+	}
+	if g.EdgeMultiplicity("B", "c") != 2 || !g.EdgeExists("a", "B") {
+		t.Error("the edges were not transferred")
+	}
+	if weight, _ := g.EdgeAttr("a", "B", WeightAttr); weight != 2 {
+		t.Errorf("the edge weighs %v, want 2", weight)
+	}
+	if color, _ := g.NodeAttr("B", "color"); color != "red" {
+		t.Errorf("the node's color is %v, want red", color)
+	}
+	if len(events) != 1 || events[0].Op != MutationReplaceNode || events[0].Nodes[0] != "b" || events[0].Nodes[1] != "B" {
+		t.Errorf("observed %v", events)
+	}
+	if err := g.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	layers, err := s.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 3 || layers[1][0] != "B" {
+		t.Errorf("the layers are %v", layers)
+	}
+
+	if err := g.ReplaceNode("b", "x"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("replacing a missing node returned %v", err)
+	}
+	if err := g.ReplaceNode("a", "c"); !errors.Is(err, ErrDuplicateNode) {
+		t.Errorf("replacing with an existing node returned %v", err)
+	}
+}
+
+func TestReplaceNodeAfterRemovals(t *testing.T) {
+	g := layeredGraph(100, 2)
+	for i := 0; i < 100; i += 3 {
+		g.RemoveNode(i)
+	}
+	// the new nodes reuse the freed IDs but are listed last
+	for i := 0; i < 10; i++ {
+		g.AddEdge(fmt.Sprint("new", i), 1)
+	}
+
+	before := copyNodes(g.Nodes())
+	for _, node := range before {
+		if err := g.ReplaceNode(node, fmt.Sprint("renamed ", node)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, node := range g.Nodes() {
+		if want := fmt.Sprint("renamed ", before[i]); node != want {
+			t.Fatalf("node %d is %v, want %v", i, node, want)
+		}
+	}
+	if err := g.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeNodes(t *testing.T) {
+	g := NewDirectedGraph()
+	g.AddEdge("a", "x")
+	g.AddEdge("a", "y")
+	g.AddEdge("x", "y")
+	g.AddEdge("y", "b")
+	g.AddEdge("x", "b")
+	g.SetNodeAttr("x", "color", "red")
+	g.SetNodeAttr("x", "shape", "box")
+	g.SetNodeAttr("y", "color", "blue")
+
+	if err := g.MergeNodes("x", "y"); err != nil {
+		t.Fatal(err)
+	}
+	if g.NodeExists("y") || g.EdgeExists("x", "x") {
+		t.Error("y was not merged away, or left a self-loop")
+	}
+	if g.EdgeCount() != 2 || !g.EdgeExists("a", "x") || !g.EdgeExists("x", "b") {
+		t.Errorf("got the edges %v, want a -> x -> b", g.AdjacencyMatrix())
+	}
+	if color, _ := g.NodeAttr("x", "color"); color != "red" {
+		t.Errorf("the merged color is %v, want into's red", color)
+	}
+	if err := g.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	g.AddEdge("b", "c")
+	if err := g.MergeNodesWithOptions(MergeOptions{KeepSelfLoops: true}, "b", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if !g.EdgeExists("b", "b") {
+		t.Error("KeepSelfLoops dropped the self-loop")
+	}
+
+	before := g.AdjacencyMatrix()
+	if err := g.MergeNodes("a", "a"); err != nil || !reflect.DeepEqual(g.AdjacencyMatrix(), before) {
+		t.Errorf("merging a node into itself changed the graph from %v: %v", before, err)
+	}
+	if err := g.MergeNodes("a", "missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("got %v, want ErrNodeNotFound", err)
+	}
+}
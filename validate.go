@@ -0,0 +1,91 @@
+package graff
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors relating to graph validation.
+var (
+	ErrInvalidGraph = errors.New("The graph is inconsistent")
+)
+
+// IsDAG determines whether the graph is a directed acyclic graph,
+// i.e. whether it can be topologically sorted.
+func (g *DirectedGraph) IsDAG() bool {
+	return g.findCycle() == nil
+}
+
+// findCycle searches the graph for a cycle using an iterative depth-first
+// search, returning the nodes along the first cycle found (in edge order)
+// or nil if the graph is acyclic.
+func (g *DirectedGraph) findCycle() []Node {
+	type frame struct {
+		node     Node
+		outgoing []Node
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[Node]int, g.NodeCount())
+	stack := make([]frame, 0)
+
+	for _, root := range g.Nodes() {
+		if state[root] != 0 {
+			continue
+		}
+
+		state[root] = visiting
+		stack = append(stack, frame{root, g.OutgoingEdges(root)})
+
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if len(top.outgoing) == 0 {
+				state[top.node] = visited
+				stack = stack[:len(stack)-1]
+				continue
+			}
+
+			next := top.outgoing[0]
+			top.outgoing = top.outgoing[1:]
+
+			switch state[next] {
+			case visited:
+				continue
+			case visiting:
+				// the cycle consists of the stack from next to the top
+				cycle := make([]Node, 0)
+				for i := len(stack) - 1; i >= 0; i-- {
+					cycle = append(cycle, stack[i].node)
+					if stack[i].node == next {
+						break
+					}
+				}
+				for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return cycle
+			}
+
+			state[next] = visiting
+			stack = append(stack, frame{next, g.OutgoingEdges(next)})
+		}
+	}
+	return nil
+}
+
+// Validate verifies the graph's internal invariants: every edge endpoint
+// exists within the graph, incoming and outgoing edges mirror each other,
+// and no node or edge is stored twice.
+// The returned error wraps ErrInvalidGraph and names the first inconsistency.
+func (g *DirectedGraph) Validate() error {
+	if err := g.nodes.validate(); err != nil {
+		return fmt.Errorf("%w: nodes: %v", ErrInvalidGraph, err)
+	}
+	if err := g.edges.validate(g.NodeExists); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGraph, err)
+	}
+	return nil
+}
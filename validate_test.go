@@ -0,0 +1,83 @@
+package graff
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIsDAG(t *testing.T) {
+	g := layeredGraph(50, 3)
+	if !g.IsDAG() {
+		t.Error("an acyclic graph is reported cyclic")
+	}
+	g.AddEdge(49, 0)
+	if g.IsDAG() {
+		t.Error("a cyclic graph is reported acyclic")
+	}
+
+	g = NewDirectedGraph()
+	g.AddEdge("a", "a")
+	if g.IsDAG() {
+		t.Error("a self-loop is reported acyclic")
+	}
+	if !NewDirectedGraph().IsDAG() {
+		t.Error("the empty graph is reported cyclic")
+	}
+}
+
+func TestValidateAfterMutations(t *testing.T) {
+	g := layeredGraph(200, 4)
+	if err := g.RemoveTransitives(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Validate(); err != nil {
+		t.Fatalf("after RemoveTransitives: %v", err)
+	}
+
+	removed := make([]Node, 0)
+	for i := 0; i < 200; i += 7 {
+		removed = append(removed, i)
+	}
+	g.RemoveNodes(removed...)
+	if err := g.Validate(); err != nil {
+		t.Fatalf("after RemoveNodes: %v", err)
+	}
+
+	// the freed IDs are reused by the new nodes
+	for i := 0; i < 20; i++ {
+		g.AddEdge(i*10+1, "new")
+	}
+	if err := g.Validate(); err != nil {
+		t.Fatalf("after reusing IDs: %v", err)
+	}
+}
+
+func TestValidateNamesInconsistency(t *testing.T) {
+	corruptions := map[string]struct {
+		corrupt func(l *directedEdgeList, a int32)
+		want    string
+	}{
+		"duplicate": {func(l *directedEdgeList, a int32) {
+			l.outgoing[a] = append(l.outgoing[a], l.outgoing[a][0])
+		}, "a -> b is listed twice"},
+		"unmirrored": {func(l *directedEdgeList, a int32) {
+			b, _ := l.index.Lookup("b")
+			l.incoming[b] = l.incoming[b][:0]
+		}, "0 are incoming"},
+		"unknown ID": {func(l *directedEdgeList, a int32) {
+			l.outgoing[a][0] = 99
+		}, "refers to a free ID"},
+	}
+	for name, c := range corruptions {
+		g := NewDirectedGraph()
+		g.AddEdge("a", "b")
+		g.AddNode("c")
+		a, _ := g.edges.index.Lookup("a")
+		c.corrupt(g.edges, a)
+		err := g.Validate()
+		if !errors.Is(err, ErrInvalidGraph) || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: got %v, want ErrInvalidGraph naming %q", name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package graff
+
+import (
+	"fmt"
+)
+
+// Directed is the read-only view of a directed graph consumed by the sorters
+// and schedulers, the ReadyTracker, the AncestorCache, the ReachabilityIndex,
+// the layering utilities and Invariants, so that they can run on other
+// storage, e.g. a database-backed adjacency.
+// An edge from one node to another means the former comes first.
+// DirectedGraph and FrozenGraph implement it. An EventGraph satisfies it as
+// well, but its EdgeExists uses the reversed orientation, so everything
+// accepting a Directed views an EventGraph through its DirectedGraph, where
+// an edge leads from an event to the later ones, as EventSort does. Sorters
+// given an EventGraph therefore work on the graph itself rather than a copy.
+//
+// Nodes must list every node once in a stable order, which determines the
+// order of the results, and the edge methods must agree with each other.
+// Algorithms which need more than the view, e.g. to remove transitive edges,
+// work on a copy made by NewDirectedGraphFrom.
+//
+// Implementations loading their edges on demand, like LazyGraph, may also
+// implement Prefetch(nodes []Node), which is called with nodes whose edges
+// are about to be needed so they can be loaded in batches, and Err() error,
+// which the sorters return as soon as it is not nil so that failures to load
+// propagate out of them.
+type Directed interface {
+	Nodes() []Node
+	NodeCount() int
+	NodeExists(node Node) bool
+	OutgoingEdges(node Node) []Node
+	IncomingEdges(node Node) []Node
+	EdgeExists(from Node, to Node) bool
+}
+
+var (
+	_ Directed = (*DirectedGraph)(nil)
+	_ Directed = (*FrozenGraph)(nil)
+)
+
+// directedView returns the DirectedGraph of an EventGraph, whose methods all
+// agree on the orientation, and any other graph as it is.
+func directedView(d Directed) Directed {
+	if e, ok := d.(*EventGraph); ok {
+		return e.DirectedGraph
+	}
+	return d
+}
+
+// NewDirectedGraphFrom copies the graph viewed as Directed into a new
+// DirectedGraph, keeping its node order and the order of its edges.
+func NewDirectedGraphFrom(d Directed) *DirectedGraph {
+	d = directedView(d)
+	nodes := d.Nodes()
+	prefetch(d, nodes)
+	g := NewDirectedGraph()
+	g.Grow(len(nodes), 0)
+	g.graph.AddNodes(nodes...)
+	for _, from := range nodes {
+		for _, to := range d.OutgoingEdges(from) {
+			g.edges.Add(from, to)
+		}
+	}
+	return g
+}
+
+// asDirectedGraph returns the graph itself if it is a DirectedGraph, the
+// DirectedGraph of an EventGraph, and a copy of its current state otherwise.
+func asDirectedGraph(d Directed) *DirectedGraph {
+	if g, ok := directedView(d).(*DirectedGraph); ok {
+		return g
+	}
+	return NewDirectedGraphFrom(d)
+}
+
+// snapshot returns the graph itself if it is a DirectedGraph, and a copy of
+// its current state otherwise, or the error reported by the graph.
+func snapshot(d Directed) (*DirectedGraph, error) {
+	g := asDirectedGraph(d)
+	if err := graphErr(d); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// guardOf returns a modificationGuard for the graph if it is a
+// DirectedGraph or an EventGraph, and otherwise one which fails once the
+// graph reports an error.
+func guardOf(d Directed) modificationGuard {
+	if g, ok := directedView(d).(*DirectedGraph); ok {
+		return g.guard()
+	}
+	if f, ok := d.(failer); ok {
+		return modificationGuard{view: f}
+	}
+	return modificationGuard{}
+}
+
+// failer is implemented by graphs which may fail to load their edges.
+type failer interface {
+	Err() error
+}
+
+// graphErr returns the error reported by the graph, if any.
+func graphErr(d Directed) error {
+	if f, ok := d.(failer); ok {
+		return f.Err()
+	}
+	return nil
+}
+
+// prefetcher is implemented by graphs which load their edges on demand.
+type prefetcher interface {
+	Prefetch(nodes []Node)
+}
+
+// prefetch hints that the edges of the nodes are about to be needed.
+func prefetch(d Directed, nodes []Node) {
+	if p, ok := d.(prefetcher); ok && len(nodes) > 0 {
+		p.Prefetch(nodes)
+	}
+}
+
+// prefetchOutgoing hints that the edges of the targets of the node's
+// outgoing edges are about to be needed.
+func prefetchOutgoing(d Directed, node Node) {
+	if p, ok := d.(prefetcher); ok {
+		if outgoing := d.OutgoingEdges(node); len(outgoing) > 0 {
+			p.Prefetch(outgoing)
+		}
+	}
+}
+
+// eachOutgoing calls fn for the targets of the node's outgoing edges until it
+// returns false, without allocating if the graph supports it.
+func eachOutgoing(d Directed, node Node, fn func(Node) bool) {
+	if g, ok := d.(interface {
+		EachOutgoing(node Node, fn func(Node) bool)
+	}); ok {
+		g.EachOutgoing(node, fn)
+		return
+	}
+	for _, to := range d.OutgoingEdges(node) {
+		if !fn(to) {
+			return
+		}
+	}
+}
+
+// eachIncoming calls fn for the sources of the node's incoming edges until it
+// returns false, without allocating if the graph supports it.
+func eachIncoming(d Directed, node Node, fn func(Node) bool) {
+	if g, ok := d.(interface {
+		EachIncoming(node Node, fn func(Node) bool)
+	}); ok {
+		g.EachIncoming(node, fn)
+		return
+	}
+	for _, from := range d.IncomingEdges(node) {
+		if !fn(from) {
+			return
+		}
+	}
+}
+
+// checkDirectedNodes returns an error wrapping ErrNodeNotFound for the first
+// node which does not exist within the graph.
+func checkDirectedNodes(d Directed, nodes []Node) error {
+	for _, node := range nodes {
+		if !d.NodeExists(node) {
+			return &NodeNotFoundError{Node: node}
+		}
+	}
+	return nil
+}
+
+// incomingEdgeCount returns the number of the node's incoming edges, without
+// allocating if the graph supports it.
+func incomingEdgeCount(d Directed, node Node) int {
+	if g, ok := d.(interface {
+		IncomingEdgeCount(node Node) int
+	}); ok {
+		return g.IncomingEdgeCount(node)
+	}
+	return len(d.IncomingEdges(node))
+}
+
+// rootNodes returns the nodes without incoming edges in the graph's order.
+func rootNodes(d Directed) []Node {
+	results := make([]Node, 0)
+	for _, node := range d.Nodes() {
+		if incomingEdgeCount(d, node) == 0 {
+			results = append(results, node)
+		}
+	}
+	return results
+}
+
+// cycleError returns a CyclicGraphError for a cycle of the graph, or the
+// error reported by the graph if its edges could not be loaded.
+func cycleError(d Directed) error {
+	if err := graphErr(d); err != nil {
+		return err
+	}
+	return asDirectedGraph(d).cycleError()
+}
+
+// validateView verifies that the graph lists every node once and that its
+// edge methods agree with each other, returning an error wrapping
+// ErrInvalidGraph for the first inconsistency.
+func validateView(d Directed) error {
+	nodes := d.Nodes()
+	if len(nodes) != d.NodeCount() {
+		return fmt.Errorf("%w: it lists %d nodes but counts %d", ErrInvalidGraph, len(nodes), d.NodeCount())
+	}
+	seen := make(map[Node]bool, len(nodes))
+	for _, node := range nodes {
+		if seen[node] {
+			return fmt.Errorf("%w: node %v is listed twice", ErrInvalidGraph, node)
+		}
+		if !d.NodeExists(node) {
+			return fmt.Errorf("%w: node %v is listed but does not exist", ErrInvalidGraph, node)
+		}
+		seen[node] = true
+	}
+	outgoing := 0
+	for _, from := range nodes {
+		for _, to := range d.OutgoingEdges(from) {
+			if !seen[to] {
+				return fmt.Errorf("%w: edge %v -> %v leads to a missing node", ErrInvalidGraph, from, to)
+			}
+			if !d.EdgeExists(from, to) {
+				return fmt.Errorf("%w: edge %v -> %v is outgoing but does not exist", ErrInvalidGraph, from, to)
+			}
+			outgoing++
+		}
+	}
+	incoming := 0
+	for _, to := range nodes {
+		for _, from := range d.IncomingEdges(to) {
+			if !seen[from] || !d.EdgeExists(from, to) {
+				return fmt.Errorf("%w: edge %v -> %v is incoming but not outgoing", ErrInvalidGraph, from, to)
+			}
+			incoming++
+		}
+	}
+	if incoming != outgoing {
+		return fmt.Errorf("%w: %d outgoing but %d incoming edges", ErrInvalidGraph, outgoing, incoming)
+	}
+	return graphErr(d)
+}
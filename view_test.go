@@ -0,0 +1,140 @@
+package graff
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// adjacency is a minimal Directed backed by maps, standing in for storage
+// other than a DirectedGraph.
+type adjacency struct {
+	nodes    []Node
+	outgoing map[Node][]Node
+	incoming map[Node][]Node
+}
+
+func newAdjacency(edges ...Edge) *adjacency {
+	a := &adjacency{outgoing: make(map[Node][]Node), incoming: make(map[Node][]Node)}
+	add := func(node Node) {
+		if !a.NodeExists(node) {
+			a.nodes = append(a.nodes, node)
+			a.outgoing[node] = nil
+		}
+	}
+	for _, edge := range edges {
+		add(edge.From)
+		add(edge.To)
+		a.outgoing[edge.From] = append(a.outgoing[edge.From], edge.To)
+		a.incoming[edge.To] = append(a.incoming[edge.To], edge.From)
+	}
+	return a
+}
+
+func (a *adjacency) Nodes() []Node                  { return append([]Node(nil), a.nodes...) }
+func (a *adjacency) NodeCount() int                 { return len(a.nodes) }
+func (a *adjacency) OutgoingEdges(node Node) []Node { return a.outgoing[node] }
+func (a *adjacency) IncomingEdges(node Node) []Node { return a.incoming[node] }
+
+func (a *adjacency) NodeExists(node Node) bool {
+	_, ok := a.outgoing[node]
+	return ok
+}
+
+func (a *adjacency) EdgeExists(from Node, to Node) bool {
+	for _, node := range a.outgoing[from] {
+		if node == to {
+			return true
+		}
+	}
+	return false
+}
+
+var diamond = []Edge{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}}
+
+func TestDirectedFakeSorters(t *testing.T) {
+	fake := newAdjacency(diamond...)
+	want := NewDirectedGraph()
+	for _, edge := range diamond {
+		want.AddEdge(edge.From, edge.To)
+	}
+
+	sorters := map[string]func() ([]Node, error){
+		"dfs":    NewDFSSorter(fake).Sort,
+		"kahn":   NewKahnSorter(fake, nil).Sort,
+		"random": NewRandomSorter(fake, rand.New(rand.NewSource(1))).Sort,
+	}
+	for name, sort := range sorters {
+		order, err := sort()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if ok, v := want.IsLinearExtension(order); !ok {
+			t.Errorf("%s: %v is not a topological order: %v", name, order, v)
+		}
+	}
+
+	layers, err := NewPriorityListScheduler(fake, 2, nil).Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Invariants(fake, WithLayering(layers, 2)); err != nil {
+		t.Error(err)
+	}
+
+	tracker := NewReadyTracker(fake)
+	if roots := tracker.Roots(); len(roots) != 1 || roots[0] != "a" {
+		t.Errorf("roots are %v, want [a]", roots)
+	}
+	if ready := tracker.MarkDone("a"); len(ready) != 2 {
+		t.Errorf("marking a as done readied %v, want b and c", ready)
+	}
+
+	ancestors, err := NewAncestorCache(fake, 0).Get("d")
+	if err != nil || len(ancestors) != 3 {
+		t.Errorf("ancestors of d are %v, %v, want a, b and c", ancestors, err)
+	}
+
+	slice, in, out := SliceByLevels(fake, map[Node]int{"a": 0, "b": 1, "c": 1, "d": 2}, 1, 1)
+	if slice.NodeCount() != 2 || len(in) != 2 || len(out) != 2 {
+		t.Errorf("slice holds %d nodes, %d incoming and %d outgoing edges, want 2 each", slice.NodeCount(), len(in), len(out))
+	}
+}
+
+func TestDirectedFakeCycle(t *testing.T) {
+	fake := newAdjacency(Edge{"a", "b"}, Edge{"b", "a"})
+	if _, err := NewKahnSorter(fake, nil).Sort(); !errors.Is(err, ErrCyclicGraph) {
+		t.Errorf("got %v, want a CyclicGraphError", err)
+	}
+}
+
+func TestInvariantsInconsistentView(t *testing.T) {
+	fake := newAdjacency(diamond...)
+	fake.incoming["d"] = fake.incoming["d"][:1]
+	if err := Invariants(fake); !errors.Is(err, ErrInvalidGraph) {
+		t.Errorf("got %v, want an error wrapping ErrInvalidGraph", err)
+	}
+}
+
+func TestEventGraphIsNotCopied(t *testing.T) {
+	g := NewEventGraph()
+	g.AddHappensBefore("a", "b")
+	g.AddHappensBefore("b", "c")
+
+	if asDirectedGraph(g) != g.DirectedGraph {
+		t.Fatal("the event graph was copied")
+	}
+	order, err := NewKahnSorter(g, nil).Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, v := g.IsLinearExtension(order); !ok {
+		t.Errorf("%v is not a linear extension: %v", order, v)
+	}
+
+	guard := guardOf(g)
+	g.AddHappensBefore("c", "d")
+	if err := guard.check(); !errors.Is(err, ErrConcurrentModification) {
+		t.Errorf("got %v, want ErrConcurrentModification", err)
+	}
+}